@@ -18,6 +18,7 @@ type Account struct {
 	Address   string          `json:"address" gorm:"primaryKey"`
 	Keys      []keys.Storable `json:"keys" gorm:"foreignKey:AccountAddress;references:Address;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
 	Type      AccountType     `json:"type" gorm:"default:custodial"`
+	Payer     string          `json:"payer,omitempty" gorm:"column:payer"`
 	CreatedAt time.Time       `json:"createdAt" `
 	UpdatedAt time.Time       `json:"updatedAt"`
 	DeletedAt gorm.DeletedAt  `json:"-" gorm:"index"`
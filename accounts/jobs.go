@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
 	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/onflow/flow-go-sdk"
 	log "github.com/sirupsen/logrus"
@@ -12,6 +13,18 @@ import (
 
 const AccountCreateJobType = "account_create"
 
+// AccountJobResult is the typed result of account creation and key jobs.
+// The legacy result is the address, followed by ":<key count>" for key jobs.
+type AccountJobResult struct {
+	Address       string `json:"address"`
+	KeyCount      int    `json:"keyCount"`
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+type accountCreateJobAttributes struct {
+	Payer string `json:"payer,omitempty"`
+}
+
 func (s *ServiceImpl) executeAccountCreateJob(ctx context.Context, j *jobs.Job) error {
 	if j.Type != AccountCreateJobType {
 		return jobs.ErrInvalidJobType
@@ -19,7 +32,15 @@ func (s *ServiceImpl) executeAccountCreateJob(ctx context.Context, j *jobs.Job)
 
 	j.ShouldSendNotification = true
 
-	a, txID, err := s.createAccount(ctx)
+	// Jobs created before payers have no attributes
+	var attrs accountCreateJobAttributes
+	if len(j.Attributes) > 0 {
+		if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+			return err
+		}
+	}
+
+	a, txID, err := s.createAccount(ctx, attrs.Payer)
 	if err != nil {
 		return err
 	}
@@ -27,7 +48,7 @@ func (s *ServiceImpl) executeAccountCreateJob(ctx context.Context, j *jobs.Job)
 	j.TransactionID = txID
 	j.Result = a.Address
 
-	return nil
+	return j.SetTypedResult(AccountJobResult{Address: a.Address, KeyCount: len(a.Keys), TransactionID: txID})
 }
 
 const SyncAccountKeyCountJobType = "sync_account_key_count"
@@ -62,7 +83,7 @@ func (s *ServiceImpl) executeSyncAccountKeyCountJob(ctx context.Context, j *jobs
 	j.TransactionID = txID
 	j.Result = fmt.Sprintf("%s:%d", attrs.Address, numKeys)
 
-	return nil
+	return j.SetTypedResult(AccountJobResult{Address: flow_helpers.FormatAddress(attrs.Address), KeyCount: numKeys, TransactionID: txID})
 }
 
 const AddNewKeyJobType = "add_new_key"
@@ -95,7 +116,7 @@ func (s *ServiceImpl) executeAddNewKeyJob(ctx context.Context, j *jobs.Job) erro
 
 	j.Result = fmt.Sprintf("%s:%d", account.Address, len(account.Keys))
 
-	return nil
+	return j.SetTypedResult(AccountJobResult{Address: account.Address, KeyCount: len(account.Keys)})
 }
 	
 const RevokeKeyJobType = "revoke_key"
@@ -129,5 +150,5 @@ func (s *ServiceImpl) executeRevokeKeyJob(ctx context.Context, j *jobs.Job) erro
 
 	j.Result = fmt.Sprintf("%s:%d", account.Address, len(account.Keys))
 
-	return nil
+	return j.SetTypedResult(AccountJobResult{Address: account.Address, KeyCount: len(account.Keys)})
 }
@@ -25,18 +25,17 @@ import (
 	"go.uber.org/ratelimit"
 )
 
-const maxGasLimit = 9999
-
 type Service interface {
 	List(limit, offset int) (result []Account, err error)
-	Create(ctx context.Context, sync bool) (*jobs.Job, *Account, error)
+	Create(ctx context.Context, sync bool, payer string, opts ...jobs.JobOption) (*jobs.Job, *Account, error)
+	SetPayer(ctx context.Context, address, payer string) (Account, error)
 	AddNonCustodialAccount(address string) (*Account, error)
 	DeleteNonCustodialAccount(address string) error
 	SyncAccountKeyCount(ctx context.Context, address flow.Address) (*jobs.Job, error)
 	Details(address string) (Account, error)
 	InitAdminAccount(ctx context.Context) error
-	AddNewKey(ctx context.Context, address flow.Address) (*jobs.Job, error)
-	RevokeKey(ctx context.Context, address flow.Address, oldKeyIndex uint32) (*jobs.Job, error)
+	AddNewKey(ctx context.Context, address flow.Address, opts ...jobs.JobOption) (*jobs.Job, error)
+	RevokeKey(ctx context.Context, address flow.Address, oldKeyIndex uint32, opts ...jobs.JobOption) (*jobs.Job, error)
 	GetKeysByType(ctx context.Context, keyType string) ([]keys.Storable, error)
 }
 
@@ -75,10 +74,18 @@ func NewService(
 	}
 
 	// Register asynchronous job executors
-	wp.RegisterExecutor(AccountCreateJobType, svc.executeAccountCreateJob)
-	wp.RegisterExecutor(SyncAccountKeyCountJobType, svc.executeSyncAccountKeyCountJob)
-	wp.RegisterExecutor(AddNewKeyJobType, svc.executeAddNewKeyJob)
-	wp.RegisterExecutor(RevokeKeyJobType, svc.executeRevokeKeyJob)
+	wp.RegisterExecutor(AccountCreateJobType, svc.executeAccountCreateJob,
+		jobs.WithAttributesSchema(accountCreateJobAttributes{}),
+		jobs.WithResultSchema(AccountJobResult{}))
+	wp.RegisterExecutor(SyncAccountKeyCountJobType, svc.executeSyncAccountKeyCountJob,
+		jobs.WithAttributesSchema(syncAccountKeyCountJobAttributes{}),
+		jobs.WithResultSchema(AccountJobResult{}))
+	wp.RegisterExecutor(AddNewKeyJobType, svc.executeAddNewKeyJob,
+		jobs.WithAttributesSchema(addNewKeyJobAttributes{}),
+		jobs.WithResultSchema(AccountJobResult{}))
+	wp.RegisterExecutor(RevokeKeyJobType, svc.executeRevokeKeyJob,
+		jobs.WithAttributesSchema(revokeKeyJobAttributes{}),
+		jobs.WithResultSchema(AccountJobResult{}))
 
 	return svc
 }
@@ -92,12 +99,29 @@ func (s *ServiceImpl) List(limit, offset int) (result []Account, err error) {
 // Create calls account.New to generate a new account.
 // It receives a new account with a corresponding private key or resource ID
 // and stores both in datastore.
+// The payer pays for the account creation and becomes the default payer of
+// the account, the admin account pays if it is empty.
+// Job options only apply to asynchronous requests.
 // It returns a job, the new account and a possible error.
-func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Account, error) {
-	log.WithFields(log.Fields{"sync": sync}).Trace("Create account")
+func (s *ServiceImpl) Create(ctx context.Context, sync bool, payer string, opts ...jobs.JobOption) (*jobs.Job, *Account, error) {
+	log.WithFields(log.Fields{"sync": sync, "payer": payer}).Trace("Create account")
 
 	if !sync {
-		job, err := s.wp.CreateJob(AccountCreateJobType, "")
+		var attrOpts []jobs.JobOption
+		if payer != "" {
+			// Check the payer before scheduling
+			if _, err := s.km.PayerAuthorizer(ctx, payer); err != nil {
+				return nil, nil, err
+			}
+
+			attrBytes, err := json.Marshal(accountCreateJobAttributes{Payer: payer})
+			if err != nil {
+				return nil, nil, err
+			}
+			attrOpts = append(attrOpts, jobs.WithAttributes(attrBytes))
+		}
+
+		job, err := s.wp.CreateJob(AccountCreateJobType, "", append(attrOpts, opts...)...)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -110,7 +134,7 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Accoun
 		return job, nil, err
 	}
 
-	account, _, err := s.createAccount(ctx)
+	account, _, err := s.createAccount(ctx, payer)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -118,6 +142,30 @@ func (s *ServiceImpl) Create(ctx context.Context, sync bool) (*jobs.Job, *Accoun
 	return nil, account, nil
 }
 
+// SetPayer sets the default payer of an account, resetting it to the admin
+// account if payer is empty.
+func (s *ServiceImpl) SetPayer(ctx context.Context, address, payer string) (Account, error) {
+	log.WithFields(log.Fields{"address": address, "payer": payer}).Trace("Set account payer")
+
+	// Check if the input is a valid address
+	address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
+	if err != nil {
+		return Account{}, err
+	}
+
+	if payer != "" {
+		if _, err := s.km.PayerAuthorizer(ctx, payer); err != nil {
+			return Account{}, err
+		}
+	}
+
+	if err := s.store.UpdateAccountPayer(address, payer); err != nil {
+		return Account{}, err
+	}
+
+	return s.Details(address)
+}
+
 func (s *ServiceImpl) AddNonCustodialAccount(address string) (*Account, error) {
 	log.WithFields(log.Fields{"address": address}).Trace("Add non-custodial account")
 
@@ -296,7 +344,7 @@ func (s *ServiceImpl) syncAccountKeyCount(ctx context.Context, address flow.Addr
 		entry.WithFields(log.Fields{"args": args}).Debug("args prepared")
 
 		// NOTE: sync, so will wait for transaction to be sent & sealed
-		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, code, args, transactions.General)
+		_, tx, err := s.txs.Create(ctx, true, dbAccount.Address, nil, code, args, transactions.General)
 		if err != nil {
 			entry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
 			return 0, tx.TransactionId, err
@@ -323,22 +371,23 @@ func (s *ServiceImpl) syncAccountKeyCount(ctx context.Context, address flow.Addr
 
 // createAccount creates a new account on the flow blockchain. It generates a
 // fresh key pair and constructs a flow transaction to create the account with
-// generated key. Admin account is used to pay for the transaction.
+// generated key. The payer, or the admin account if payerName is empty, is
+// used to propose and pay for the transaction.
 //
 // Returns created account and the flow transaction ID of the account creation.
-func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, error) {
-	account := &Account{Type: AccountTypeCustodial}
+func (s *ServiceImpl) createAccount(ctx context.Context, payerName string) (*Account, string, error) {
+	account := &Account{Type: AccountTypeCustodial, Payer: payerName}
 
 	// Important to ratelimit all the way up here so the keys and reference blocks
 	// are "fresh" when the transaction is actually sent
 	s.txRateLimiter.Take()
 
-	payer, err := s.km.AdminAuthorizer(ctx)
+	payer, err := s.km.PayerAuthorizer(ctx, payerName)
 	if err != nil {
 		return nil, "", err
 	}
 
-	proposer, err := s.km.AdminProposalKey(ctx)
+	proposer, err := s.km.PayerProposalKey(ctx, payerName)
 	if err != nil {
 		return nil, "", err
 	}
@@ -380,7 +429,7 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetComputeLimit(maxGasLimit)
+		SetComputeLimit(s.cfg.MaxComputeLimit)
 
 	// Check if we want to use a custom account create script
 	if s.cfg.ScriptPathCreateAccount != "" {
@@ -404,6 +453,11 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		return nil, "", err
 	}
 
+	// Prevent the job from being cancelled from here on
+	if err := jobs.MarkTransactionSubmitted(ctx); err != nil {
+		return nil, "", err
+	}
+
 	// Send and wait for the transaction to be sealed
 	result, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
 	if err != nil {
@@ -425,6 +479,8 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 		return nil, "", fmt.Errorf("something went wrong when waiting for address")
 	}
 
+	s.recordFee(flowTx.ID().Hex(), payerName, result.Events)
+
 	account.Address = flow_helpers.FormatAddress(newAddress)
 
 	// Convert the key to storable form (encrypt it)
@@ -458,7 +514,7 @@ func (s *ServiceImpl) createAccount(ctx context.Context) (*Account, string, erro
 }
 
 // AddNewKey adds a new key to the given account
-func (s *ServiceImpl) AddNewKey(ctx context.Context, address flow.Address) (*jobs.Job, error) {
+func (s *ServiceImpl) AddNewKey(ctx context.Context, address flow.Address, opts ...jobs.JobOption) (*jobs.Job, error) {
 	fmt.Println("AddNewKey called")
 	// entry := log.WithFields(log.Fields{"address": address, "function": "ServiceImpl.AddNewKey"})
 
@@ -469,7 +525,7 @@ func (s *ServiceImpl) AddNewKey(ctx context.Context, address flow.Address) (*job
 	}
 
 	// make it always async
-	job, err := s.wp.CreateJob(AddNewKeyJobType, "", jobs.WithAttributes(attrBytes))
+	job, err := s.wp.CreateJob(AddNewKeyJobType, "", append([]jobs.JobOption{jobs.WithAttributes(attrBytes)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -560,7 +616,7 @@ func (s *ServiceImpl) addKey(ctx context.Context, logEntry *log.Entry, address f
 	return &dbAccount, nil
 }
 
-func (s *ServiceImpl) RevokeKey(ctx context.Context, address flow.Address, oldKeyIndex uint32) (*jobs.Job, error) {
+func (s *ServiceImpl) RevokeKey(ctx context.Context, address flow.Address, oldKeyIndex uint32, opts ...jobs.JobOption) (*jobs.Job, error) {
 	fmt.Println("RevokeKey called")
 	// entry := log.WithFields(log.Fields{"address": address, "function": "ServiceImpl.RevokeKey"})
 	attrs := revokeKeyJobAttributes{Address: address, OldKeyIndex: oldKeyIndex}
@@ -569,7 +625,7 @@ func (s *ServiceImpl) RevokeKey(ctx context.Context, address flow.Address, oldKe
 		return nil, err
 	}
 	// make it always async
-	job, err := s.wp.CreateJob(RevokeKeyJobType, "", jobs.WithAttributes(attrBytes))
+	job, err := s.wp.CreateJob(RevokeKeyJobType, "", append([]jobs.JobOption{jobs.WithAttributes(attrBytes)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -661,7 +717,7 @@ func (s *ServiceImpl) createNewKeyTx(ctx context.Context, logEntry *log.Entry, a
 	// Create & send add key transaction
 	code := t.AddAccountKey
 	sync := true
-	_, tx, err := s.txs.Create(ctx, sync, accountAddress, code, args, transactions.General)
+	_, tx, err := s.txs.Create(ctx, sync, accountAddress, nil, code, args, transactions.General)
 
 	if err != nil {
 		logEntry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
@@ -686,7 +742,7 @@ func (s *ServiceImpl) createRevokeKeyTx(ctx context.Context, logEntry *log.Entry
 	// it is possible that it will use the key that is being revoked, which could mean that the tx fails
 	// if the tx is tried again, it will work, since that key is no longer the 'least recently used' key
 	// but this is confusing and not ideal
-	_, tx, err := s.txs.Create(ctx, sync, accountAddress, code, args, transactions.General)
+	_, tx, err := s.txs.Create(ctx, sync, accountAddress, nil, code, args, transactions.General)
 
 	if err != nil {
 		logEntry.WithFields(log.Fields{"err": err}).Error("failed to create transaction")
@@ -696,6 +752,22 @@ func (s *ServiceImpl) createRevokeKeyTx(ctx context.Context, logEntry *log.Entry
 	return tx, nil
 }
 
+// recordFee attributes the fee of a transaction which was not created with
+// the transaction service to its payer.
+func (s *ServiceImpl) recordFee(transactionId, payerName string, events []flow.Event) {
+	if payerName == "" {
+		payerName = keys.AdminPayerName
+	}
+
+	tx := s.txs.GetOrCreateTransaction(transactionId)
+	tx.Payer = payerName
+	tx.Fee = transactions.FeeFromEvents(events)
+
+	if err := s.txs.UpdateTransaction(tx); err != nil {
+		log.WithFields(log.Fields{"error": err, "transactionId": transactionId}).Warn("Error while saving transaction fee")
+	}
+}
+
 func (s *ServiceImpl) GetKeysByType(ctx context.Context, keyType string) ([]keys.Storable, error) {
 	return s.store.GetKeysByType(keyType)
 }
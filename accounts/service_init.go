@@ -87,7 +87,7 @@ func (s *ServiceImpl) addAdminProposalKeys(ctx context.Context, count uint16) er
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(payer.Address, payer.Key.Index, payer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetComputeLimit(maxGasLimit).
+		SetComputeLimit(s.cfg.MaxComputeLimit).
 		SetScript([]byte(code))
 
 	if err := flowTx.AddArgument(cadence.NewInt(int(s.cfg.AdminKeyIndex))); err != nil {
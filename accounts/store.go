@@ -19,6 +19,9 @@ type Store interface {
 	// Update an existing account.
 	SaveAccount(a *Account) error
 
+	// Set the default payer of an account.
+	UpdateAccountPayer(address, payer string) error
+
 	// Permanently delete an account, despite of `DeletedAt` field.
 	HardDeleteAccount(a *Account) error
 
@@ -38,6 +38,17 @@ func (s *GormStore) SaveAccount(a *Account) error {
 	return s.db.Save(&a).Error
 }
 
+func (s *GormStore) UpdateAccountPayer(address, payer string) error {
+	res := s.db.Model(&Account{}).Where(&Account{Address: address}).Update("payer", payer)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (s *GormStore) HardDeleteAccount(a *Account) error {
 	return s.db.Unscoped().Delete(a).Error
 }
@@ -44,7 +44,7 @@ func AddContract(
 		SetReferenceBlockID(*referenceBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetComputeLimit(maxGasLimit).
+		SetComputeLimit(flow.DefaultTransactionGasLimit).
 		SetScript([]byte(template_strings.AddAccountContractWithAdmin)).
 		AddAuthorizer(payer.Address)
 
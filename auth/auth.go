@@ -0,0 +1,130 @@
+// Package auth provides API key management and scope based authorization.
+package auth
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// Scope is a type for API key permission scopes.
+type Scope string
+
+const (
+	ScopeAccountsRead      Scope = "accounts:read"
+	ScopeAccountsWrite     Scope = "accounts:write"
+	ScopeTransactionsRead  Scope = "transactions:read"
+	ScopeTransactionsWrite Scope = "transactions:write"
+	ScopeTokensRead        Scope = "tokens:read"
+	ScopeTokensWrite       Scope = "tokens:write"
+	ScopeTokensWithdraw    Scope = "tokens:withdraw"
+	ScopeJobsRead          Scope = "jobs:read"
+	ScopeJobsWrite         Scope = "jobs:write"
+	ScopeScriptsExecute    Scope = "scripts:execute"
+	ScopeWebhooksRead      Scope = "webhooks:read"
+	ScopeWebhooksWrite     Scope = "webhooks:write"
+	ScopeEventsRead        Scope = "events:read"
+	// ScopeSystemAdmin grants access to every route, including API key management.
+	ScopeSystemAdmin Scope = "system:admin"
+)
+
+// Scopes lists all known scopes.
+var Scopes = []Scope{
+	ScopeAccountsRead,
+	ScopeAccountsWrite,
+	ScopeTransactionsRead,
+	ScopeTransactionsWrite,
+	ScopeTokensRead,
+	ScopeTokensWrite,
+	ScopeTokensWithdraw,
+	ScopeJobsRead,
+	ScopeJobsWrite,
+	ScopeScriptsExecute,
+	ScopeWebhooksRead,
+	ScopeWebhooksWrite,
+	ScopeEventsRead,
+	ScopeSystemAdmin,
+}
+
+func (s Scope) IsValid() bool {
+	for _, known := range Scopes {
+		if s == known {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKey database model. The key itself is never stored, only its hash.
+type APIKey struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Name      string         `gorm:"column:name"`
+	Prefix    string         `gorm:"column:prefix"`
+	Hash      string         `gorm:"column:hash;uniqueIndex"`
+	Scopes    pq.StringArray `gorm:"column:scopes;type:text[]"`
+	RevokedAt sql.NullTime   `gorm:"column:revoked_at"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func (k *APIKey) BeforeCreate(tx *gorm.DB) (err error) {
+	k.ID = uuid.New()
+	return nil
+}
+
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt.Valid
+}
+
+// HasScope checks if the key has been granted the given scope.
+// ScopeSystemAdmin implies every other scope.
+func (k *APIKey) HasScope(scope Scope) bool {
+	for _, s := range k.Scopes {
+		if Scope(s) == scope || Scope(s) == ScopeSystemAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// API key HTTP request
+type JSONRequest struct {
+	Name   string  `json:"name"`
+	Scopes []Scope `json:"scopes"`
+}
+
+// API key HTTP response
+type JSONResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	Key       string     `json:"key,omitempty"` // Only returned once, when the key is created
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (k APIKey) ToJSONResponse() JSONResponse {
+	res := JSONResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    []string(k.Scopes),
+		CreatedAt: k.CreatedAt,
+		UpdatedAt: k.UpdatedAt,
+	}
+
+	if k.RevokedAt.Valid {
+		res.RevokedAt = &k.RevokedAt.Time
+	}
+
+	return res
+}
@@ -0,0 +1,11 @@
+package auth
+
+type ServiceOption func(*ServiceImpl)
+
+// WithAdminKey sets a bootstrap key which is granted ScopeSystemAdmin
+// without being stored in the database.
+func WithAdminKey(key string) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.adminKey = key
+	}
+}
@@ -0,0 +1,171 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	keyPrefix      = "fwa_"
+	keyRandomBytes = 32
+	// Number of characters of the key stored in plain text for identification.
+	displayPrefixLength = len(keyPrefix) + 8
+)
+
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+type Service interface {
+	// CreateKey issues a new API key. The returned string is the plain text
+	// key which is not stored anywhere and can not be recovered later.
+	CreateKey(name string, scopes []Scope) (*APIKey, string, error)
+	ListKeys() ([]APIKey, error)
+	RevokeKey(id string) (*APIKey, error)
+	// Authenticate resolves a plain text key to a stored, non-revoked API key.
+	Authenticate(key string) (*APIKey, error)
+}
+
+type ServiceImpl struct {
+	store    Store
+	adminKey string
+}
+
+func NewService(store Store, opts ...ServiceOption) Service {
+	svc := &ServiceImpl{store: store}
+
+	// Go through options
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
+}
+
+func (svc *ServiceImpl) CreateKey(name string, scopes []Scope) (*APIKey, string, error) {
+	log.WithFields(log.Fields{"name": name, "scopes": scopes}).Trace("Create API key")
+
+	if name == "" {
+		return nil, "", &wallet_errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("api key name is required"),
+		}
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", &wallet_errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("at least one scope is required"),
+		}
+	}
+
+	ss := make([]string, len(scopes))
+	for i, s := range scopes {
+		if !s.IsValid() {
+			return nil, "", &wallet_errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("unknown scope: %s", s),
+			}
+		}
+		ss[i] = string(s)
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	k := &APIKey{
+		Name:   name,
+		Prefix: key[:displayPrefixLength],
+		Hash:   hashKey(key),
+		Scopes: ss,
+	}
+
+	if err := svc.store.InsertAPIKey(k); err != nil {
+		return nil, "", err
+	}
+
+	return k, key, nil
+}
+
+func (svc *ServiceImpl) ListKeys() ([]APIKey, error) {
+	return svc.store.APIKeys()
+}
+
+func (svc *ServiceImpl) RevokeKey(id string) (*APIKey, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Revoke API key")
+
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &wallet_errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid api key id"),
+		}
+	}
+
+	k, err := svc.store.APIKey(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !k.IsRevoked() {
+		k.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		if err := svc.store.UpdateAPIKey(&k); err != nil {
+			return nil, err
+		}
+	}
+
+	return &k, nil
+}
+
+func (svc *ServiceImpl) Authenticate(key string) (*APIKey, error) {
+	if key == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if svc.adminKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(svc.adminKey)) == 1 {
+		return &APIKey{Name: "admin", Scopes: []string{string(ScopeSystemAdmin)}}, nil
+	}
+
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	k, err := svc.store.APIKeyByHash(hashKey(key))
+	if err != nil {
+		if err.Error() == "record not found" {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if k.IsRevoked() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	return &k, nil
+}
+
+func generateKey() (string, error) {
+	b := make([]byte, keyRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + hex.EncodeToString(b), nil
+}
+
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
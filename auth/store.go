@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+)
+
+// Store manages data regarding API keys.
+type Store interface {
+	APIKeys() ([]APIKey, error)
+	APIKey(id uuid.UUID) (APIKey, error)
+	APIKeyByHash(hash string) (APIKey, error)
+	InsertAPIKey(*APIKey) error
+	UpdateAPIKey(*APIKey) error
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db}
+}
+
+func (s *GormStore) APIKeys() (kk []APIKey, err error) {
+	err = s.db.Order("created_at desc").Find(&kk).Error
+	return
+}
+
+func (s *GormStore) APIKey(id uuid.UUID) (k APIKey, err error) {
+	err = s.db.First(&k, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) APIKeyByHash(hash string) (k APIKey, err error) {
+	err = s.db.First(&k, "hash = ?", hash).Error
+	return
+}
+
+func (s *GormStore) InsertAPIKey(k *APIKey) error {
+	return s.db.Create(k).Error
+}
+
+func (s *GormStore) UpdateAPIKey(k *APIKey) error {
+	return s.db.Save(k).Error
+}
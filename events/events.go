@@ -0,0 +1,108 @@
+// Package events provides a log of wallet events which clients can follow
+// as a stream of server-sent events.
+package events
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+)
+
+// Kind is a type for the kinds of events in the stream.
+type Kind string
+
+const (
+	KindJobUpdated        Kind = "job.updated"
+	KindDepositRegistered Kind = "deposit.registered"
+	KindAccountCreated    Kind = "account.created"
+)
+
+// Kinds lists all known event kinds.
+var Kinds = []Kind{
+	KindJobUpdated,
+	KindDepositRegistered,
+	KindAccountCreated,
+}
+
+func (k Kind) IsValid() bool {
+	for _, known := range Kinds {
+		if k == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event database model. IDs increase with every event and are used as the
+// server-sent event id to resume a stream.
+type Event struct {
+	ID        uint64         `gorm:"column:id;primaryKey;autoIncrement"`
+	Kind      Kind           `gorm:"column:kind"`
+	Addresses pq.StringArray `gorm:"column:addresses;type:text[]"`
+	JobType   string         `gorm:"column:job_type"`
+	TokenName string         `gorm:"column:token_name"`
+	Data      datatypes.JSON `gorm:"column:data"`
+	CreatedAt time.Time      `gorm:"column:created_at;index"`
+}
+
+func (Event) TableName() string {
+	return "stream_events"
+}
+
+// Filter selects the events of a stream. Empty filters match everything.
+// Job types only apply to job events while addresses and token names only
+// match events which carry them.
+type Filter struct {
+	Kinds      []string
+	Addresses  []string
+	JobTypes   []string
+	TokenNames []string
+}
+
+// Matches returns true if the event should be sent to the stream.
+func (f Filter) Matches(e Event) bool {
+	if !matchesFilter(f.Kinds, string(e.Kind)) {
+		return false
+	}
+
+	if e.Kind == KindJobUpdated && !matchesFilter(f.JobTypes, e.JobType) {
+		return false
+	}
+
+	if len(f.TokenNames) > 0 && (e.TokenName == "" || !matchesFilter(f.TokenNames, e.TokenName)) {
+		return false
+	}
+
+	if len(f.Addresses) > 0 {
+		for _, a := range e.Addresses {
+			if matchesFilter(f.Addresses, a) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func matchesFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Stream HTTP request
+type StreamRequest struct {
+	Kinds       []string
+	Addresses   []string
+	JobTypes    []string
+	TokenNames  []string
+	LastEventID string
+}
@@ -0,0 +1,25 @@
+package events
+
+import "time"
+
+type ServiceOption func(*ServiceImpl)
+
+// WithPollInterval sets how often streams check the log for events
+// published by other instances.
+func WithPollInterval(d time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) {
+		if d > 0 {
+			svc.pollInterval = d
+		}
+	}
+}
+
+// WithCommitGrace sets how long streams hold back events after a missing
+// event id, which may belong to an event that is still being committed.
+func WithCommitGrace(d time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) {
+		if d > 0 {
+			svc.commitGrace = d
+		}
+	}
+}
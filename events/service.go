@@ -0,0 +1,217 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/onflow/flow-go-sdk"
+	log "github.com/sirupsen/logrus"
+)
+
+// Events are read from the store in pages of this size
+const pageSize = 100
+
+var addressRegexp = regexp.MustCompile(`^(0x)?[0-9a-fA-F]{1,16}$`)
+
+type Service interface {
+	// Publish adds an event to the log and wakes up the streams of this
+	// instance. Streams on other instances see it on their next poll.
+	Publish(e *Event) error
+	// Stream validates the request and returns a stream positioned after
+	// the last event ID of the request, or at the newest event.
+	Stream(req StreamRequest) (*Stream, error)
+}
+
+type ServiceImpl struct {
+	store        Store
+	pollInterval time.Duration
+	commitGrace  time.Duration
+
+	mu      sync.Mutex
+	streams map[chan struct{}]struct{}
+}
+
+func NewService(store Store, opts ...ServiceOption) Service {
+	svc := &ServiceImpl{
+		store:        store,
+		pollInterval: time.Second,
+		commitGrace:  5 * time.Second,
+		streams:      make(map[chan struct{}]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
+}
+
+func (s *ServiceImpl) Publish(e *Event) error {
+	if err := s.store.InsertEvent(e); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.streams {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (s *ServiceImpl) Stream(req StreamRequest) (*Stream, error) {
+	log.WithFields(log.Fields{"request": req}).Trace("Open event stream")
+
+	filter, err := parseFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastID uint64
+	if req.LastEventID != "" {
+		lastID, err = strconv.ParseUint(req.LastEventID, 10, 64)
+		if err != nil {
+			return nil, badRequest("invalid last event id")
+		}
+	} else {
+		// Only new events
+		lastID, err = s.store.LatestEventID()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	s.streams[c] = struct{}{}
+	s.mu.Unlock()
+
+	st := &Stream{
+		svc:       s,
+		filter:    filter,
+		lastID:    lastID,
+		published: c,
+	}
+
+	return st, nil
+}
+
+// Stream is a position in the event log of a single client.
+type Stream struct {
+	svc       *ServiceImpl
+	filter    Filter
+	lastID    uint64
+	published chan struct{}
+}
+
+// Next blocks until there are events matching the filter of the stream and
+// returns them. Events are only returned once, in the order of their ids.
+//
+// Ids are assigned when an event is inserted, so an event may become visible
+// after events with higher ids. If an id is missing, the events after it are
+// held back until they are older than the commit grace period; by then the
+// missing event has been committed or never will be. A stream, or a client
+// resuming from the id of the last event it received, therefore does not
+// skip events which were still being committed.
+func (st *Stream) Next(ctx context.Context) ([]Event, error) {
+	poll := time.NewTicker(st.svc.pollInterval)
+	defer poll.Stop()
+
+	for {
+		ee, err := st.svc.store.EventsAfter(st.lastID, pageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		var (
+			res  []Event
+			held <-chan time.Time
+			now  = time.Now()
+		)
+		for _, e := range ee {
+			if wait := st.svc.commitGrace - now.Sub(e.CreatedAt); e.ID != st.lastID+1 && wait > 0 {
+				held = time.After(wait)
+				break
+			}
+
+			st.lastID = e.ID
+			if st.filter.Matches(e) {
+				res = append(res, e)
+			}
+		}
+
+		if len(res) > 0 {
+			return res, nil
+		}
+
+		if len(ee) == pageSize && held == nil {
+			// Skipped a full page of other events, there may be more
+			continue
+		}
+
+		select {
+		case <-held:
+		case <-st.published:
+		case <-poll.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close stops the stream from being woken up by published events.
+func (st *Stream) Close() {
+	st.svc.mu.Lock()
+	defer st.svc.mu.Unlock()
+	delete(st.svc.streams, st.published)
+}
+
+func parseFilter(req StreamRequest) (Filter, error) {
+	f := Filter{
+		Kinds:      req.Kinds,
+		JobTypes:   req.JobTypes,
+		TokenNames: req.TokenNames,
+	}
+
+	for _, k := range req.Kinds {
+		if !Kind(k).IsValid() {
+			return f, badRequest(fmt.Sprintf("unknown event: %s", k))
+		}
+	}
+
+	for _, a := range req.Addresses {
+		if !addressRegexp.MatchString(a) {
+			return f, badRequest(fmt.Sprintf("invalid address: %s", a))
+		}
+		f.Addresses = append(f.Addresses, normalizeAddress(a))
+	}
+
+	return f, nil
+}
+
+// normalizeAddress formats an address the same way regardless of prefix,
+// case and leading zeros.
+func normalizeAddress(a string) string {
+	return flow_helpers.FormatAddress(flow.HexToAddress(strings.ToLower(a)))
+}
+
+func badRequest(msg string) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("%s", msg),
+	}
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/numeroai/flow-wallet-api/accounts"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/templates"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	log "github.com/sirupsen/logrus"
+)
+
+type accountCreatedData struct {
+	Address string `json:"address"`
+}
+
+type depositRegisteredData struct {
+	TransactionId    string              `json:"transactionId"`
+	RecipientAddress string              `json:"recipientAddress"`
+	SenderAddress    string              `json:"senderAddress"`
+	TokenName        string              `json:"tokenName"`
+	TokenType        templates.TokenType `json:"tokenType"`
+	FtAmount         string              `json:"amount,omitempty"`
+	NftID            uint64              `json:"nftId,omitempty"`
+}
+
+func newEvent(kind Kind, data interface{}) (*Event, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{Kind: kind, Data: b}, nil
+}
+
+func NewJobUpdatedEvent(job jobs.Job) (*Event, error) {
+	e, err := newEvent(KindJobUpdated, job.ToJSONResponse())
+	if err != nil {
+		return nil, err
+	}
+
+	e.JobType = job.Type
+	e.Addresses, e.TokenName = jobAddressesAndToken(job)
+
+	return e, nil
+}
+
+func NewAccountCreatedEvent(address string) (*Event, error) {
+	e, err := newEvent(KindAccountCreated, accountCreatedData{Address: address})
+	if err != nil {
+		return nil, err
+	}
+
+	e.Addresses = []string{normalizeAddress(address)}
+
+	return e, nil
+}
+
+func NewDepositRegisteredEvent(p tokens.DepositRegisteredPayload) (*Event, error) {
+	e, err := newEvent(KindDepositRegistered, depositRegisteredData(p))
+	if err != nil {
+		return nil, err
+	}
+
+	e.TokenName = p.TokenName
+	for _, a := range []string{p.RecipientAddress, p.SenderAddress} {
+		if a != "" {
+			e.Addresses = append(e.Addresses, normalizeAddress(a))
+		}
+	}
+
+	return e, nil
+}
+
+// jobAddressesAndToken looks for the accounts and token a job is about in
+// its attributes, e.g. the sender and recipient of a withdrawal. The address
+// of a created account is its result.
+func jobAddressesAndToken(job jobs.Job) (addresses []string, tokenName string) {
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				s, isString := child.(string)
+				switch {
+				case !isString:
+					walk(child)
+				case strings.EqualFold(k, "tokenName"):
+					tokenName = s
+				case strings.EqualFold(k, "address"), strings.EqualFold(k, "sender"), strings.EqualFold(k, "recipient"):
+					if addressRegexp.MatchString(s) {
+						addresses = append(addresses, normalizeAddress(s))
+					}
+				}
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	var attrs interface{}
+	if len(job.Attributes) > 0 && json.Unmarshal(job.Attributes, &attrs) == nil {
+		walk(attrs)
+	}
+
+	if job.Type == accounts.AccountCreateJobType && addressRegexp.MatchString(job.Result) {
+		addresses = append(addresses, normalizeAddress(job.Result))
+	}
+
+	return
+}
+
+// JobUpdatedHandler publishes job state changes to the event stream.
+type JobUpdatedHandler struct {
+	Service Service
+}
+
+func (h *JobUpdatedHandler) Handle(payload jobs.JobUpdatedPayload) {
+	publish(h.Service, KindJobUpdated, func() (*Event, error) { return NewJobUpdatedEvent(payload.Job) })
+}
+
+// AccountAddedHandler publishes created accounts to the event stream.
+type AccountAddedHandler struct {
+	Service Service
+}
+
+func (h *AccountAddedHandler) Handle(payload accounts.AccountAddedPayload) {
+	publish(h.Service, KindAccountCreated, func() (*Event, error) {
+		return NewAccountCreatedEvent(flow_helpers.FormatAddress(payload.Address))
+	})
+}
+
+// DepositRegisteredHandler publishes registered deposits to the event stream.
+type DepositRegisteredHandler struct {
+	Service Service
+}
+
+func (h *DepositRegisteredHandler) Handle(payload tokens.DepositRegisteredPayload) {
+	publish(h.Service, KindDepositRegistered, func() (*Event, error) { return NewDepositRegisteredEvent(payload) })
+}
+
+func publish(svc Service, kind Kind, newEvent func() (*Event, error)) {
+	e, err := newEvent()
+	if err == nil {
+		err = svc.Publish(e)
+	}
+	if err != nil {
+		log.
+			WithFields(log.Fields{"error": err, "event": kind}).
+			Warn("Error while publishing event to the event stream")
+	}
+}
@@ -0,0 +1,11 @@
+package events
+
+// Store manages the event log.
+type Store interface {
+	InsertEvent(*Event) error
+	// EventsAfter returns up to limit events with an ID greater than id,
+	// oldest first.
+	EventsAfter(id uint64, limit int) ([]Event, error)
+	// LatestEventID returns the ID of the newest event, 0 if there are none.
+	LatestEventID() (uint64, error)
+}
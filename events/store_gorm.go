@@ -0,0 +1,31 @@
+package events
+
+import (
+	"gorm.io/gorm"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db}
+}
+
+func (s *GormStore) InsertEvent(e *Event) error {
+	return s.db.Create(e).Error
+}
+
+func (s *GormStore) EventsAfter(id uint64, limit int) (ee []Event, err error) {
+	err = s.db.
+		Where("id > ?", id).
+		Order("id asc").
+		Limit(limit).
+		Find(&ee).Error
+	return
+}
+
+func (s *GormStore) LatestEventID() (id uint64, err error) {
+	err = s.db.Model(&Event{}).Select("coalesce(max(id), 0)").Scan(&id).Error
+	return
+}
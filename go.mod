@@ -20,6 +20,7 @@ require (
 	github.com/onflow/cadence v1.3.3
 	github.com/onflow/flow-go-sdk v1.2.2
 	github.com/onflow/sdks v0.6.0-preview.1
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.9.3
 	go.uber.org/goleak v1.3.0
 	go.uber.org/ratelimit v0.2.0
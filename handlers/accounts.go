@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/numeroai/flow-wallet-api/accounts"
+	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/onflow/flow-go-sdk"
 )
 
@@ -19,6 +21,34 @@ type SyncKeyCountRequest struct {
 	Address flow.Address `json:"address"`
 }
 
+// ScheduleRequest represents an optional JSON payload for HTTP requests
+// creating asynchronous jobs
+type ScheduleRequest struct {
+	RunAt *time.Time `json:"runAt"`
+}
+
+func (r ScheduleRequest) jobOptions() []jobs.JobOption {
+	if r.RunAt == nil {
+		return nil
+	}
+	return []jobs.JobOption{jobs.WithRunAt(*r.RunAt)}
+}
+
+// AccountCreateRequest represents the optional JSON payload for account
+// creation requests.
+type AccountCreateRequest struct {
+	ScheduleRequest
+	// Name of the payer of the account creation and default payer of the
+	// account, defaults to the admin account
+	Payer string `json:"payer"`
+}
+
+// AccountPayerRequest represents the JSON payload for setting the default
+// payer of an account.
+type AccountPayerRequest struct {
+	Payer string `json:"payer"`
+}
+
 // NewAccounts initiates a new accounts server.
 func NewAccounts(service accounts.Service) *Accounts {
 	return &Accounts{service}
@@ -48,6 +78,10 @@ func (s *Accounts) Details() http.Handler {
 	return http.HandlerFunc(s.DetailsFunc)
 }
 
+func (s *Accounts) SetPayer() http.Handler {
+	return UseJson(http.HandlerFunc(s.SetPayerFunc))
+}
+
 func (s *Accounts) AddNewKey() http.Handler {
 	return http.HandlerFunc(s.AddNewKeyFunc)
 }
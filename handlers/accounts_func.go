@@ -3,6 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -40,7 +41,20 @@ func (s *Accounts) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 	// Decide whether to serve sync or async, default async
 	sync := r.FormValue(SyncQueryParameter) != ""
 
-	job, acc, err := s.service.Create(r.Context(), sync)
+	var req AccountCreateRequest
+	err := decodeOptionalBody(r, &req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	if sync && req.RunAt != nil {
+		err = &errors.RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("runAt can not be used with sync")}
+		handleError(rw, r, err)
+		return
+	}
+
+	job, acc, err := s.service.Create(r.Context(), sync, req.Payer, req.jobOptions()...)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -142,7 +156,14 @@ func (s *Accounts) AddNewKeyFunc(rw http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 
 	address := flow.HexToAddress(vars["address"])
-	job, err := s.service.AddNewKey(r.Context(), address)
+
+	req, err := decodeScheduleRequest(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	job, err := s.service.AddNewKey(r.Context(), address, req.jobOptions()...)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -160,8 +181,16 @@ func (s *Accounts) RevokeKeyFunc(rw http.ResponseWriter, r *http.Request) {
 	key, err := strconv.Atoi(vars["index"])
 	if err != nil {
 		handleError(rw, r, err)
+		return
+	}
+
+	req, err := decodeScheduleRequest(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
 	}
-	job, err := s.service.RevokeKey(r.Context(), address, uint32(key))
+
+	job, err := s.service.RevokeKey(r.Context(), address, uint32(key), req.jobOptions()...)
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -171,6 +200,30 @@ func (s *Accounts) RevokeKeyFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusOK, job)
 }
 
+// SetPayerFunc sets the default payer of an account.
+func (s *Accounts) SetPayerFunc(rw http.ResponseWriter, r *http.Request) {
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req AccountPayerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	res, err := s.service.SetPayer(r.Context(), vars["address"], req.Payer)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
 func (s *Accounts) GetKeysByTypeFunc(rw http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	keyType := vars["type"]
@@ -184,3 +237,25 @@ func (s *Accounts) GetKeysByTypeFunc(rw http.ResponseWriter, r *http.Request) {
 
 	handleJsonResponse(rw, http.StatusOK, acc)
 }
+
+// decodeScheduleRequest reads the optional schedule of a job from the request
+// body, an empty body schedules the job right away.
+func decodeScheduleRequest(r *http.Request) (ScheduleRequest, error) {
+	var req ScheduleRequest
+	err := decodeOptionalBody(r, &req)
+	return req, err
+}
+
+// decodeOptionalBody decodes the JSON body of the request into v, if the
+// request has a body.
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil && err != io.EOF {
+		return InvalidBodyError
+	}
+
+	return nil
+}
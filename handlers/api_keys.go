@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/auth"
+)
+
+// APIKeys is a HTTP server for API key management.
+// It provides create, list and revoke APIs.
+type APIKeys struct {
+	service auth.Service
+}
+
+// NewAPIKeys initiates a new API keys server.
+func NewAPIKeys(service auth.Service) *APIKeys {
+	return &APIKeys{service}
+}
+
+func (s *APIKeys) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *APIKeys) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *APIKeys) Revoke() http.Handler {
+	return http.HandlerFunc(s.RevokeFunc)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/auth"
+)
+
+// List returns all API keys, including revoked ones.
+func (s *APIKeys) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	keys, err := s.service.ListKeys()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]auth.JSONResponse, len(keys))
+	for i, k := range keys {
+		res[i] = k.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Create issues a new API key.
+// The plain text key is included in the response and can not be retrieved later.
+func (s *APIKeys) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req auth.JSONRequest
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	k, key, err := s.service.CreateKey(req.Name, req.Scopes)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := k.ToJSONResponse()
+	res.Key = key
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+// Revoke revokes an API key, it can no longer be used to authenticate.
+func (s *APIKeys) RevokeFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	k, err := s.service.RevokeKey(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, k.ToJSONResponse())
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/numeroai/flow-wallet-api/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// Authentication middleware
+// ===========================================================================
+
+const APIKeyHeader = "Authorization"
+
+type apiKeyContextKey struct{}
+
+type AuthHandlerOptions struct {
+	IgnorePaths []string
+}
+
+func UseAuth(h http.Handler, opts AuthHandlerOptions, service auth.Service) http.Handler {
+	return AuthHandler(h, opts, service)
+}
+
+// AuthHandler returns a http.HandlerFunc that authenticates the request
+// using the API key in the "Authorization: Bearer <key>" header and stores
+// the resolved key in the request context for RequireScope.
+func AuthHandler(h http.Handler, opts AuthHandlerOptions, service auth.Service) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// Check for ignored paths
+		for _, path := range opts.IgnorePaths {
+			if strings.HasPrefix(r.URL.Path, path) {
+				h.ServeHTTP(rw, r)
+				return
+			}
+		}
+
+		// Let CORS preflight requests through, they never carry credentials
+		if r.Method == http.MethodOptions {
+			h.ServeHTTP(rw, r)
+			return
+		}
+
+		key := strings.TrimSpace(strings.TrimPrefix(r.Header.Get(APIKeyHeader), "Bearer "))
+		if key == "" {
+			http.Error(rw, "API key not found", http.StatusUnauthorized)
+			return
+		}
+
+		apiKey, err := service.Authenticate(key)
+		if err != nil {
+			if err != auth.ErrInvalidAPIKey {
+				log.
+					WithFields(log.Fields{"error": err}).
+					Warn("Error while authenticating API key")
+				http.Error(rw, "Error while authenticating API key", http.StatusInternalServerError)
+				return
+			}
+			http.Error(rw, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, apiKey)))
+	})
+}
+
+// RequireScope returns a http.Handler that only lets requests through if
+// they were authenticated with an API key that has the given scope.
+func RequireScope(scope auth.Scope, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		apiKey := APIKeyFromContext(r.Context())
+		if apiKey == nil {
+			http.Error(rw, "API key not found", http.StatusUnauthorized)
+			return
+		}
+
+		if !apiKey.HasScope(scope) {
+			http.Error(rw, fmt.Sprintf("API key is missing required scope: %s", scope), http.StatusForbidden)
+			return
+		}
+
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// APIKeyFromContext returns the API key the request was authenticated with,
+// or nil if the request was not authenticated.
+func APIKeyFromContext(ctx context.Context) *auth.APIKey {
+	apiKey, _ := ctx.Value(apiKeyContextKey{}).(*auth.APIKey)
+	return apiKey
+}
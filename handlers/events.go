@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/events"
+)
+
+// LastEventIDHeader is sent by server-sent event clients when they reconnect.
+const LastEventIDHeader = "Last-Event-ID"
+
+// Events is a HTTP server for the event stream.
+// It provides a server-sent events API.
+type Events struct {
+	service   events.Service
+	keepAlive time.Duration
+}
+
+// NewEvents initiates a new event stream server. A comment is sent to idle
+// streams every keepAlive to keep proxies from closing them.
+func NewEvents(service events.Service, keepAlive time.Duration) *Events {
+	return &Events{service, keepAlive}
+}
+
+func (s *Events) Stream() http.Handler {
+	return http.HandlerFunc(s.StreamFunc)
+}
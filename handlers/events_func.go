@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/events"
+	log "github.com/sirupsen/logrus"
+)
+
+// Stream sends matching events as server-sent events until the client
+// disconnects. Events after the ID in the Last-Event-ID header or the
+// lastEventId query parameter are sent first.
+func (s *Events) StreamFunc(rw http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	lastEventID := r.Header.Get(LastEventIDHeader)
+	if lastEventID == "" {
+		lastEventID = query.Get("lastEventId")
+	}
+
+	stream, err := s.service.Stream(events.StreamRequest{
+		Kinds:       splitQueryValues(query["event"]),
+		Addresses:   splitQueryValues(query["address"]),
+		JobTypes:    splitQueryValues(query["jobType"]),
+		TokenNames:  splitQueryValues(query["tokenName"]),
+		LastEventID: lastEventID,
+	})
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+	defer stream.Close()
+
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		ctx, cancel := context.WithTimeout(r.Context(), s.keepAlive)
+		ee, err := stream.Next(ctx)
+		cancel()
+
+		switch {
+		case r.Context().Err() != nil:
+			// Client disconnected
+			return
+		case errors.Is(err, context.DeadlineExceeded):
+			fmt.Fprint(rw, ": keep-alive\n\n")
+		case err != nil:
+			log.
+				WithFields(log.Fields{"error": err}).
+				Warn("Error while reading event stream")
+			return
+		}
+
+		for _, e := range ee {
+			fmt.Fprintf(rw, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Kind, e.Data)
+		}
+
+		flusher.Flush()
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	gorilla "github.com/gorilla/handlers"
 	log "github.com/sirupsen/logrus"
@@ -21,7 +22,10 @@ var EmptyBodyError = &errors.RequestError{StatusCode: http.StatusBadRequest, Err
 var InvalidBodyError = &errors.RequestError{StatusCode: http.StatusBadRequest, Err: fmt.Errorf("invalid body")}
 
 func UseCors(h http.Handler) http.Handler {
-	return gorilla.CORS(gorilla.AllowedOrigins([]string{"*"}))(h)
+	return gorilla.CORS(
+		gorilla.AllowedOrigins([]string{"*"}),
+		gorilla.ExposedHeaders([]string{TotalCountHeader, NextCursorHeader}),
+	)(h)
 }
 
 func UseLogging(h http.Handler) http.Handler {
@@ -37,6 +41,44 @@ func UseJson(h http.Handler) http.Handler {
 	return gorilla.ContentTypeHandler(h, "application/json")
 }
 
+// TimeoutHandlerOptions lists the paths of streaming requests which are not
+// bound by the server request timeout.
+type TimeoutHandlerOptions struct {
+	IgnorePaths []string
+}
+
+// UseTimeout limits the time spent on a request, http.TimeoutHandler buffers
+// the response so streaming requests need to be ignored.
+func UseTimeout(h http.Handler, timeout time.Duration, opts TimeoutHandlerOptions) http.Handler {
+	th := http.TimeoutHandler(h, timeout, "request timed out")
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for _, path := range opts.IgnorePaths {
+			if strings.HasPrefix(r.URL.Path, path) {
+				h.ServeHTTP(rw, r)
+				return
+			}
+		}
+		th.ServeHTTP(rw, r)
+	})
+}
+
+// UseDrain rejects requests which would create new work with 503 Service
+// Unavailable while the instance is draining, reads are still served.
+func UseDrain(h http.Handler, isDraining func() bool) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if isDraining() {
+				rw.Header().Set("Retry-After", "1")
+				http.Error(rw, "server is shutting down", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		h.ServeHTTP(rw, r)
+	})
+}
+
 func UseIdempotency(h http.Handler, opts IdempotencyHandlerOptions, store IdempotencyStore) http.Handler {
 	return IdempotencyHandler(h, opts, store)
 }
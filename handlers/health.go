@@ -4,8 +4,16 @@ import (
 	"net/http"
 )
 
-func HandleHealthReady(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
+// Readiness responds with 503 Service Unavailable while isReady returns
+// false, e.g. while the instance is draining.
+func Readiness(isReady func() bool) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !isReady() {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	})
 }
 
 func Liveness(getLiveness func() (interface{}, error)) http.Handler {
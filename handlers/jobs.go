@@ -6,8 +6,14 @@ import (
 	"github.com/numeroai/flow-wallet-api/jobs"
 )
 
+const (
+	TotalCountHeader        = "X-Total-Count"
+	NextCursorHeader        = "X-Next-Cursor"
+	jobAttributeQueryPrefix = "attributes."
+)
+
 // Jobs is a HTTP server for jobs.
-// It provides details API.
+// It provides list, details, cancel, retry, bulk retry, delivery log, delivery replay and job type schema APIs.
 // It uses jobs service to interface with data.
 type Jobs struct {
 	service jobs.Service
@@ -25,3 +31,31 @@ func (s *Jobs) List() http.Handler {
 func (s *Jobs) Details() http.Handler {
 	return http.HandlerFunc(s.DetailsFunc)
 }
+
+func (s *Jobs) Cancel() http.Handler {
+	return http.HandlerFunc(s.CancelFunc)
+}
+
+func (s *Jobs) Retry() http.Handler {
+	return http.HandlerFunc(s.RetryFunc)
+}
+
+func (s *Jobs) RetryFailed() http.Handler {
+	return http.HandlerFunc(s.RetryFailedFunc)
+}
+
+func (s *Jobs) Deliveries() http.Handler {
+	return http.HandlerFunc(s.DeliveriesFunc)
+}
+
+func (s *Jobs) ReplayDelivery() http.Handler {
+	return http.HandlerFunc(s.ReplayDeliveryFunc)
+}
+
+func (s *Jobs) Schemas() http.Handler {
+	return http.HandlerFunc(s.SchemasFunc)
+}
+
+func (s *Jobs) Schema() http.Handler {
+	return http.HandlerFunc(s.SchemaFunc)
+}
@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/gorilla/mux"
 )
 
-// List returns all jobs.
+// List returns jobs matching the filters in the query string.
+// The total number of matching jobs is returned in the X-Total-Count header
+// and the cursor for the next page, if any, in the X-Next-Cursor header.
 func (s *Jobs) ListFunc(rw http.ResponseWriter, r *http.Request) {
 	limit, err := strconv.Atoi(r.FormValue("limit"))
 	if err != nil {
@@ -20,28 +24,85 @@ func (s *Jobs) ListFunc(rw http.ResponseWriter, r *http.Request) {
 		offset = 0
 	}
 
-	jobsSlice, err := s.service.List(limit, offset)
+	query := r.URL.Query()
+
+	req := jobs.ListRequest{
+		States:        splitQueryValues(query["state"]),
+		Types:         splitQueryValues(query["type"]),
+		TransactionID: query.Get("transactionId"),
+		Scheduled:     query.Get("scheduled") == "true",
+		CreatedAfter:  query.Get("createdAfter"),
+		CreatedBefore: query.Get("createdBefore"),
+		UpdatedAfter:  query.Get("updatedAfter"),
+		UpdatedBefore: query.Get("updatedBefore"),
+		Attributes:    make(map[string]string),
+		Sort:          query.Get("sort"),
+		Order:         query.Get("order"),
+		Cursor:        query.Get("cursor"),
+		Limit:         limit,
+		Offset:        offset,
+	}
+
+	for key, values := range query {
+		if path := strings.TrimPrefix(key, jobAttributeQueryPrefix); path != key && len(values) > 0 {
+			req.Attributes[path] = values[0]
+		}
+	}
+
+	list, err := s.service.List(req)
 
 	if err != nil {
 		handleError(rw, r, err)
 		return
 	}
 
-	res := make([]jobs.JSONResponse, len(*jobsSlice))
-	for i, job := range *jobsSlice {
+	res := make([]jobs.JSONResponse, len(list.Jobs))
+	for i, job := range list.Jobs {
 		res[i] = job.ToJSONResponse()
 	}
 
+	rw.Header().Set(TotalCountHeader, strconv.FormatInt(list.TotalCount, 10))
+	if list.NextCursor != "" {
+		rw.Header().Set(NextCursorHeader, list.NextCursor)
+	}
+
 	handleJsonResponse(rw, http.StatusOK, res)
 }
 
+// splitQueryValues allows repeating a query parameter and giving
+// comma separated values.
+func splitQueryValues(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
 // Details returns details regarding a job.
 // It reads the job id for the wanted job from URL.
+// With waitFor or timeout in the query the request is held open until the job
+// reaches one of the waitFor states or finishes, or the timeout passes.
 // Job service is responsible for validating the job id.
 func (s *Jobs) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
+	query := r.URL.Query()
+
+	var job *jobs.Job
+	var err error
 
-	job, err := s.service.Details(vars["jobId"])
+	if query.Has("waitFor") || query.Has("timeout") {
+		job, err = s.service.Wait(r.Context(), vars["jobId"], jobs.WaitRequest{
+			States:  splitQueryValues(query["waitFor"]),
+			Timeout: query.Get("timeout"),
+		})
+	} else {
+		job, err = s.service.Details(vars["jobId"])
+	}
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -52,3 +113,130 @@ func (s *Jobs) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 
 	handleJsonResponse(rw, http.StatusOK, res)
 }
+
+// Cancel cancels a job that is waiting to be executed.
+// Job service is responsible for validating the job id.
+func (s *Jobs) CancelFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.Cancel(vars["jobId"])
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, job.ToJSONResponse())
+}
+
+// Retry requeues a FAILED job, jobs which have submitted a transaction only
+// with force=true.
+// Job service is responsible for validating the job id.
+func (s *Jobs) RetryFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.Retry(vars["jobId"], r.URL.Query().Get("force") == "true")
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, job.ToJSONResponse())
+}
+
+// RetryFailed requeues all FAILED jobs matching the filters in the request body.
+// It responds with the requeued jobs.
+func (s *Jobs) RetryFailedFunc(rw http.ResponseWriter, r *http.Request) {
+	var req jobs.RetryRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	jobsSlice, err := s.service.RetryFailed(req)
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]jobs.JSONResponse, len(*jobsSlice))
+	for i, job := range *jobsSlice {
+		res[i] = job.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Deliveries returns the webhook delivery attempts of notifications about a job.
+func (s *Jobs) DeliveriesFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	offset, err := strconv.Atoi(r.FormValue("offset"))
+	if err != nil {
+		offset = 0
+	}
+
+	deliveries, err := s.service.Deliveries(vars["jobId"], limit, offset)
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]jobs.DeliveryJSONResponse, len(*deliveries))
+	for i, d := range *deliveries {
+		res[i] = d.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// ReplayDelivery re-sends the notification of a delivery attempt.
+// It responds with the new notification job.
+func (s *Jobs) ReplayDeliveryFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := s.service.ReplayDelivery(vars["jobId"], vars["deliveryId"])
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, job.ToJSONResponse())
+}
+
+// Schemas returns the JSON schemas of the attributes and typed results of all
+// job types.
+func (s *Jobs) SchemasFunc(rw http.ResponseWriter, r *http.Request) {
+	handleJsonResponse(rw, http.StatusOK, s.service.Schemas())
+}
+
+// Schema returns the JSON schemas of a job type.
+func (s *Jobs) SchemaFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	schema, err := s.service.Schema(vars["jobType"])
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, schema)
+}
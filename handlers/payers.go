@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/payers"
+)
+
+// Payers is a HTTP server for payer account management.
+// It provides list, create, details and delete APIs.
+type Payers struct {
+	service payers.Service
+}
+
+// NewPayers initiates a new payers server.
+func NewPayers(service payers.Service) *Payers {
+	return &Payers{service}
+}
+
+func (s *Payers) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *Payers) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *Payers) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
+
+func (s *Payers) Delete() http.Handler {
+	return http.HandlerFunc(s.DeleteFunc)
+}
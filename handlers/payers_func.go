@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/payers"
+)
+
+// List returns all payers, including the admin account, with their fees.
+func (s *Payers) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	res, err := s.service.List()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Create registers a new payer account.
+func (s *Payers) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req payers.JSONRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	res, err := s.service.Create(r.Context(), req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+// Details returns a payer with its fees.
+func (s *Payers) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	res, err := s.service.Details(vars["name"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Delete removes a payer account.
+func (s *Payers) DeleteFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.service.Delete(vars["name"]); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
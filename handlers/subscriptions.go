@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/subscriptions"
+)
+
+// Subscriptions is a HTTP server for webhook subscription management.
+// It provides create, list, details, update and delete APIs.
+type Subscriptions struct {
+	service subscriptions.Service
+}
+
+// NewSubscriptions initiates a new webhook subscriptions server.
+func NewSubscriptions(service subscriptions.Service) *Subscriptions {
+	return &Subscriptions{service}
+}
+
+func (s *Subscriptions) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *Subscriptions) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *Subscriptions) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
+
+func (s *Subscriptions) Update() http.Handler {
+	h := http.HandlerFunc(s.UpdateFunc)
+	return UseJson(h)
+}
+
+func (s *Subscriptions) Delete() http.Handler {
+	return http.HandlerFunc(s.DeleteFunc)
+}
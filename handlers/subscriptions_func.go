@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/subscriptions"
+)
+
+// List returns all webhook subscriptions.
+func (s *Subscriptions) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	subs, err := s.service.List()
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]subscriptions.JSONResponse, len(subs))
+	for i, sub := range subs {
+		res[i] = sub.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Create adds a new webhook subscription.
+// A generated secret is included in the response and can not be retrieved later.
+func (s *Subscriptions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	req, err := decodeSubscriptionRequest(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res, err := s.service.Create(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+// Details returns a webhook subscription.
+func (s *Subscriptions) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sub, err := s.service.Details(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sub.ToJSONResponse())
+}
+
+// Update replaces a webhook subscription.
+func (s *Subscriptions) UpdateFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	req, err := decodeSubscriptionRequest(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	sub, err := s.service.Update(vars["id"], req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sub.ToJSONResponse())
+}
+
+// Delete removes a webhook subscription, pending deliveries to it will fail.
+func (s *Subscriptions) DeleteFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.service.Delete(vars["id"]); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+func decodeSubscriptionRequest(r *http.Request) (subscriptions.JSONRequest, error) {
+	var req subscriptions.JSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		return req, err
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, InvalidBodyError
+	}
+
+	return req, nil
+}
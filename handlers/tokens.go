@@ -11,6 +11,14 @@ type Tokens struct {
 	service tokens.Service
 }
 
+// TokenSetupRequest represents the optional JSON payload for token setup
+// requests.
+type TokenSetupRequest struct {
+	// Compute limit of the setup transaction, defaults to the configured
+	// maximum
+	ComputeLimit uint64 `json:"computeLimit"`
+}
+
 func NewTokens(service tokens.Service) *Tokens {
 	return &Tokens{service}
 }
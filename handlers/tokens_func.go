@@ -8,6 +8,7 @@ import (
 	"github.com/numeroai/flow-wallet-api/errors"
 	"github.com/numeroai/flow-wallet-api/templates"
 	"github.com/numeroai/flow-wallet-api/tokens"
+	"github.com/numeroai/flow-wallet-api/transactions"
 	"github.com/gorilla/mux"
 )
 
@@ -18,7 +19,14 @@ func (s *Tokens) SetupFunc(rw http.ResponseWriter, r *http.Request) {
 
 	// Decide whether to serve sync or async, default async
 	sync := r.FormValue(SyncQueryParameter) != ""
-	job, transaction, err := s.service.Setup(r.Context(), sync, tokenName, address)
+
+	var req TokenSetupRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	job, transaction, err := s.service.Setup(r.Context(), sync, tokenName, address, transactions.WithComputeLimit(req.ComputeLimit))
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -29,10 +29,20 @@ func (s *Transactions) Sign() http.Handler {
 	return UseJson(h)
 }
 
+func (s *Transactions) CoSign() http.Handler {
+	h := http.HandlerFunc(s.CoSignFunc)
+	return UseJson(h)
+}
+
 func (s *Transactions) Details() http.Handler {
 	return http.HandlerFunc(s.DetailsFunc)
 }
 
+func (s *Transactions) Estimate() http.Handler {
+	h := http.HandlerFunc(s.EstimateFunc)
+	return UseJson(h)
+}
+
 func (s *Transactions) ExecuteScript() http.Handler {
 	h := http.HandlerFunc(s.ExecuteScriptFunc)
 	return UseJson(h)
@@ -81,7 +81,8 @@ func (s *Transactions) CreateFunc(rw http.ResponseWriter, r *http.Request) {
 
 	// Decide whether to serve sync or async, default async
 	sync := r.FormValue(SyncQueryParameter) != ""
-	job, transaction, err := s.service.Create(r.Context(), sync, vars["address"], txReq.Code, txReq.Arguments, transactions.General)
+	job, transaction, err := s.service.Create(r.Context(), sync, vars["address"], txReq.Authorizers, txReq.Code, txReq.Arguments, transactions.General,
+		transactions.WithPayer(txReq.Payer), transactions.WithComputeLimit(txReq.ComputeLimit))
 
 	if err != nil {
 		handleError(rw, r, err)
@@ -120,7 +121,8 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tx, err := s.service.Sign(r.Context(), vars["address"], txReq.Code, txReq.Arguments)
+	tx, err := s.service.Sign(r.Context(), vars["address"], txReq.Authorizers, txReq.Code, txReq.Arguments,
+		transactions.WithPayer(txReq.Payer), transactions.WithComputeLimit(txReq.ComputeLimit))
 	if err != nil {
 		handleError(rw, r, err)
 		return
@@ -139,6 +141,45 @@ func (s *Transactions) SignFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusCreated, resp)
 }
 
+func (s *Transactions) CoSignFunc(rw http.ResponseWriter, r *http.Request) {
+	err := checkNonEmptyBody(r)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var txReq transactions.CoSignJSONRequest
+
+	// Try to decode the request body into the struct.
+	err = json.NewDecoder(r.Body).Decode(&txReq)
+	if err != nil {
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid body"),
+		}
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decide whether to serve sync or async, default async
+	sync := r.FormValue(SyncQueryParameter) != ""
+	job, transaction, err := s.service.CoSign(r.Context(), sync, txReq.Transaction, transactions.WithPayer(txReq.Payer))
+
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var res interface{}
+	if sync {
+		res = transaction.ToJSONResponse()
+	} else {
+		res = job.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
 func (s *Transactions) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	var (
 		transaction *transactions.Transaction
@@ -166,6 +207,28 @@ func (s *Transactions) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
 	handleJsonResponse(rw, http.StatusOK, res)
 }
 
+// Estimate predicts the computation and fee of a transaction.
+func (s *Transactions) EstimateFunc(rw http.ResponseWriter, r *http.Request) {
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	var req transactions.EstimateJSONRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	res, err := s.service.Estimate(req.Code, req.Arguments, transactions.WithComputeLimit(req.ComputeLimit))
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
 func (s *Transactions) ExecuteScriptFunc(rw http.ResponseWriter, r *http.Request) {
 	var err error
 
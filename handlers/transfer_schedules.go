@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/schedules"
+)
+
+// TransferSchedules is a HTTP server for recurring transfer schedules.
+// It provides create, list, details, pause, resume, delete and run history APIs.
+type TransferSchedules struct {
+	service schedules.Service
+}
+
+// NewTransferSchedules initiates a new transfer schedules server.
+func NewTransferSchedules(service schedules.Service) *TransferSchedules {
+	return &TransferSchedules{service}
+}
+
+func (s *TransferSchedules) List() http.Handler {
+	return http.HandlerFunc(s.ListFunc)
+}
+
+func (s *TransferSchedules) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *TransferSchedules) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
+
+func (s *TransferSchedules) Pause() http.Handler {
+	return http.HandlerFunc(s.PauseFunc)
+}
+
+func (s *TransferSchedules) Resume() http.Handler {
+	return http.HandlerFunc(s.ResumeFunc)
+}
+
+func (s *TransferSchedules) Delete() http.Handler {
+	return http.HandlerFunc(s.DeleteFunc)
+}
+
+func (s *TransferSchedules) Runs() http.Handler {
+	return http.HandlerFunc(s.RunsFunc)
+}
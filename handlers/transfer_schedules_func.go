@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/schedules"
+)
+
+// List returns transfer schedules, newest first.
+func (s *TransferSchedules) ListFunc(rw http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePaging(r)
+
+	list, err := s.service.List(limit, offset)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]schedules.JSONResponse, len(list))
+	for i, sch := range list {
+		res[i] = sch.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+// Create adds a new transfer schedule.
+func (s *TransferSchedules) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	var req schedules.JSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	sch, err := s.service.Create(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, sch.ToJSONResponse())
+}
+
+// Details returns a transfer schedule.
+func (s *TransferSchedules) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sch, err := s.service.Details(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sch.ToJSONResponse())
+}
+
+// Pause stops a transfer schedule from creating withdrawals.
+func (s *TransferSchedules) PauseFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sch, err := s.service.Pause(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sch.ToJSONResponse())
+}
+
+// Resume continues a paused transfer schedule from its next occurrence.
+func (s *TransferSchedules) ResumeFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	sch, err := s.service.Resume(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, sch.ToJSONResponse())
+}
+
+// Delete removes a transfer schedule, withdrawals it already created are not affected.
+func (s *TransferSchedules) DeleteFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := s.service.Delete(vars["id"]); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	rw.WriteHeader(http.StatusOK)
+}
+
+// Runs returns the occurrences of a transfer schedule and the withdrawal jobs they created.
+func (s *TransferSchedules) RunsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	limit, offset := parsePaging(r)
+
+	runs, err := s.service.Runs(vars["id"], limit, offset)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	res := make([]schedules.RunJSONResponse, len(runs))
+	for i, run := range runs {
+		res[i] = run.ToJSONResponse()
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
+
+func parsePaging(r *http.Request) (limit, offset int) {
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	offset, err = strconv.Atoi(r.FormValue("offset"))
+	if err != nil {
+		offset = 0
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/auth"
+	"github.com/numeroai/flow-wallet-api/workflows"
+)
+
+// workflowOperationScopes maps workflow operations to the scope of the API
+// they replace, a workflow can only run operations the API key could run.
+var workflowOperationScopes = map[workflows.Operation]auth.Scope{
+	workflows.OpAccountCreate:    auth.ScopeAccountsWrite,
+	workflows.OpTokenSetup:       auth.ScopeTokensWrite,
+	workflows.OpWithdrawalCreate: auth.ScopeTokensWithdraw,
+}
+
+// Workflows is a HTTP server for multi-step workflows.
+// It provides create and details APIs.
+type Workflows struct {
+	service workflows.Service
+}
+
+// NewWorkflows initiates a new workflows server.
+func NewWorkflows(service workflows.Service) *Workflows {
+	return &Workflows{service}
+}
+
+func (s *Workflows) Create() http.Handler {
+	h := http.HandlerFunc(s.CreateFunc)
+	return UseJson(h)
+}
+
+func (s *Workflows) Details() http.Handler {
+	return http.HandlerFunc(s.DetailsFunc)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/workflows"
+)
+
+// Create validates a workflow and schedules its steps.
+func (s *Workflows) CreateFunc(rw http.ResponseWriter, r *http.Request) {
+	var req workflows.JSONRequest
+
+	// Check body is not empty
+	if err := checkNonEmptyBody(r); err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	// Decode JSON
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		handleError(rw, r, InvalidBodyError)
+		return
+	}
+
+	// Requests are only authenticated when auth is enabled
+	if apiKey := APIKeyFromContext(r.Context()); apiKey != nil {
+		for _, step := range req.Steps {
+			scope, ok := workflowOperationScopes[step.Operation]
+			if ok && !apiKey.HasScope(scope) {
+				http.Error(rw, fmt.Sprintf("API key is missing required scope: %s", scope), http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	res, err := s.service.Create(req)
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusCreated, res)
+}
+
+// Details returns a workflow with the aggregate state of its steps.
+func (s *Workflows) DetailsFunc(rw http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	res, err := s.service.Details(vars["id"])
+	if err != nil {
+		handleError(rw, r, err)
+		return
+	}
+
+	handleJsonResponse(rw, http.StatusOK, res)
+}
@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff computes how long an errored job waits before it is executed again.
+// The delay doubles with every execution starting from Base until it reaches
+// Max. Jitter is the fraction of the delay, between 0 and 1, which is randomly
+// subtracted so that jobs failing together do not retry together.
+type Backoff struct {
+	Base   time.Duration
+	Max    time.Duration
+	Jitter float64
+}
+
+var defaultBackoff = Backoff{
+	Base:   1 * time.Minute,
+	Max:    1 * time.Hour,
+	Jitter: 0.1,
+}
+
+// Delay returns the delay after a job has been executed execCount times.
+func (b Backoff) Delay(execCount int) time.Duration {
+	d := b.Base
+	for i := 1; i < execCount && d < b.Max; i++ {
+		d *= 2
+	}
+
+	if d > b.Max {
+		d = b.Max
+	}
+
+	if jitter := math.Min(b.Jitter, 1); jitter > 0 {
+		d -= time.Duration(jitter * rand.Float64() * float64(d))
+	}
+
+	return d
+}
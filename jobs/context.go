@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type executionContextKey struct{}
+
+type execution struct {
+	job   *Job
+	store Store
+}
+
+func contextWithExecution(ctx context.Context, j *Job, store Store) context.Context {
+	return context.WithValue(ctx, executionContextKey{}, &execution{j, store})
+}
+
+// MarkTransactionSubmitted records that the job being executed in ctx is
+// about to submit a Flow transaction, after which the job can no longer be
+// cancelled. It must be called right before sending the transaction and
+// does nothing if ctx does not belong to a job execution.
+func MarkTransactionSubmitted(ctx context.Context) error {
+	e, ok := ctx.Value(executionContextKey{}).(*execution)
+	if !ok {
+		return nil
+	}
+
+	if e.job.TransactionSubmittedAt.Valid {
+		return nil
+	}
+
+	e.job.TransactionSubmittedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	// Store immediately, the job is only updated after execution otherwise
+	return e.store.UpdateJob(e.job)
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Maximum number of response body bytes stored for a delivery.
+const deliveryResponseSnippetLength = 512
+
+// Delivery records a single attempt to deliver a webhook notification.
+type Delivery struct {
+	ID                uuid.UUID     `gorm:"column:id;primary_key;type:uuid;"`
+	NotificationJobID uuid.UUID     `gorm:"column:notification_job_id;type:uuid;index"`
+	ParentJobID       uuid.NullUUID `gorm:"column:parent_job_id;type:uuid;index"`
+	SubscriptionID    string        `gorm:"column:subscription_id"`
+	Event             string        `gorm:"column:event"`
+	URL               string        `gorm:"column:url"`
+	Attempt           int           `gorm:"column:attempt"`
+	StatusCode        int           `gorm:"column:status_code"`
+	Latency           time.Duration `gorm:"column:latency"`
+	ResponseSnippet   string        `gorm:"column:response_snippet"`
+	Error             string        `gorm:"column:error"`
+	CreatedAt         time.Time     `gorm:"column:created_at"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func (d *Delivery) BeforeCreate(tx *gorm.DB) (err error) {
+	d.ID = uuid.New()
+	return nil
+}
+
+// Delivery HTTP response
+type DeliveryJSONResponse struct {
+	ID                uuid.UUID  `json:"id"`
+	NotificationJobID uuid.UUID  `json:"notificationJobId"`
+	ParentJobID       *uuid.UUID `json:"parentJobId,omitempty"`
+	SubscriptionID    string     `json:"subscriptionId,omitempty"`
+	Event             string     `json:"event,omitempty"`
+	URL               string     `json:"url"`
+	Attempt           int        `json:"attempt"`
+	StatusCode        int        `json:"statusCode"`
+	LatencyMs         int64      `json:"latencyMs"`
+	ResponseSnippet   string     `json:"responseSnippet"`
+	Error             string     `json:"error,omitempty"`
+	CreatedAt         time.Time  `json:"createdAt"`
+}
+
+func (d Delivery) ToJSONResponse() DeliveryJSONResponse {
+	res := DeliveryJSONResponse{
+		ID:                d.ID,
+		NotificationJobID: d.NotificationJobID,
+		SubscriptionID:    d.SubscriptionID,
+		Event:             d.Event,
+		URL:               d.URL,
+		Attempt:           d.Attempt,
+		StatusCode:        d.StatusCode,
+		LatencyMs:         d.Latency.Milliseconds(),
+		ResponseSnippet:   d.ResponseSnippet,
+		Error:             d.Error,
+		CreatedAt:         d.CreatedAt,
+	}
+	if d.ParentJobID.Valid {
+		res.ParentJobID = &d.ParentJobID.UUID
+	}
+	return res
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+type JobFinishedPayload struct {
+	Job Job
+}
+
+type jobFinishedHandler interface {
+	Handle(JobFinishedPayload)
+}
+
+type jobFinished struct {
+	handlers []jobFinishedHandler
+}
+
+var JobFinished jobFinished // singleton of type jobFinished
+
+// Register adds an event handler for this event
+func (e *jobFinished) Register(handler jobFinishedHandler) {
+	log.Debug("Registering JobFinished event handler")
+	e.handlers = append(e.handlers, handler)
+}
+
+// Trigger sends out an event with the payload
+func (e *jobFinished) Trigger(payload JobFinishedPayload) {
+	log.
+		WithFields(log.Fields{"jobID": payload.Job.ID, "jobType": payload.Job.Type}).
+		Trace("Handling JobFinished event")
+
+	for _, handler := range e.handlers {
+		go handler.Handle(payload)
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+type JobUpdatedPayload struct {
+	Job Job
+}
+
+type jobUpdatedHandler interface {
+	Handle(JobUpdatedPayload)
+}
+
+type jobUpdated struct {
+	handlers []jobUpdatedHandler
+}
+
+var JobUpdated jobUpdated // singleton of type jobUpdated
+
+// Register adds an event handler for this event
+func (e *jobUpdated) Register(handler jobUpdatedHandler) {
+	log.Debug("Registering JobUpdated event handler")
+	e.handlers = append(e.handlers, handler)
+}
+
+// Trigger sends out an event with the payload. Unlike other events the
+// handlers run synchronously, so that the state changes of a job reach them
+// in the order they happened. Handlers should return quickly.
+func (e *jobUpdated) Trigger(payload JobUpdatedPayload) {
+	log.
+		WithFields(log.Fields{"jobID": payload.Job.ID, "jobType": payload.Job.Type, "jobState": payload.Job.State}).
+		Trace("Handling JobUpdated event")
+
+	for _, handler := range e.handlers {
+		handler.Handle(payload)
+	}
+}
+
+// stateChanged wakes up requests waiting for the job and sends out a
+// JobUpdated event, and a JobFinished event once the job is in a finished
+// state. Notification jobs are not reported.
+func stateChanged(job Job) {
+	updates.notify(job.ID)
+
+	if job.Type == SendJobStatusJobType {
+		return
+	}
+
+	JobUpdated.Trigger(JobUpdatedPayload{Job: job})
+
+	if job.IsFinished() {
+		JobFinished.Trigger(JobFinishedPayload{Job: job})
+	}
+}
@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// jobUpdates wakes up requests waiting for a job to change. Updates made by
+// other instances are only seen here with a JobNotifier, waiters fall back to
+// re-reading the database for those.
+type jobUpdates struct {
+	mu       sync.Mutex
+	watchers map[uuid.UUID]map[chan struct{}]struct{}
+}
+
+var updates = jobUpdates{watchers: make(map[uuid.UUID]map[chan struct{}]struct{})} // singleton of type jobUpdates
+
+// watch returns a channel which receives a value when the job is updated.
+// Several updates before the channel is read are coalesced into one.
+// Call stop when done watching.
+func (u *jobUpdates) watch(id uuid.UUID) (ch <-chan struct{}, stop func()) {
+	c := make(chan struct{}, 1)
+
+	u.mu.Lock()
+	if u.watchers[id] == nil {
+		u.watchers[id] = make(map[chan struct{}]struct{})
+	}
+	u.watchers[id][c] = struct{}{}
+	u.mu.Unlock()
+
+	stop = func() {
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		delete(u.watchers[id], c)
+		if len(u.watchers[id]) == 0 {
+			delete(u.watchers, id)
+		}
+	}
+
+	return c, stop
+}
+
+// notify wakes up everyone watching the job.
+func (u *jobUpdates) notify(id uuid.UUID) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for c := range u.watchers[id] {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package jobs
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -25,11 +27,14 @@ const (
 	Error              State = "ERROR"
 	Complete           State = "COMPLETE"
 	Failed             State = "FAILED"
+	Cancelled          State = "CANCELLED"
+	Blocked            State = "BLOCKED" // Waiting for parent jobs to complete
+	Skipped            State = "SKIPPED" // A parent job did not complete
 )
 
 // Job database model
 type Job struct {
-	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid;index:idx_jobs_created_at_id,priority:2"`
 	Type                   string         `gorm:"column:type"`
 	State                  State          `gorm:"column:state;default:INIT;index:idx_jobs_state_updated_at"`
 	Error                  string         `gorm:"column:error"`
@@ -37,11 +42,19 @@ type Job struct {
 	Result                 string         `gorm:"column:result"`
 	TransactionID          string         `gorm:"column:transaction_id"`
 	ExecCount              int            `gorm:"column:exec_count;default:0"`
-	CreatedAt              time.Time      `gorm:"column:created_at"`
+	CreatedAt              time.Time      `gorm:"column:created_at;index:idx_jobs_created_at_id,priority:1"`
 	UpdatedAt              time.Time      `gorm:"column:updated_at;index:idx_jobs_state_updated_at"`
 	DeletedAt              gorm.DeletedAt `gorm:"column:deleted_at;index"`
 	ShouldSendNotification bool           `gorm:"-"` // Whether or not to notify admin (via webhook for example)
 	Attributes             datatypes.JSON `gorm:"attributes"`
+	TransactionSubmittedAt sql.NullTime   `gorm:"column:transaction_submitted_at"` // Set right before a Flow transaction is sent
+	NextRunAt              sql.NullTime   `gorm:"column:next_run_at;index"`        // Set when the job errors, see Backoff
+	Priority               int            `gorm:"column:priority;default:0"`       // Jobs with a higher priority are executed first
+	WorkerID               string         `gorm:"column:worker_id"`                // Worker pool instance which accepted the job last
+	LeaseExpiresAt         sql.NullTime   `gorm:"column:lease_expires_at;index"`   // Renewed by the worker while the job is ACCEPTED
+	TypedResult            datatypes.JSON `gorm:"column:typed_result"`             // Structured result, see SetTypedResult
+
+	parentIDs []uuid.UUID // Set with WithParents, stored as JobDependency rows
 }
 
 func (Job) TableName() string {
@@ -55,37 +68,79 @@ type JobQueueStatus struct {
 	JobsErrored     int `json:"jobsErrored"`
 	JobsFailed      int `json:"jobsFailed"`
 	JobsCompleted   int `json:"jobsCompleted"`
+	JobsCancelled   int `json:"jobsCancelled"`
+	JobsBlocked     int `json:"jobsBlocked"`
+	JobsSkipped     int `json:"jobsSkipped"`
+}
+
+// JobDependency database model, the job stays BLOCKED until its parent
+// job has completed.
+type JobDependency struct {
+	JobID       uuid.UUID `gorm:"column:job_id;primary_key;type:uuid"`
+	ParentJobID uuid.UUID `gorm:"column:parent_job_id;primary_key;type:uuid;index"`
+}
+
+func (JobDependency) TableName() string {
+	return "job_dependencies"
+}
+
+// Bulk retry HTTP request. At least one filter is required.
+type RetryRequest struct {
+	Type          string     `json:"type"`
+	ErrorContains string     `json:"errorContains"`
+	FailedAfter   *time.Time `json:"failedAfter"`
+	FailedBefore  *time.Time `json:"failedBefore"`
+	Limit         int        `json:"limit"`
+	// Force also retries jobs which have submitted a transaction
+	Force bool `json:"force"`
 }
 
 // Job HTTP response
 type JSONResponse struct {
-	ID            uuid.UUID `json:"jobId"`
-	Type          string    `json:"type"`
-	State         State     `json:"state"`
-	Error         string    `json:"error"`
-	Errors        []string  `json:"errors"`
-	Result        string    `json:"result"`
-	TransactionID string    `json:"transactionId"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	ID            uuid.UUID       `json:"jobId"`
+	Type          string          `json:"type"`
+	State         State           `json:"state"`
+	Error         string          `json:"error"`
+	Errors        []string        `json:"errors"`
+	Result        string          `json:"result"`
+	TypedResult   json.RawMessage `json:"typedResult"` // See the schema of the job type
+	Attributes    json.RawMessage `json:"attributes"`  // Sensitive values are redacted
+	TransactionID string          `json:"transactionId"`
+	ExecCount     int             `json:"execCount"`
+	Priority      int             `json:"priority"`
+	NextRunAt     *time.Time      `json:"nextRunAt"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
 }
 
 func (j Job) ToJSONResponse() JSONResponse {
-	return JSONResponse{
+	res := JSONResponse{
 		ID:            j.ID,
 		Type:          j.Type,
 		State:         j.State,
 		Error:         j.Error,
 		Errors:        []string(j.Errors),
 		Result:        j.Result,
+		Attributes:    sanitizedAttributes(j.Attributes),
 		TransactionID: j.TransactionID,
+		ExecCount:     j.ExecCount,
+		Priority:      j.Priority,
 		CreatedAt:     j.CreatedAt,
 		UpdatedAt:     j.UpdatedAt,
 	}
+	if len(j.TypedResult) > 0 {
+		res.TypedResult = json.RawMessage(j.TypedResult)
+	}
+	if j.NextRunAt.Valid {
+		res.NextRunAt = &j.NextRunAt.Time
+	}
+	return res
 }
 
 func (j *Job) BeforeCreate(tx *gorm.DB) (err error) {
-	j.ID = uuid.New()
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
 	return nil
 }
 
@@ -101,3 +156,23 @@ func (j *Job) logEntry(entry *log.Entry) *log.Entry {
 
 	return log.WithFields(jobFields)
 }
+
+// IsDue returns true if the job is not delayed to a later time.
+func (j Job) IsDue(now time.Time) bool {
+	return !j.NextRunAt.Valid || !j.NextRunAt.Time.After(now)
+}
+
+// IsCancellable returns true if the job is waiting to be executed and no
+// Flow transaction has been submitted for it.
+func (j Job) IsCancellable() bool {
+	if j.TransactionSubmittedAt.Valid {
+		return false
+	}
+	return j.State == Init || j.State == NoAvailableWorkers || j.State == Error || j.State == Blocked
+}
+
+// IsFinished returns true if the job will not be executed again without
+// being retried.
+func (j Job) IsFinished() bool {
+	return j.State == Complete || j.State == Failed || j.State == Cancelled || j.State == Skipped
+}
@@ -9,29 +9,60 @@ import (
 
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus/hooks/test"
 
 	"github.com/numeroai/flow-wallet-api/datastore"
+	"github.com/numeroai/flow-wallet-api/webhooks"
 	"github.com/google/uuid"
 )
 
 type dummyStore struct{}
 
-func (*dummyStore) Jobs(datastore.ListOptions) ([]Job, error) { return nil, nil }
-func (*dummyStore) Job(id uuid.UUID) (Job, error)             { return Job{}, nil }
-func (*dummyStore) InsertJob(*Job) error                      { return nil }
-func (*dummyStore) UpdateJob(*Job) error                      { return nil }
-func (*dummyStore) AcceptJob(j *Job, acceptedGracePeriod time.Duration) error {
+func (*dummyStore) Jobs(JobQuery, datastore.ListOptions) ([]Job, error) { return nil, nil }
+func (*dummyStore) CountJobs(JobFilter) (int64, error)                  { return 0, nil }
+func (*dummyStore) Job(id uuid.UUID) (Job, error)                       { return Job{}, nil }
+func (*dummyStore) InsertJob(*Job) error                                { return nil }
+func (*dummyStore) UpdateJob(*Job) error                                { return nil }
+func (*dummyStore) AcceptJob(j *Job, workerID string, leaseDuration, acceptedGracePeriod time.Duration) error {
 	j.ExecCount = j.ExecCount + 1
 	return nil
 }
+func (*dummyStore) RenewLeases(workerID string, ids []uuid.UUID, leaseDuration time.Duration) (int64, error) {
+	return 0, nil
+}
+func (*dummyStore) CancelJob(id uuid.UUID) (Job, error) { return Job{}, nil }
+func (*dummyStore) ReleaseJob(id uuid.UUID) (Job, bool, error) {
+	return Job{}, false, nil
+}
+func (*dummyStore) RetryJob(id uuid.UUID, force bool) (Job, error) { return Job{}, nil }
+func (*dummyStore) FailedJobs(f RetryFilter, o datastore.ListOptions) ([]Job, error) {
+	return nil, nil
+}
+func (*dummyStore) DependentJobs(parentID uuid.UUID) ([]Job, error)    { return nil, nil }
+func (*dummyStore) BlockedJobs(o datastore.ListOptions) ([]Job, error) { return nil, nil }
+func (*dummyStore) ResolveBlockedJob(id uuid.UUID) (Job, bool, error) {
+	return Job{}, false, nil
+}
 func (*dummyStore) SchedulableJobs(acceptedGracePeriod, reSchedulableGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error) {
 	return nil, nil
 }
-func (*dummyStore) Status() ([]StatusQuery, error) { return nil, nil }
+func (*dummyStore) Status() ([]StatusQuery, error)        { return nil, nil }
+func (*dummyStore) LeasesByWorker() ([]LeaseQuery, error) { return nil, nil }
+func (*dummyStore) InsertDelivery(*Delivery) error        { return nil }
+func (*dummyStore) Deliveries(parentJobID uuid.UUID, o datastore.ListOptions) ([]Delivery, error) {
+	return nil, nil
+}
+func (*dummyStore) Delivery(id uuid.UUID) (Delivery, error) { return Delivery{}, nil }
+
+type dummyResolver struct {
+	target *WebhookTarget
+}
+
+func (r *dummyResolver) WebhookTarget(string) (*WebhookTarget, error) { return r.target, nil }
 
 func TestScheduleSendNotification(t *testing.T) {
 	logger, hook := test.NewNullLogger()
@@ -41,7 +72,7 @@ func TestScheduleSendNotification(t *testing.T) {
 		context:       ctx,
 		cancelContext: cancel,
 		executors:     make(map[string]ExecutorFunc),
-		jobChan:       make(chan *Job, 1),
+		queue:         newJobQueue(1, 0, nil, nil),
 		store:         &dummyStore{},
 	}
 
@@ -70,11 +101,11 @@ func TestScheduleSendNotification(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if len(wp.jobChan) == 0 {
+	if wp.queue.len() == 0 {
 		t.Fatal("expected job channel to contain a job")
 	}
 
-	sendNotificationJob := <-wp.jobChan
+	sendNotificationJob := wp.queue.pop()
 
 	if sendNotificationJob.Type != "send_job_status" {
 		t.Fatalf("expected pool to have a send_job_status job")
@@ -110,7 +141,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:       ctx,
 			cancelContext: cancel,
 			executors:     make(map[string]ExecutorFunc),
-			jobChan:       make(chan *Job, 1),
+			queue:         newJobQueue(1, 0, nil, nil),
 			store:         &dummyStore{},
 		}
 
@@ -133,7 +164,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if err := wp.process(<-wp.jobChan); err != nil {
+		if err := wp.process(wp.queue.pop()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -166,7 +197,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:       ctx,
 			cancelContext: cancel,
 			executors:     make(map[string]ExecutorFunc),
-			jobChan:       make(chan *Job, 1),
+			queue:         newJobQueue(1, 0, nil, nil),
 			store:         &dummyStore{},
 		}
 
@@ -188,7 +219,7 @@ func TestExecuteSendNotification(t *testing.T) {
 		if err := wp.process(job); err != nil {
 			t.Fatal(err)
 		}
-		if err := wp.process(<-wp.jobChan); err != nil {
+		if err := wp.process(wp.queue.pop()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -205,6 +236,103 @@ func TestExecuteSendNotification(t *testing.T) {
 		}
 	})
 
+	t.Run("webhook should be signed when a secret is set", func(t *testing.T) {
+		secret := "webhook-secret"
+		var verifyErr error
+		received := false
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = true
+			_, verifyErr = webhooks.VerifyRequest(r, []byte(secret), webhooks.DefaultTolerance)
+		}))
+		defer svr.Close()
+
+		logger, _ := test.NewNullLogger()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wp := WorkerPoolImpl{
+			context:       ctx,
+			cancelContext: cancel,
+			executors:     make(map[string]ExecutorFunc),
+			queue:         newJobQueue(1, 0, nil, nil),
+			store:         &dummyStore{},
+		}
+
+		WithJobStatusWebhook(svr.URL, time.Minute)(&wp)
+		WithJobStatusWebhookSecret(secret)(&wp)
+		WithLogger(logger)(&wp)
+
+		wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
+
+		wp.RegisterExecutor("TestJobType", func(ctx context.Context, j *Job) error {
+			j.ShouldSendNotification = true
+			return nil
+		})
+
+		job, err := wp.CreateJob("TestJobType", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wp.process(job); err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.process(wp.queue.pop()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !received {
+			t.Fatalf("expected webhook endpoint to have received a notification")
+		}
+
+		if verifyErr != nil {
+			t.Fatalf("expected a valid signature, got %s", verifyErr)
+		}
+	})
+
+	t.Run("subscription notification should be sent to the resolved target", func(t *testing.T) {
+		secret := "subscription-secret"
+		var verifyErr error
+		received := false
+		svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = true
+			_, verifyErr = webhooks.VerifyRequest(r, []byte(secret), webhooks.DefaultTolerance)
+		}))
+		defer svr.Close()
+
+		logger, _ := test.NewNullLogger()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		wp := WorkerPoolImpl{
+			context:       ctx,
+			cancelContext: cancel,
+			executors:     make(map[string]ExecutorFunc),
+			queue:         newJobQueue(1, 0, nil, nil),
+			store:         &dummyStore{},
+		}
+
+		u, _ := url.Parse(svr.URL)
+		WithWebhookTargetResolver(&dummyResolver{&WebhookTarget{URL: u, Secret: []byte(secret), Timeout: time.Minute}})(&wp)
+		WithLogger(logger)(&wp)
+
+		wp.RegisterExecutor(SendJobStatusJobType, wp.executeSendJobStatus)
+
+		if err := wp.ScheduleWebhookNotification("subscription-id", "job.finished", "", "{}"); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wp.process(wp.queue.pop()); err != nil {
+			t.Fatal(err)
+		}
+
+		if !received {
+			t.Fatalf("expected subscription endpoint to have received a notification")
+		}
+
+		if verifyErr != nil {
+			t.Fatalf("expected a valid signature, got %s", verifyErr)
+		}
+	})
+
 	t.Run("erroring job should not send", func(t *testing.T) {
 		logger, _ := test.NewNullLogger()
 
@@ -213,7 +341,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:          ctx,
 			cancelContext:    cancel,
 			executors:        make(map[string]ExecutorFunc),
-			jobChan:          make(chan *Job, 1),
+			queue:            newJobQueue(1, 0, nil, nil),
 			store:            &dummyStore{},
 			maxJobErrorCount: 1,
 		}
@@ -237,7 +365,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if len(wp.jobChan) != 0 {
+		if wp.queue.len() != 0 {
 			t.Errorf("did not expect a job to be queued")
 		}
 	})
@@ -255,7 +383,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			context:          ctx,
 			cancelContext:    cancel,
 			executors:        make(map[string]ExecutorFunc),
-			jobChan:          make(chan *Job, 1),
+			queue:            newJobQueue(1, 0, nil, nil),
 			store:            &dummyStore{},
 			maxJobErrorCount: 1,
 		}
@@ -279,7 +407,7 @@ func TestExecuteSendNotification(t *testing.T) {
 			t.Fatal()
 		}
 
-		sendNotificationJob := <-wp.jobChan
+		sendNotificationJob := wp.queue.pop()
 
 		if err := wp.process(sendNotificationJob); err != nil {
 			t.Fatal(err)
@@ -319,7 +447,7 @@ func TestJobErrorMessages(t *testing.T) {
 			context:          ctx,
 			cancelContext:    cancel,
 			executors:        make(map[string]ExecutorFunc),
-			jobChan:          make(chan *Job, 1),
+			queue:            newJobQueue(1, 0, nil, nil),
 			store:            &dummyStore{},
 			maxJobErrorCount: retryCount,
 		}
@@ -357,7 +485,7 @@ func TestJobErrorMessages(t *testing.T) {
 		}
 
 		// Send the notification
-		sendNotificationJob := <-wp.jobChan
+		sendNotificationJob := wp.queue.pop()
 		if err := wp.process(sendNotificationJob); err != nil {
 			t.Fatal(err)
 		}
@@ -379,3 +507,134 @@ func TestJobErrorMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Base: time.Second, Max: 10 * time.Second}
+
+	expected := []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for execCount, want := range expected {
+		if got := b.Delay(execCount); got != want {
+			t.Errorf("expected delay %s after %d executions, got %s", want, execCount, got)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := b.Delay(3); got < 2*time.Second || got > 4*time.Second {
+			t.Fatalf("expected jittered delay to be within [2s, 4s], got %s", got)
+		}
+	}
+
+	// Large execution counts must not overflow
+	if got := (Backoff{Base: time.Minute, Max: time.Hour}).Delay(1000); got != time.Hour {
+		t.Errorf("expected delay to be capped at %s, got %s", time.Hour, got)
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	t.Run("higher priority first, in order within a priority", func(t *testing.T) {
+		q := newJobQueue(10, 1, nil, nil)
+		a := &Job{Type: "a"}
+		b := &Job{Type: "b", Priority: 10}
+		c := &Job{Type: "c"}
+		for _, j := range []*Job{a, b, c} {
+			if !q.push(j, false) {
+				t.Fatal("expected push to succeed")
+			}
+		}
+
+		for _, want := range []*Job{b, a, c} {
+			if got := q.pop(); got != want {
+				t.Fatalf("expected job of type %q, got %q", want.Type, got.Type)
+			}
+		}
+	})
+
+	t.Run("full queue", func(t *testing.T) {
+		q := newJobQueue(1, 1, nil, nil)
+		if !q.push(&Job{}, false) {
+			t.Fatal("expected push to succeed")
+		}
+		if q.push(&Job{}, false) {
+			t.Fatal("expected push to a full queue to fail")
+		}
+	})
+
+	t.Run("concurrency limit skips jobs of a busy type", func(t *testing.T) {
+		q := newJobQueue(10, 4, map[string]int{"limited": 1}, nil)
+		l1 := &Job{Type: "limited", Priority: 1}
+		l2 := &Job{Type: "limited", Priority: 1}
+		other := &Job{Type: "other"}
+		for _, j := range []*Job{l1, l2, other} {
+			q.push(j, false)
+		}
+
+		if got := q.pop(); got != l1 {
+			t.Fatalf("expected first limited job, got %+v", got)
+		}
+		if got := q.pop(); got != other {
+			t.Fatalf("expected limited job to be skipped, got %+v", got)
+		}
+
+		q.done(l1)
+		if got := q.pop(); got != l2 {
+			t.Fatalf("expected second limited job after the first is done, got %+v", got)
+		}
+	})
+
+	t.Run("reserved workers are kept idle for their type", func(t *testing.T) {
+		q := newJobQueue(10, 2, nil, map[string]int{"reserved": 1})
+		a1 := &Job{Type: "other"}
+		a2 := &Job{Type: "other"}
+		q.push(a1, false)
+		q.push(a2, false)
+
+		if got := q.pop(); got != a1 {
+			t.Fatalf("expected first job, got %+v", got)
+		}
+
+		popped := make(chan *Job)
+		go func() { popped <- q.pop() }()
+
+		select {
+		case j := <-popped:
+			t.Fatalf("expected the last idle worker to be reserved, got %+v", j)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		r := &Job{Type: "reserved"}
+		q.push(r, false)
+
+		if got := <-popped; got != r {
+			t.Fatalf("expected reserved job, got %+v", got)
+		}
+	})
+
+	t.Run("closed queue", func(t *testing.T) {
+		q := newJobQueue(1, 1, nil, nil)
+		q.close()
+		if q.push(&Job{}, true) {
+			t.Fatal("expected push to a closed queue to fail")
+		}
+		if q.pop() != nil {
+			t.Fatal("expected pop from a closed queue to return nil")
+		}
+	})
+}
+
+func TestParseJobTypeValues(t *testing.T) {
+	values, err := ParseJobTypeValues([]string{"withdrawal_create:10", "send_job_status:-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(values, map[string]int{"withdrawal_create": 10, "send_job_status": -5}) {
+		t.Fatalf("unexpected values %v", values)
+	}
+
+	for _, invalid := range []string{"withdrawal_create", ":1", "withdrawal_create:many"} {
+		if _, err := ParseJobTypeValues([]string{invalid}); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package jobs
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SortField is a type for the columns jobs can be listed by.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "createdAt"
+	SortByUpdatedAt SortField = "updatedAt"
+)
+
+func (f SortField) column() string {
+	if f == SortByUpdatedAt {
+		return "updated_at"
+	}
+	return "created_at"
+}
+
+// JobFilter selects jobs in a listing. Empty fields match all jobs.
+type JobFilter struct {
+	States        []State
+	Types         []string
+	TransactionID string
+	// Scheduled only matches delayed jobs which have not started yet.
+	Scheduled     bool
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+	// Attributes maps dot separated paths in Job.Attributes to the value
+	// they must equal, for example "Request.Recipient".
+	Attributes map[string]string
+}
+
+// JobQuery filters, sorts and pages a job listing.
+type JobQuery struct {
+	JobFilter
+	SortBy    SortField
+	Ascending bool
+	// After continues the listing from the job the cursor points to.
+	After *JobCursor
+}
+
+// JobCursor points to a job in a sorted listing. Jobs are ordered by the
+// sort column and then by id, so the position is stable even if other jobs
+// share the same timestamp.
+type JobCursor struct {
+	SortBy    SortField
+	Ascending bool
+	Value     time.Time
+	ID        uuid.UUID
+}
+
+func cursorFor(j Job, q JobQuery) *JobCursor {
+	c := &JobCursor{SortBy: q.SortBy, Ascending: q.Ascending, ID: j.ID, Value: j.CreatedAt}
+	if q.SortBy == SortByUpdatedAt {
+		c.Value = j.UpdatedAt
+	}
+	return c
+}
+
+// String encodes the cursor into an opaque token.
+func (c JobCursor) String() string {
+	order := "desc"
+	if c.Ascending {
+		order = "asc"
+	}
+	raw := strings.Join([]string{string(c.SortBy), order, c.Value.Format(time.RFC3339Nano), c.ID.String()}, "|")
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parseJobCursor(s string) (*JobCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(string(b), "|")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("malformed cursor")
+	}
+
+	c := &JobCursor{SortBy: SortField(parts[0]), Ascending: parts[1] == "asc"}
+
+	if c.Value, err = time.Parse(time.RFC3339Nano, parts[2]); err != nil {
+		return nil, err
+	}
+
+	if c.ID, err = uuid.Parse(parts[3]); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+var attributePathRegexp = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// Job list HTTP request, read from the query string.
+type ListRequest struct {
+	States        []string
+	Types         []string
+	TransactionID string
+	Scheduled     bool
+	CreatedAfter  string
+	CreatedBefore string
+	UpdatedAfter  string
+	UpdatedBefore string
+	Attributes    map[string]string
+	Sort          string
+	Order         string
+	Cursor        string
+	Limit         int
+	Offset        int
+}
+
+// ListResult is a page of a job listing.
+type ListResult struct {
+	Jobs []Job
+	// TotalCount is the number of jobs matching the filters on all pages.
+	TotalCount int64
+	// NextCursor continues the listing, it is empty on the last page.
+	NextCursor string
+}
+
+func isKnownState(s State) bool {
+	switch s {
+	case Init, Accepted, NoAvailableWorkers, Error, Complete, Failed, Cancelled, Blocked, Skipped:
+		return true
+	}
+	return false
+}
@@ -3,10 +3,15 @@ package jobs
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
+
+	"github.com/numeroai/flow-wallet-api/webhooks"
 )
 
 const SendJobStatusJobType = "send_job_status"
@@ -14,6 +19,30 @@ const SendJobStatusJobType = "send_job_status"
 type NotificationConfig struct {
 	jobStatusWebhookUrl     *url.URL
 	jobStatusWebhookTimeout time.Duration
+	jobStatusWebhookSecret  []byte
+	webhookTargetResolver   WebhookTargetResolver
+}
+
+// WebhookTarget is an endpoint a notification is delivered to.
+type WebhookTarget struct {
+	URL     *url.URL
+	Secret  []byte
+	Timeout time.Duration
+}
+
+// WebhookTargetResolver resolves the target of notifications that were
+// scheduled for a webhook subscription.
+type WebhookTargetResolver interface {
+	WebhookTarget(subscriptionID string) (*WebhookTarget, error)
+}
+
+// notificationJobAttributes are stored on SendJobStatusJobType jobs.
+// Jobs with a subscription id deliver to the subscription instead of the
+// configured job status webhook.
+type notificationJobAttributes struct {
+	SubscriptionID string `json:"subscriptionId,omitempty"`
+	Event          string `json:"event,omitempty"`
+	ParentJobID    string `json:"parentJobId,omitempty"`
 }
 
 func (cfg *NotificationConfig) ShouldSendJobStatus() bool {
@@ -40,25 +69,92 @@ func (cfg *NotificationConfig) SendJobStatusWebhook(ctx context.Context, content
 		return nil
 	}
 
-	client := http.Client{
+	_, err := sendWebhook(ctx, cfg.jobStatusWebhookTarget(), content)
+	return err
+}
+
+func (cfg *NotificationConfig) jobStatusWebhookTarget() *WebhookTarget {
+	return &WebhookTarget{
+		URL:     cfg.jobStatusWebhookUrl,
+		Secret:  cfg.jobStatusWebhookSecret,
 		Timeout: cfg.jobStatusWebhookTimeout,
 	}
+}
+
+// target returns the webhook target of a notification job, or nil if there is none.
+func (cfg *NotificationConfig) target(attrs notificationJobAttributes) (*WebhookTarget, error) {
+	if attrs.SubscriptionID == "" {
+		if cfg.jobStatusWebhookUrl == nil {
+			return nil, nil
+		}
+		return cfg.jobStatusWebhookTarget(), nil
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", cfg.jobStatusWebhookUrl.String(), bytes.NewBuffer([]byte(content)))
+	if cfg.webhookTargetResolver == nil {
+		return nil, PermanentFailure(fmt.Errorf("no webhook target resolver configured"))
+	}
+
+	target, err := cfg.webhookTargetResolver.WebhookTarget(attrs.SubscriptionID)
 	if err != nil {
-		return fmt.Errorf("error while creating webhook request: %w", err)
+		if err.Error() == "record not found" {
+			// Subscription was deleted after the notification was scheduled
+			return nil, PermanentFailure(fmt.Errorf("webhook subscription %s not found", attrs.SubscriptionID))
+		}
+		return nil, err
+	}
+
+	return target, nil
+}
+
+// sendWebhook delivers content to target. The returned Delivery describes
+// the attempt, also when an error is returned.
+func sendWebhook(ctx context.Context, target *WebhookTarget, content string) (*Delivery, error) {
+	delivery := &Delivery{URL: target.URL.String()}
+
+	client := http.Client{
+		Timeout: target.Timeout,
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", target.URL.String(), bytes.NewBuffer([]byte(content)))
+	if err != nil {
+		return delivery, fmt.Errorf("error while creating webhook request: %w", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
 
+	// Sign the payload so the receiver can verify it came from us,
+	// the timestamp is part of the signature to prevent replays
+	if len(target.Secret) > 0 {
+		req.Header.Add(webhooks.SignatureHeader, webhooks.Sign(target.Secret, []byte(content), time.Now()))
+	}
+
+	begin := time.Now()
 	resp, err := client.Do(req)
+	delivery.Latency = time.Since(begin)
 	if err != nil {
-		return fmt.Errorf("error while sending webhook request: %w", err)
+		return delivery, fmt.Errorf("error while sending webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	if snippet, err := io.ReadAll(io.LimitReader(resp.Body, deliveryResponseSnippetLength)); err == nil {
+		delivery.ResponseSnippet = strings.ToValidUTF8(string(snippet), "")
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("webhook endpoint responded with an unexpected status code: %d", resp.StatusCode)
+		return delivery, fmt.Errorf("webhook endpoint responded with an unexpected status code: %d", resp.StatusCode)
 	}
 
-	return nil
+	return delivery, nil
+}
+
+func parseNotificationJobAttributes(j *Job) (notificationJobAttributes, error) {
+	var attrs notificationJobAttributes
+	if len(j.Attributes) == 0 {
+		return attrs, nil
+	}
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return attrs, PermanentFailure(fmt.Errorf("invalid notification job attributes: %w", err))
+	}
+	return attrs, nil
 }
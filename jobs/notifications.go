@@ -0,0 +1,124 @@
+package jobs
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+)
+
+// JobNotificationChannel is the PostgreSQL channel GormStore notifies on
+// when a job is inserted or changes state.
+const JobNotificationChannel = "wallet_jobs"
+
+// Ping the listener connection this often to notice when it has been lost.
+const pqNotifierPingInterval = 90 * time.Second
+
+// JobNotification tells that a job was inserted or changed state, possibly
+// on another instance. A notification with a nil ID means notifications may
+// have been missed, e.g. while reconnecting.
+type JobNotification struct {
+	ID    uuid.UUID `json:"id"`
+	State State     `json:"state"`
+}
+
+// JobNotifier delivers job notifications to a worker pool, see
+// WithJobNotifier.
+type JobNotifier interface {
+	Notifications() <-chan JobNotification
+}
+
+// PqNotifier listens to the notifications sent by GormStore on PostgreSQL.
+type PqNotifier struct {
+	listener      *pq.Listener
+	notifications chan JobNotification
+	done          chan struct{}
+}
+
+// NewPqNotifier connects to the PostgreSQL database at dsn and starts
+// listening on JobNotificationChannel. The connection is re-established
+// automatically, call Close when done.
+func NewPqNotifier(dsn string) (*PqNotifier, error) {
+	entry := log.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "PqNotifier",
+	})
+
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			entry.
+				WithFields(log.Fields{"error": err, "event": event}).
+				Warn("Job notification listener error")
+		}
+	})
+
+	if err := listener.Listen(JobNotificationChannel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	n := &PqNotifier{
+		listener:      listener,
+		notifications: make(chan JobNotification, 100),
+		done:          make(chan struct{}),
+	}
+
+	go n.run(entry)
+
+	return n, nil
+}
+
+func (n *PqNotifier) Notifications() <-chan JobNotification {
+	return n.notifications
+}
+
+// Close stops listening and closes the connection.
+func (n *PqNotifier) Close() error {
+	close(n.done)
+	return n.listener.Close()
+}
+
+func (n *PqNotifier) run(entry *log.Entry) {
+	defer close(n.notifications)
+
+	ping := time.NewTicker(pqNotifierPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case pn, ok := <-n.listener.Notify:
+			if !ok {
+				return
+			}
+
+			var jn JobNotification
+			if pn != nil {
+				// pq sends nil after reconnecting, jn is left empty in that case
+				if err := json.Unmarshal([]byte(pn.Extra), &jn); err != nil {
+					entry.
+						WithFields(log.Fields{"error": err, "payload": pn.Extra}).
+						Warn("Invalid job notification")
+					continue
+				}
+			}
+
+			select {
+			case n.notifications <- jn:
+			case <-n.done:
+				return
+			}
+		case <-ping.C:
+			go func() {
+				if err := n.listener.Ping(); err != nil {
+					entry.
+						WithFields(log.Fields{"error": err}).
+						Debug("Job notification listener ping failed")
+				}
+			}()
+		case <-n.done:
+			return
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package jobs
 
 import (
+	"database/sql"
+	"fmt"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/numeroai/flow-wallet-api/system"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/datatypes"
@@ -11,6 +16,7 @@ import (
 
 type WorkerPoolOption func(*WorkerPoolImpl)
 type JobOption func(*Job)
+type ServiceOption func(*ServiceImpl)
 
 func WithJobStatusWebhook(u string, timeout time.Duration) WorkerPoolOption {
 	return func(wp *WorkerPoolImpl) {
@@ -32,6 +38,34 @@ func WithJobStatusWebhook(u string, timeout time.Duration) WorkerPoolOption {
 	}
 }
 
+// WithJobStatusWebhookSecret enables signing of job status webhook requests,
+// see the webhooks package for the signature format.
+func WithJobStatusWebhookSecret(secret string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if secret == "" {
+			return
+		}
+
+		if wp.notificationConfig == nil {
+			wp.notificationConfig = &NotificationConfig{}
+		}
+
+		wp.notificationConfig.jobStatusWebhookSecret = []byte(secret)
+	}
+}
+
+// WithWebhookTargetResolver sets the resolver used to deliver notifications
+// scheduled with ScheduleWebhookNotification.
+func WithWebhookTargetResolver(r WebhookTargetResolver) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if wp.notificationConfig == nil {
+			wp.notificationConfig = &NotificationConfig{}
+		}
+
+		wp.notificationConfig.webhookTargetResolver = r
+	}
+}
+
 func WithSystemService(svc system.Service) WorkerPoolOption {
 	return func(wp *WorkerPoolImpl) {
 		wp.systemService = svc
@@ -56,20 +90,149 @@ func WithDbJobPollInterval(d time.Duration) WorkerPoolOption {
 	}
 }
 
+// WithJobNotifier wakes up the worker pool when jobs are inserted or change
+// state on any instance, instead of waiting for the next DB poll.
+func WithJobNotifier(n JobNotifier) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.notifier = n
+	}
+}
+
+// WithRedisQueue shares the jobs waiting for a worker between all instances
+// through a Redis stream instead of keeping them in memory. The stream is
+// read by cfg.Group, each instance being a consumer identified by its worker
+// ID. Requires Redis 6.2 or later.
+func WithRedisQueue(cfg RedisQueueConfig) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if cfg.Pool == nil {
+			return
+		}
+		wp.redisQueue = &cfg
+	}
+}
+
 func WithAcceptedGracePeriod(d time.Duration) WorkerPoolOption {
 	return func(wp *WorkerPoolImpl) {
 		wp.acceptedGracePeriod = d
 	}
 }
 
+// WithWorkerID sets the ID of this worker pool instance, it is stored on the
+// jobs the instance accepts. Defaults to the hostname with a random suffix.
+func WithWorkerID(id string) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if id != "" {
+			wp.workerID = id
+		}
+	}
+}
+
+// WithLeaseDuration sets how long an ACCEPTED job stays leased to this
+// instance without a heartbeat.
+func WithLeaseDuration(d time.Duration) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		if d > 0 {
+			wp.leaseDuration = d
+		}
+	}
+}
+
 func WithReSchedulableGracePeriod(d time.Duration) WorkerPoolOption {
 	return func(wp *WorkerPoolImpl) {
 		wp.reSchedulableGracePeriod = d
 	}
 }
 
+// WithBackoff sets how long errored jobs wait before they are executed again.
+func WithBackoff(base, max time.Duration, jitter float64) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.backoff = Backoff{Base: base, Max: max, Jitter: jitter}
+	}
+}
+
+// WithJobTypePriorities sets the default priority of new jobs per job type.
+func WithJobTypePriorities(priorities map[string]int) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.jobTypePriorities = priorities
+	}
+}
+
+// WithJobTypeConcurrency limits how many jobs of a type can be executed at
+// the same time.
+func WithJobTypeConcurrency(limits map[string]int) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.jobTypeConcurrency = limits
+	}
+}
+
+// WithJobTypeReservedWorkers reserves workers for job types, other job types
+// are not executed if that would leave too few idle workers for them.
+func WithJobTypeReservedWorkers(reserved map[string]int) WorkerPoolOption {
+	return func(wp *WorkerPoolImpl) {
+		wp.jobTypeReservedWorkers = reserved
+	}
+}
+
+// ParseJobTypeValues parses a list of "<job type>:<integer>" pairs.
+func ParseJobTypeValues(values []string) (map[string]int, error) {
+	res := make(map[string]int, len(values))
+	for _, v := range values {
+		ss := strings.Split(v, ":")
+		if len(ss) != 2 || ss[0] == "" {
+			return nil, fmt.Errorf("invalid job type value %q, expected <job type>:<integer>", v)
+		}
+		n, err := strconv.Atoi(ss[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid job type value %q, expected <job type>:<integer>", v)
+		}
+		res[ss[0]] = n
+	}
+	return res, nil
+}
+
+// WithRunAt delays the execution of a job until t.
+func WithRunAt(t time.Time) JobOption {
+	return func(job *Job) {
+		job.NextRunAt = sql.NullTime{Time: t, Valid: true}
+	}
+}
+
+func WithPriority(priority int) JobOption {
+	return func(job *Job) {
+		job.Priority = priority
+	}
+}
+
+// WithParents makes a job wait until all of its parent jobs have completed.
+// The job is skipped if any of its parents fails, is cancelled or skipped.
+func WithParents(ids ...uuid.UUID) JobOption {
+	return func(job *Job) {
+		job.parentIDs = append(job.parentIDs, ids...)
+	}
+}
+
 func WithAttributes(attributes datatypes.JSON) JobOption {
 	return func(job *Job) {
 		job.Attributes = attributes
 	}
 }
+
+// WithMaxWaitTimeout limits how long a request may wait for a job to change.
+// Keep it below the server request timeout.
+func WithMaxWaitTimeout(d time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) {
+		if d > 0 {
+			svc.maxWaitTimeout = d
+		}
+	}
+}
+
+// WithWaitPollInterval sets how often waiting requests re-read a job from
+// the database to see updates made by other instances without a JobNotifier.
+func WithWaitPollInterval(d time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) {
+		if d > 0 {
+			svc.waitPollInterval = d
+		}
+	}
+}
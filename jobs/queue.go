@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+)
+
+// queueBackend holds the jobs waiting for a worker of this instance. jobQueue
+// keeps them in memory, redisQueue shares them between instances.
+type queueBackend interface {
+	push(j *Job, block bool) bool
+	pop() *Job
+	done(j *Job)
+	len() int
+	// drain removes the jobs waiting for a worker and returns the ones the
+	// worker pool has to release to other instances.
+	drain() []*Job
+	close()
+}
+
+// jobQueue is a bounded queue of jobs waiting for a worker. Jobs are taken in
+// priority order, first in first out within the same priority, skipping jobs
+// whose type has reached its concurrency limit or would take a worker
+// reserved for another type.
+type jobQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	jobs     []*Job
+	capacity int
+	closed   bool
+
+	workerCount int
+	limits      map[string]int // Max concurrent executions per job type
+	reserved    map[string]int // Workers reserved per job type
+	running     map[string]int
+	busy        int
+}
+
+func newJobQueue(capacity, workerCount uint, limits, reserved map[string]int) *jobQueue {
+	q := &jobQueue{
+		capacity:    int(capacity),
+		workerCount: int(workerCount),
+		limits:      limits,
+		reserved:    reserved,
+		running:     make(map[string]int),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push adds a job to the queue. If the queue is full it either waits for
+// room or returns false right away depending on block.
+func (q *jobQueue) push(j *Job, block bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.jobs) >= q.capacity && !q.closed {
+		if !block {
+			return false
+		}
+		q.cond.Wait()
+	}
+
+	if q.closed {
+		return false
+	}
+
+	i := sort.Search(len(q.jobs), func(i int) bool {
+		return q.jobs[i].Priority < j.Priority
+	})
+	q.jobs = append(q.jobs, nil)
+	copy(q.jobs[i+1:], q.jobs[i:])
+	q.jobs[i] = j
+
+	q.cond.Broadcast()
+
+	return true
+}
+
+// pop waits for a job that can be executed and returns it. It returns nil
+// once the queue is closed and empty. done must be called for the returned
+// job after it has been executed.
+func (q *jobQueue) pop() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		for i, j := range q.jobs {
+			if q.canRun(j.Type) {
+				q.jobs = append(q.jobs[:i], q.jobs[i+1:]...)
+				q.running[j.Type]++
+				q.busy++
+				q.cond.Broadcast()
+				return j
+			}
+		}
+
+		if q.closed && len(q.jobs) == 0 {
+			return nil
+		}
+
+		q.cond.Wait()
+	}
+}
+
+func (q *jobQueue) done(j *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.running[j.Type]--
+	q.busy--
+	q.cond.Broadcast()
+}
+
+// canRun checks if a job of type jobType can be started without exceeding
+// its limit and while leaving enough idle workers for the reservations of
+// all types.
+func (q *jobQueue) canRun(jobType string) bool {
+	if limit, ok := q.limits[jobType]; ok && q.running[jobType] >= limit {
+		return false
+	}
+
+	deficit := 0
+	for t, n := range q.reserved {
+		missing := n - q.running[t]
+		if t == jobType {
+			// Starting this job fills one of its own reserved workers
+			missing--
+		}
+		if missing > 0 {
+			deficit += missing
+		}
+	}
+
+	return deficit == 0 || q.workerCount-q.busy-1 >= deficit
+}
+
+func (q *jobQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+func (q *jobQueue) drain() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jj := q.jobs
+	q.jobs = nil
+	q.cond.Broadcast()
+	return jj
+}
+
+func (q *jobQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
@@ -0,0 +1,438 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// Block reading the stream for at most this long to notice when the
+	// queue is closed
+	redisQueueReadBlock = time.Second
+	// Wait this long before reading again after an error
+	redisQueueRetryInterval = time.Second
+	// Claim pending entries of other instances in batches of this size
+	redisQueueClaimCount = 100
+)
+
+// RedisQueueConfig configures the Redis stream shared by the worker pools,
+// see WithRedisQueue.
+type RedisQueueConfig struct {
+	Pool   *redis.Pool
+	Stream string
+	Group  string
+}
+
+type streamEntry struct {
+	id    string
+	jobID string
+}
+
+// redisQueue shares the jobs waiting for a worker between instances through
+// a Redis stream read by a consumer group. Each instance reads entries into
+// its local jobQueue, which keeps applying priorities and job type limits,
+// and acknowledges an entry once its job has been executed. Entries left
+// pending by a crashed instance are claimed by another one after the lease
+// duration. Entries only carry the job ID, the jobs table stays the record
+// of job state.
+type redisQueue struct {
+	cfg      RedisQueueConfig
+	consumer string
+	minIdle  time.Duration
+	local    *jobQueue
+	store    Store
+	logger   *log.Logger
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu      sync.Mutex
+	entries map[*Job]string // Stream entry IDs of the jobs read into local
+	reading map[string]bool // Entry IDs read into local and not yet acknowledged
+}
+
+func newRedisQueue(cfg RedisQueueConfig, local *jobQueue, store Store, consumer string, minIdle time.Duration, logger *log.Logger) *redisQueue {
+	return &redisQueue{
+		cfg:      cfg,
+		consumer: consumer,
+		minIdle:  minIdle,
+		local:    local,
+		store:    store,
+		logger:   logger,
+		closed:   make(chan struct{}),
+		entries:  make(map[*Job]string),
+		reading:  make(map[string]bool),
+	}
+}
+
+// push adds the job to the stream. The stream is not bounded, block is
+// only relevant for the local queue.
+func (q *redisQueue) push(j *Job, block bool) bool {
+	select {
+	case <-q.closed:
+		return false
+	default:
+	}
+
+	return q.add(j)
+}
+
+func (q *redisQueue) add(j *Job) bool {
+	conn := q.cfg.Pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("XADD", q.cfg.Stream, "*", "job", j.ID.String()); err != nil {
+		j.logEntry(q.logger.WithFields(log.Fields{
+			"package":  "jobs",
+			"function": "redisQueue.add",
+			"error":    err,
+		})).Warn("Could not add job to Redis stream")
+		return false
+	}
+
+	return true
+}
+
+// pop starts reading the stream on first use, so that instances which have
+// not started their workers leave the entries to others.
+func (q *redisQueue) pop() *Job {
+	q.startOnce.Do(func() {
+		go q.read()
+	})
+	return q.local.pop()
+}
+
+func (q *redisQueue) done(j *Job) {
+	q.local.done(j)
+
+	q.mu.Lock()
+	id, ok := q.entries[j]
+	delete(q.entries, j)
+	delete(q.reading, id)
+	q.mu.Unlock()
+
+	if ok {
+		q.ack(id)
+	}
+}
+
+func (q *redisQueue) len() int {
+	return q.local.len()
+}
+
+// drain stops reading the stream and hands the jobs read into the local queue
+// back to the other instances through new entries.
+func (q *redisQueue) drain() []*Job {
+	q.closeOnce.Do(func() {
+		close(q.closed)
+	})
+
+	jj := q.local.drain()
+	for _, j := range jj {
+		q.mu.Lock()
+		id, ok := q.entries[j]
+		delete(q.entries, j)
+		delete(q.reading, id)
+		q.mu.Unlock()
+
+		if !q.add(j) {
+			// Stays pending and is claimed by another instance
+			continue
+		}
+		if ok {
+			q.ack(id)
+		}
+	}
+
+	return jj
+}
+
+func (q *redisQueue) close() {
+	q.closeOnce.Do(func() {
+		close(q.closed)
+	})
+	q.local.close()
+}
+
+func (q *redisQueue) read() {
+	entry := q.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "redisQueue.read",
+		"stream":   q.cfg.Stream,
+	})
+
+	for {
+		err := q.createGroup()
+		if q.sleepOnError(entry, err, "Could not create Redis consumer group") {
+			return
+		}
+		if err == nil {
+			break
+		}
+	}
+
+	claim := time.NewTicker(q.minIdle)
+	defer claim.Stop()
+
+	for {
+		select {
+		case <-q.closed:
+			return
+		case <-claim.C:
+			if q.sleepOnError(entry, q.claimPending(), "Could not claim pending Redis stream entries") {
+				return
+			}
+			continue
+		default:
+		}
+
+		count := q.local.capacity - q.local.len()
+		if count <= 0 {
+			// Local queue is full, let the workers catch up
+			select {
+			case <-q.closed:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+			continue
+		}
+
+		entries, err := q.readGroup(count)
+		if q.sleepOnError(entry, err, "Could not read Redis stream") {
+			return
+		}
+
+		q.enqueue(entries)
+	}
+}
+
+// sleepOnError logs err and waits before the next try. It returns true once
+// the queue is closed, and false if there was no error.
+func (q *redisQueue) sleepOnError(entry *log.Entry, err error, msg string) (closed bool) {
+	if err == nil {
+		select {
+		case <-q.closed:
+			return true
+		default:
+			return false
+		}
+	}
+
+	entry.WithFields(log.Fields{"error": err}).Warn(msg)
+
+	select {
+	case <-q.closed:
+		return true
+	case <-time.After(redisQueueRetryInterval):
+		return false
+	}
+}
+
+func (q *redisQueue) createGroup() error {
+	conn := q.cfg.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("XGROUP", "CREATE", q.cfg.Stream, q.cfg.Group, "0", "MKSTREAM")
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		// Created by another instance
+		return nil
+	}
+	return err
+}
+
+func (q *redisQueue) readGroup(count int) ([]streamEntry, error) {
+	conn := q.cfg.Pool.Get()
+	defer conn.Close()
+
+	reply, err := conn.Do(
+		"XREADGROUP", "GROUP", q.cfg.Group, q.consumer,
+		"COUNT", count,
+		"BLOCK", redisQueueReadBlock.Milliseconds(),
+		"STREAMS", q.cfg.Stream, ">",
+	)
+	if err != nil || reply == nil {
+		// Nil reply when the read timed out
+		return nil, err
+	}
+
+	streams, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []streamEntry
+	for _, s := range streams {
+		nameAndEntries, err := redis.Values(s, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(nameAndEntries) != 2 {
+			return nil, fmt.Errorf("unexpected XREADGROUP reply")
+		}
+		entries, err := parseStreamEntries(nameAndEntries[1])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, entries...)
+	}
+
+	return res, nil
+}
+
+// claimPending takes over the entries other instances have not acknowledged
+// within the lease duration.
+func (q *redisQueue) claimPending() error {
+	conn := q.cfg.Pool.Get()
+	defer conn.Close()
+
+	start := "0-0"
+	for {
+		reply, err := redis.Values(conn.Do(
+			"XAUTOCLAIM", q.cfg.Stream, q.cfg.Group, q.consumer,
+			q.minIdle.Milliseconds(), start,
+			"COUNT", redisQueueClaimCount,
+		))
+		if err != nil {
+			return err
+		}
+		if len(reply) < 2 {
+			return fmt.Errorf("unexpected XAUTOCLAIM reply")
+		}
+
+		start, err = redis.String(reply[0], nil)
+		if err != nil {
+			return err
+		}
+
+		entries, err := parseStreamEntries(reply[1])
+		if err != nil {
+			return err
+		}
+
+		q.enqueue(entries)
+
+		if start == "0-0" {
+			return nil
+		}
+	}
+}
+
+// enqueue loads the jobs of the entries and adds them to the local queue.
+// Entries whose job no longer needs a worker are acknowledged right away.
+func (q *redisQueue) enqueue(entries []streamEntry) {
+	for _, e := range entries {
+		q.mu.Lock()
+		reading := q.reading[e.id]
+		q.mu.Unlock()
+		if reading {
+			// Claimed back from this instance while waiting in the local queue
+			continue
+		}
+
+		id, err := uuid.Parse(e.jobID)
+		if err != nil {
+			q.ack(e.id)
+			continue
+		}
+
+		job, err := q.store.Job(id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			q.ack(e.id)
+			continue
+		}
+		if err != nil {
+			// Stays pending and is claimed again
+			q.logger.
+				WithFields(log.Fields{"error": err, "jobID": id}).
+				Warn("Could not fetch job of Redis stream entry from DB")
+			continue
+		}
+
+		if job.IsFinished() || (job.State == Accepted && isLeased(&job, time.Now(), q.minIdle)) {
+			// Finished, or executed by another instance which holds the lease
+			q.ack(e.id)
+			continue
+		}
+
+		q.mu.Lock()
+		q.entries[&job] = e.id
+		q.reading[e.id] = true
+		q.mu.Unlock()
+
+		if !q.local.push(&job, true) {
+			// Closed, the entry is claimed by another instance
+			q.mu.Lock()
+			delete(q.entries, &job)
+			delete(q.reading, e.id)
+			q.mu.Unlock()
+			return
+		}
+	}
+}
+
+// ack removes an entry from the stream, it has been consumed by the only
+// consumer group.
+func (q *redisQueue) ack(id string) {
+	conn := q.cfg.Pool.Get()
+	defer conn.Close()
+
+	if _, err := conn.Do("XACK", q.cfg.Stream, q.cfg.Group, id); err != nil {
+		q.logger.
+			WithFields(log.Fields{"error": err, "entryID": id}).
+			Warn("Could not acknowledge Redis stream entry")
+		return
+	}
+
+	if _, err := conn.Do("XDEL", q.cfg.Stream, id); err != nil {
+		q.logger.
+			WithFields(log.Fields{"error": err, "entryID": id}).
+			Warn("Could not delete Redis stream entry")
+	}
+}
+
+// parseStreamEntries parses a list of [id, [field, value, ...]] entries.
+// Entries deleted while pending have no fields.
+func parseStreamEntries(reply interface{}) ([]streamEntry, error) {
+	values, err := redis.Values(reply, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]streamEntry, 0, len(values))
+	for _, v := range values {
+		idAndFields, err := redis.Values(v, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(idAndFields) != 2 {
+			return nil, fmt.Errorf("unexpected stream entry")
+		}
+
+		id, err := redis.String(idAndFields[0], nil)
+		if err != nil {
+			return nil, err
+		}
+
+		e := streamEntry{id: id}
+		if idAndFields[1] != nil {
+			fields, err := redis.StringMap(idAndFields[1], nil)
+			if err != nil {
+				return nil, err
+			}
+			e.jobID = fields["job"]
+		}
+
+		res = append(res, e)
+	}
+
+	return res, nil
+}
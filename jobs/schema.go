@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const redactedAttribute = "[REDACTED]"
+
+// Attribute keys whose values are never returned by the job API. Keys are
+// compared in lower case without '_' and '-'.
+var sensitiveAttributeKeys = []string{"secret", "password", "privatekey", "apikey", "authorization", "seed", "mnemonic"}
+
+// Schema describes the attributes and the typed result of a job type as JSON
+// schemas. A nil schema means the job type has no attributes or typed result.
+type Schema struct {
+	JobType    string          `json:"jobType"`
+	Attributes json.RawMessage `json:"attributes"`
+	Result     json.RawMessage `json:"result"`
+}
+
+type ExecutorOption func(*Schema)
+
+// WithAttributesSchema publishes the JSON schema of v, the type stored in
+// the attributes of the job type.
+func WithAttributesSchema(v interface{}) ExecutorOption {
+	return func(s *Schema) {
+		s.Attributes = mustMarshalSchema(v)
+	}
+}
+
+// WithResultSchema publishes the JSON schema of v, the type the executor
+// stores with Job.SetTypedResult.
+func WithResultSchema(v interface{}) ExecutorOption {
+	return func(s *Schema) {
+		s.Result = mustMarshalSchema(v)
+	}
+}
+
+// SetTypedResult stores v as the structured result of the job, returned
+// alongside the legacy string Result.
+func (j *Job) SetTypedResult(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	j.TypedResult = b
+	return nil
+}
+
+// sanitizedAttributes returns the attributes with the values of sensitive
+// keys redacted. Attributes which are not valid JSON are left out.
+func sanitizedAttributes(attrs datatypes.JSON) json.RawMessage {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(attrs, &v); err != nil {
+		return nil
+	}
+
+	b, err := json.Marshal(redactAttributes(v))
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+func redactAttributes(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if isSensitiveAttribute(key) {
+				v[key] = redactedAttribute
+			} else {
+				v[key] = redactAttributes(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = redactAttributes(value)
+		}
+	}
+	return v
+}
+
+func isSensitiveAttribute(key string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, s := range sensitiveAttributeKeys {
+		if strings.HasSuffix(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func mustMarshalSchema(v interface{}) json.RawMessage {
+	b, err := json.Marshal(jsonSchema(reflect.TypeOf(v)))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonType          = reflect.TypeOf(datatypes.JSON{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// jsonSchema describes how encoding/json encodes values of type t. Types
+// with a custom encoding, like flow.Address, are assumed to encode as
+// strings.
+func jsonSchema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case rawMessageType, jsonType:
+		return map[string]interface{}{}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		s := jsonSchema(t.Elem())
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		}
+		return s
+	}
+
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Encoded as base64
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	// Interfaces can hold any value
+	return map[string]interface{}{}
+}
+
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			// Unexported
+			continue
+		}
+
+		name := f.Name
+		omitempty := false
+		if tag, ok := f.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			opts := strings.Split(tag, ",")
+			if opts[0] != "" {
+				name = opts[0]
+			}
+			for _, o := range opts[1:] {
+				omitempty = omitempty || o == "omitempty"
+			}
+		}
+
+		properties[name] = jsonSchema(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	sort.Strings(required)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
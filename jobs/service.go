@@ -1,8 +1,10 @@
 package jobs
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/numeroai/flow-wallet-api/datastore"
 	"github.com/numeroai/flow-wallet-api/errors"
@@ -11,32 +13,149 @@ import (
 )
 
 type Service interface {
-	List(limit, offset int) (*[]Job, error)
+	List(req ListRequest) (*ListResult, error)
 	Details(jobID string) (*Job, error)
+	Deliveries(jobID string, limit, offset int) (*[]Delivery, error)
+	ReplayDelivery(jobID, deliveryID string) (*Job, error)
+	Wait(ctx context.Context, jobID string, req WaitRequest) (*Job, error)
+	Cancel(jobID string) (*Job, error)
+	Retry(jobID string, force bool) (*Job, error)
+	RetryFailed(req RetryRequest) (*[]Job, error)
+	Schemas() []Schema
+	Schema(jobType string) (*Schema, error)
 }
 
 // ServiceImpl defines the API for job HTTP handlers.
 type ServiceImpl struct {
-	store Store
+	store            Store
+	wp               WorkerPool
+	maxWaitTimeout   time.Duration
+	waitPollInterval time.Duration
 }
 
 // NewService initiates a new job service.
-func NewService(store Store) Service {
-	return &ServiceImpl{store}
+func NewService(store Store, wp WorkerPool, opts ...ServiceOption) Service {
+	svc := &ServiceImpl{
+		store:            store,
+		wp:               wp,
+		maxWaitTimeout:   30 * time.Second,
+		waitPollInterval: 2 * time.Second,
+	}
+
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	return svc
 }
 
-// List returns all jobs in the datastore.
-func (s *ServiceImpl) List(limit, offset int) (*[]Job, error) {
-	log.WithFields(log.Fields{"limit": limit, "offset": offset}).Trace("List jobs")
+// List returns a page of the jobs matching the request, sorted by creation
+// time with the newest first unless the request says otherwise.
+func (s *ServiceImpl) List(req ListRequest) (*ListResult, error) {
+	log.WithFields(log.Fields{"request": req}).Trace("List jobs")
 
-	o := datastore.ParseListOptions(limit, offset)
+	q, err := parseListRequest(req)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+		return nil, err
+	}
+
+	o := datastore.ParseListOptions(req.Limit, req.Offset)
+
+	jobs, err := s.store.Jobs(q, o)
+	if err != nil {
+		return nil, err
+	}
 
-	jobs, err := s.store.Jobs(o)
+	total, err := s.store.CountJobs(q.JobFilter)
 	if err != nil {
 		return nil, err
 	}
 
-	return &jobs, nil
+	res := &ListResult{Jobs: jobs, TotalCount: total}
+
+	if o.Limit > 0 && len(jobs) == o.Limit {
+		res.NextCursor = cursorFor(jobs[len(jobs)-1], q).String()
+	}
+
+	return res, nil
+}
+
+func parseListRequest(req ListRequest) (JobQuery, error) {
+	q := JobQuery{}
+
+	for _, state := range req.States {
+		if !isKnownState(State(state)) {
+			return q, fmt.Errorf("invalid state: %s", state)
+		}
+		q.States = append(q.States, State(state))
+	}
+
+	q.Types = req.Types
+	q.TransactionID = req.TransactionID
+	q.Scheduled = req.Scheduled
+
+	times := []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{
+		{"createdAfter", req.CreatedAfter, &q.CreatedAfter},
+		{"createdBefore", req.CreatedBefore, &q.CreatedBefore},
+		{"updatedAfter", req.UpdatedAfter, &q.UpdatedAfter},
+		{"updatedBefore", req.UpdatedBefore, &q.UpdatedBefore},
+	}
+	for _, t := range times {
+		if t.value == "" {
+			continue
+		}
+		v, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			return q, fmt.Errorf("invalid %s, expected an RFC 3339 timestamp", t.name)
+		}
+		*t.dst = v
+	}
+
+	for path := range req.Attributes {
+		if !attributePathRegexp.MatchString(path) {
+			return q, fmt.Errorf("invalid attribute path: %s", path)
+		}
+	}
+	q.Attributes = req.Attributes
+
+	switch SortField(req.Sort) {
+	case "", SortByCreatedAt:
+		q.SortBy = SortByCreatedAt
+	case SortByUpdatedAt:
+		q.SortBy = SortByUpdatedAt
+	default:
+		return q, fmt.Errorf("invalid sort, expected %s or %s", SortByCreatedAt, SortByUpdatedAt)
+	}
+
+	switch req.Order {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return q, fmt.Errorf("invalid order, expected asc or desc")
+	}
+
+	if req.Cursor != "" {
+		if req.Offset != 0 {
+			return q, fmt.Errorf("cursor and offset can not be used together")
+		}
+		c, err := parseJobCursor(req.Cursor)
+		if err != nil || c.SortBy != q.SortBy || c.Ascending != q.Ascending {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		q.After = c
+	}
+
+	return q, nil
 }
 
 // Details returns a specific job.
@@ -66,3 +185,235 @@ func (s *ServiceImpl) Details(jobID string) (*Job, error) {
 
 	return &job, nil
 }
+
+// Schemas returns the JSON schemas of the attributes and typed results of
+// all job types.
+func (s *ServiceImpl) Schemas() []Schema {
+	return s.wp.Schemas()
+}
+
+// Schema returns the JSON schemas of a job type.
+func (s *ServiceImpl) Schema(jobType string) (*Schema, error) {
+	for _, schema := range s.wp.Schemas() {
+		if schema.JobType == jobType {
+			return &schema, nil
+		}
+	}
+
+	// Convert error to a 404 RequestError
+	err := &errors.RequestError{
+		StatusCode: http.StatusNotFound,
+		Err:        fmt.Errorf("job type not found"),
+	}
+	return nil, err
+}
+
+// Deliveries returns the webhook delivery attempts of notifications about a job.
+func (s *ServiceImpl) Deliveries(jobID string, limit, offset int) (*[]Delivery, error) {
+	log.WithFields(log.Fields{"jobID": jobID, "limit": limit, "offset": offset}).Trace("List job deliveries")
+
+	job, err := s.Details(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	o := datastore.ParseListOptions(limit, offset)
+
+	deliveries, err := s.store.Deliveries(job.ID, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deliveries, nil
+}
+
+// ReplayDelivery schedules a new delivery of the notification a delivery
+// attempt was made for and returns the new notification job.
+func (s *ServiceImpl) ReplayDelivery(jobID, deliveryID string) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID, "deliveryID": deliveryID}).Trace("Replay job delivery")
+
+	job, err := s.Details(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := uuid.Parse(deliveryID)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid delivery id"),
+		}
+		return nil, err
+	}
+
+	delivery, err := s.store.Delivery(id)
+	if (err != nil && err.Error() == "record not found") || (err == nil && delivery.ParentJobID.UUID != job.ID) {
+		// Convert error to a 404 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusNotFound,
+			Err:        fmt.Errorf("delivery not found"),
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.wp.ResendNotification(delivery.NotificationJobID)
+}
+
+// Cancel moves a job that is waiting to be executed to state CANCELLED.
+// Jobs which have already submitted a Flow transaction can not be cancelled.
+func (s *ServiceImpl) Cancel(jobID string) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID}).Trace("Cancel job")
+
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid job id"),
+		}
+		return nil, err
+	}
+
+	job, err := s.store.CancelJob(id)
+	if err != nil {
+		switch {
+		case err.Error() == "record not found":
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("job not found"),
+			}
+		case err == ErrNotCancellable && job.TransactionSubmittedAt.Valid:
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        fmt.Errorf("job can not be cancelled, a transaction has already been submitted"),
+			}
+		case err == ErrNotCancellable:
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        fmt.Errorf("job in state %s can not be cancelled", job.State),
+			}
+		}
+		return nil, err
+	}
+
+	stateChanged(job)
+
+	return &job, nil
+}
+
+// Retry requeues a FAILED job. The execution count is reset while the errors
+// of the previous executions are kept.
+//
+// A job which has submitted a Flow transaction is only retried with force,
+// the transaction may still be executed and the retry may submit another one
+// (e.g. a second transfer for withdrawal_create). It stays not cancellable.
+func (s *ServiceImpl) Retry(jobID string, force bool) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID}).Trace("Retry job")
+
+	id, err := uuid.Parse(jobID)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid job id"),
+		}
+		return nil, err
+	}
+
+	job, err := s.store.RetryJob(id, force)
+	if err != nil {
+		switch {
+		case err.Error() == "record not found":
+			// Convert error to a 404 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("job not found"),
+			}
+		case err == ErrNotRetryable:
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        fmt.Errorf("job in state %s can not be retried, only %s jobs can", job.State, Failed),
+			}
+		case err == ErrTransactionSubmitted:
+			err = &errors.RequestError{
+				StatusCode: http.StatusConflict,
+				Err:        fmt.Errorf("job has submitted a transaction which may still be executed, retrying may submit it again, use force to retry anyway"),
+			}
+		}
+		return nil, err
+	}
+
+	stateChanged(job)
+
+	if err := s.wp.Schedule(&job); err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// RetryFailed requeues all FAILED jobs matching the request and returns them.
+// Jobs which have submitted a Flow transaction are only retried with
+// req.Force, see Retry.
+func (s *ServiceImpl) RetryFailed(req RetryRequest) (*[]Job, error) {
+	log.WithFields(log.Fields{"request": req}).Trace("Retry failed jobs")
+
+	f := RetryFilter{Type: req.Type, ErrorContains: req.ErrorContains}
+	if req.FailedAfter != nil {
+		f.FailedAfter = *req.FailedAfter
+	}
+	if req.FailedBefore != nil {
+		f.FailedBefore = *req.FailedBefore
+	}
+
+	if f == (RetryFilter{}) {
+		// Refuse to blindly retry every failed job
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("at least one of type, errorContains, failedAfter or failedBefore is required"),
+		}
+	}
+
+	f.Submitted = req.Force
+
+	if !f.FailedAfter.IsZero() && !f.FailedBefore.IsZero() && !f.FailedAfter.Before(f.FailedBefore) {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("failedAfter must be before failedBefore"),
+		}
+	}
+
+	o := datastore.ParseListOptions(req.Limit, 0)
+
+	failed, err := s.store.FailedJobs(f, o)
+	if err != nil {
+		return nil, err
+	}
+
+	retried := make([]Job, 0, len(failed))
+	for _, j := range failed {
+		job, err := s.store.RetryJob(j.ID, req.Force)
+		if err == ErrNotRetryable || err == ErrTransactionSubmitted {
+			// Retried or otherwise changed after it was listed
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		stateChanged(job)
+
+		if err := s.wp.Schedule(&job); err != nil {
+			return nil, err
+		}
+
+		retried = append(retried, job)
+	}
+
+	return &retried, nil
+}
@@ -9,16 +9,63 @@ import (
 
 // Store manages data regarding jobs.
 type Store interface {
-	Jobs(datastore.ListOptions) ([]Job, error)
+	Jobs(q JobQuery, o datastore.ListOptions) ([]Job, error)
+	CountJobs(f JobFilter) (int64, error)
 	Job(id uuid.UUID) (Job, error)
 	InsertJob(*Job) error
 	UpdateJob(*Job) error
-	AcceptJob(j *Job, acceptedGracePeriod time.Duration) error
+	// AcceptJob moves the job to ACCEPTED and leases it to the worker for
+	// leaseDuration. Jobs without a lease, accepted before leases were
+	// introduced, are leased for acceptedGracePeriod after their last update.
+	AcceptJob(j *Job, workerID string, leaseDuration, acceptedGracePeriod time.Duration) error
+	// RenewLeases extends the leases the worker holds on the ACCEPTED jobs,
+	// it returns the number of leases renewed.
+	RenewLeases(workerID string, ids []uuid.UUID, leaseDuration time.Duration) (int64, error)
+	// CancelJob moves a cancellable job to state CANCELLED, it returns
+	// ErrNotCancellable if the job can not be cancelled.
+	CancelJob(id uuid.UUID) (Job, error)
+	// ReleaseJob moves a job waiting for a worker to NO_AVAILABLE_WORKERS so
+	// that other instances pick it up. released is false if the job was not
+	// waiting anymore, e.g. it was cancelled or accepted meanwhile.
+	ReleaseJob(id uuid.UUID) (job Job, released bool, err error)
+	// RetryJob moves a FAILED job back to state INIT and resets its execution
+	// count, it returns ErrNotRetryable if the job has not failed and
+	// ErrTransactionSubmitted if the job has submitted a transaction, unless
+	// force is set.
+	RetryJob(id uuid.UUID, force bool) (Job, error)
+	FailedJobs(f RetryFilter, o datastore.ListOptions) ([]Job, error)
+	// DependentJobs lists the BLOCKED jobs waiting for a parent job.
+	DependentJobs(parentID uuid.UUID) ([]Job, error)
+	BlockedJobs(o datastore.ListOptions) ([]Job, error)
+	// ResolveBlockedJob moves a BLOCKED job to INIT once all of its parents
+	// have completed or to SKIPPED if any of them did not complete. resolved
+	// is false if the job was not BLOCKED or still has unfinished parents.
+	ResolveBlockedJob(id uuid.UUID) (job Job, resolved bool, err error)
 	SchedulableJobs(acceptedGracePeriod, reSchedulableGracePeriod time.Duration, o datastore.ListOptions) ([]Job, error)
 	Status() ([]StatusQuery, error)
+	// LeasesByWorker counts the unexpired leases of each worker.
+	LeasesByWorker() ([]LeaseQuery, error)
+	InsertDelivery(*Delivery) error
+	Deliveries(parentJobID uuid.UUID, o datastore.ListOptions) ([]Delivery, error)
+	Delivery(id uuid.UUID) (Delivery, error)
 }
 
 type StatusQuery struct {
 	State State
 	Count int
 }
+
+type LeaseQuery struct {
+	WorkerID string
+	Count    int
+}
+
+// RetryFilter selects FAILED jobs for a bulk retry. Empty fields match all jobs.
+type RetryFilter struct {
+	Type          string
+	ErrorContains string
+	FailedAfter   time.Time
+	FailedBefore  time.Time
+	// Submitted also matches jobs which have submitted a transaction
+	Submitted bool
+}
@@ -1,58 +1,202 @@
 package jobs
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/numeroai/flow-wallet-api/datastore"
 	"github.com/numeroai/flow-wallet-api/datastore/lib"
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
 type GormStore struct {
 	db *gorm.DB
+
+	// Send a NOTIFY on job state changes, only supported by PostgreSQL
+	notifications bool
 }
 
 func NewGormStore(db *gorm.DB) Store {
-	return &GormStore{db}
+	return &GormStore{db: db, notifications: db.Dialector.Name() == "postgres"}
 }
 
-func (s *GormStore) Jobs(o datastore.ListOptions) (jj []Job, err error) {
-	err = s.db.
-		Order("created_at desc").
+func (s *GormStore) Jobs(q JobQuery, o datastore.ListOptions) (jj []Job, err error) {
+	col := q.SortBy.column()
+	dir, cmp := "desc", "<"
+	if q.Ascending {
+		dir, cmp = "asc", ">"
+	}
+
+	tx := filterJobs(s.db, q.JobFilter)
+
+	if c := q.After; c != nil {
+		// Keyset pagination, rows inserted meanwhile do not shift the pages
+		tx = tx.Where(
+			fmt.Sprintf("%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?)", col, cmp),
+			c.Value, c.Value, c.ID,
+		)
+	}
+
+	err = tx.
+		Order(fmt.Sprintf("%s %s, id %s", col, dir, dir)).
 		Limit(o.Limit).
 		Offset(o.Offset).
 		Find(&jj).Error
 	return
 }
 
+func (s *GormStore) CountJobs(f JobFilter) (count int64, err error) {
+	err = filterJobs(s.db.Model(&Job{}), f).Count(&count).Error
+	return
+}
+
+func filterJobs(tx *gorm.DB, f JobFilter) *gorm.DB {
+	if len(f.States) > 0 {
+		tx = tx.Where("state IN ?", f.States)
+	}
+
+	if len(f.Types) > 0 {
+		tx = tx.Where("type IN ?", f.Types)
+	}
+
+	if f.Scheduled {
+		tx = tx.Where("state = ? AND next_run_at IS NOT NULL", Init)
+	}
+
+	if f.TransactionID != "" {
+		tx = tx.Where("transaction_id = ?", f.TransactionID)
+	}
+
+	if !f.CreatedAfter.IsZero() {
+		tx = tx.Where("created_at >= ?", f.CreatedAfter)
+	}
+
+	if !f.CreatedBefore.IsZero() {
+		tx = tx.Where("created_at < ?", f.CreatedBefore)
+	}
+
+	if !f.UpdatedAfter.IsZero() {
+		tx = tx.Where("updated_at >= ?", f.UpdatedAfter)
+	}
+
+	if !f.UpdatedBefore.IsZero() {
+		tx = tx.Where("updated_at < ?", f.UpdatedBefore)
+	}
+
+	for path, value := range f.Attributes {
+		tx = tx.Where(datatypes.JSONQuery("attributes").Equals(value, strings.Split(path, ".")...))
+	}
+
+	return tx
+}
+
 func (s *GormStore) Job(id uuid.UUID) (j Job, err error) {
 	err = s.db.First(&j, "id = ?", id).Error
 	return
 }
 
 func (s *GormStore) InsertJob(j *Job) error {
-	return s.db.Create(j).Error
+	if len(j.parentIDs) == 0 && !s.notifications {
+		return s.db.Create(j).Error
+	}
+
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		if len(j.parentIDs) > 0 {
+			var count int64
+			if err := tx.Model(&Job{}).Where("id IN ?", j.parentIDs).Count(&count).Error; err != nil {
+				return err
+			}
+			if int(count) != len(uniqueIDs(j.parentIDs)) {
+				return ErrUnknownParent
+			}
+		}
+
+		// Dependencies go in first, a BLOCKED job is never resolved with only
+		// some of its parents where the transaction is a no-op (sqlite)
+		j.ID = uuid.New()
+		for _, parentID := range uniqueIDs(j.parentIDs) {
+			if err := tx.Create(&JobDependency{JobID: j.ID, ParentJobID: parentID}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(j).Error; err != nil {
+			return err
+		}
+
+		return s.notify(tx, j)
+	})
+}
+
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	res := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
 }
 
 func (s *GormStore) UpdateJob(j *Job) error {
-	return s.db.Save(j).Error
+	if !s.notifications {
+		return s.db.Save(j).Error
+	}
+
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		if err := tx.Save(j).Error; err != nil {
+			return err
+		}
+		return s.notify(tx, j)
+	})
+}
+
+// notify sends a notification about the new state of the job to the worker
+// pools listening with a PqNotifier. It is delivered when tx commits.
+func (s *GormStore) notify(tx *gorm.DB, j *Job) error {
+	if !s.notifications {
+		return nil
+	}
+
+	payload, err := json.Marshal(JobNotification{ID: j.ID, State: j.State})
+	if err != nil {
+		return err
+	}
+
+	return tx.Exec("SELECT pg_notify(?, ?)", JobNotificationChannel, string(payload)).Error
 }
 
 func isAcceptable(j *Job, acceptedGracePeriod time.Duration) bool {
-	tAccepted := time.Now().Add(-1 * acceptedGracePeriod)
-	if j.State == Accepted && j.UpdatedAt.After(tAccepted) {
+	if j.State == Accepted && isLeased(j, time.Now(), acceptedGracePeriod) {
 		return false
 	}
-	if j.State == Complete || j.State == Failed {
+	if j.IsFinished() || j.State == Blocked {
+		return false
+	}
+	if j.State == Init && !j.IsDue(time.Now()) {
 		return false
 	}
 	return true
 }
 
-func (s *GormStore) AcceptJob(j *Job, acceptedGracePeriod time.Duration) error {
+// isLeased is true while the worker which accepted the job is expected to
+// be executing it.
+func isLeased(j *Job, now time.Time, acceptedGracePeriod time.Duration) bool {
+	if j.LeaseExpiresAt.Valid {
+		return j.LeaseExpiresAt.Time.After(now)
+	}
+	return j.UpdatedAt.After(now.Add(-1 * acceptedGracePeriod))
+}
+
+func (s *GormStore) AcceptJob(j *Job, workerID string, leaseDuration, acceptedGracePeriod time.Duration) error {
 	if !isAcceptable(j, acceptedGracePeriod) {
 		return fmt.Errorf("error job is not acceptable")
 	}
@@ -67,24 +211,224 @@ func (s *GormStore) AcceptJob(j *Job, acceptedGracePeriod time.Duration) error {
 		}
 		j.State = Accepted
 		j.ExecCount = job.ExecCount + 1
+		j.WorkerID = workerID
+		j.LeaseExpiresAt = sql.NullTime{Time: time.Now().Add(leaseDuration), Valid: true}
 		err = tx.Save(j).Error
 		if err != nil {
 			return err
 		}
-		return nil
+		return s.notify(tx, j)
 	})
 }
 
+func (s *GormStore) RenewLeases(workerID string, ids []uuid.UUID, leaseDuration time.Duration) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	res := s.db.
+		Model(&Job{}).
+		Where("id IN ? AND state = ? AND worker_id = ?", ids, Accepted, workerID).
+		UpdateColumn("lease_expires_at", time.Now().Add(leaseDuration))
+
+	return res.RowsAffected, res.Error
+}
+
+func (s *GormStore) CancelJob(id uuid.UUID) (job Job, err error) {
+	err = lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		if !job.IsCancellable() {
+			return ErrNotCancellable
+		}
+		job.State = Cancelled
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+		return s.notify(tx, &job)
+	})
+	return
+}
+
+func (s *GormStore) ReleaseJob(id uuid.UUID) (job Job, released bool, err error) {
+	err = lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		if job.State != Init && job.State != NoAvailableWorkers && job.State != Error {
+			return nil
+		}
+		job.State = NoAvailableWorkers
+		released = true
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+		return s.notify(tx, &job)
+	})
+	return
+}
+
+func (s *GormStore) RetryJob(id uuid.UUID, force bool) (job Job, err error) {
+	err = lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		if job.State != Failed {
+			return ErrNotRetryable
+		}
+		if job.TransactionSubmittedAt.Valid && !force {
+			return ErrTransactionSubmitted
+		}
+		// Keep Error and Errors as history of the previous executions, and
+		// TransactionSubmittedAt as the transaction may still be executed
+		job.State = Init
+		job.ExecCount = 0
+		job.NextRunAt = sql.NullTime{}
+		if err := tx.Save(&job).Error; err != nil {
+			return err
+		}
+		return s.notify(tx, &job)
+	})
+	return
+}
+
+func (s *GormStore) DependentJobs(parentID uuid.UUID) (jj []Job, err error) {
+	err = s.db.
+		Where("state = ? AND id IN (?)", Blocked, s.db.Model(&JobDependency{}).Select("job_id").Where("parent_job_id = ?", parentID)).
+		Order("created_at asc").
+		Find(&jj).Error
+	return
+}
+
+func (s *GormStore) BlockedJobs(o datastore.ListOptions) (jj []Job, err error) {
+	err = s.db.
+		Where("state = ?", Blocked).
+		Order("created_at asc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&jj).Error
+	return
+}
+
+func (s *GormStore) ResolveBlockedJob(id uuid.UUID) (job Job, resolved bool, err error) {
+	err = lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&job, "id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		if job.State != Blocked {
+			return nil
+		}
+
+		// Parents which have since been deleted still count with their last state
+		var parents []Job
+		err = tx.Unscoped().
+			Where("id IN (?)", tx.Model(&JobDependency{}).Select("parent_job_id").Where("job_id = ?", id)).
+			Order("created_at asc").
+			Find(&parents).Error
+		if err != nil {
+			return err
+		}
+
+		// Only one of concurrent resolves moves the job out of BLOCKED, not
+		// every database supports the row lock above
+		resolve := func(values map[string]interface{}) error {
+			res := tx.Model(&job).Where("state = ?", Blocked).Updates(values)
+			if res.Error != nil {
+				return res.Error
+			}
+			resolved = res.RowsAffected == 1
+			if !resolved {
+				return nil
+			}
+			return s.notify(tx, &job)
+		}
+
+		complete := 0
+		for _, p := range parents {
+			if p.State == Complete {
+				complete++
+				continue
+			}
+			if p.IsFinished() {
+				job.State = Skipped
+				job.Error = fmt.Sprintf("parent job %s did not complete", p.ID)
+				job.Errors = append(job.Errors, job.Error)
+				return resolve(map[string]interface{}{"state": job.State, "error": job.Error, "errors": job.Errors})
+			}
+		}
+
+		if complete < len(parents) {
+			return nil
+		}
+
+		job.State = Init
+		return resolve(map[string]interface{}{"state": job.State})
+	})
+	return
+}
+
+func (s *GormStore) FailedJobs(f RetryFilter, o datastore.ListOptions) (jj []Job, err error) {
+	q := s.db.Where("state = ?", Failed)
+
+	if f.Type != "" {
+		q = q.Where("type = ?", f.Type)
+	}
+
+	if f.ErrorContains != "" {
+		q = q.Where("LOWER(error) LIKE ? ESCAPE '!'", "%"+escapeLike(strings.ToLower(f.ErrorContains))+"%")
+	}
+
+	if !f.FailedAfter.IsZero() {
+		q = q.Where("updated_at >= ?", f.FailedAfter)
+	}
+
+	if !f.FailedBefore.IsZero() {
+		q = q.Where("updated_at < ?", f.FailedBefore)
+	}
+
+	if !f.Submitted {
+		q = q.Where("transaction_submitted_at IS NULL")
+	}
+
+	err = q.
+		Order("created_at asc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&jj).Error
+	return
+}
+
+// escapeLike escapes s for LIKE ... ESCAPE '!', a backslash would start an
+// escape sequence in MySQL string literals
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
+
+// SchedulableJobs only returns jobs in states INIT, ACCEPTED, ERROR and
+// NO_AVAILABLE_WORKERS, so COMPLETE, FAILED and CANCELLED jobs are never rescheduled.
+// ERROR jobs and delayed INIT jobs are returned once their next_run_at has
+// passed, ERROR jobs without one (errored before it existed) use the
+// re-schedulable grace period.
 func (s *GormStore) SchedulableJobs(acceptedGracePeriod, reSchedulableGracePeriod time.Duration, o datastore.ListOptions) (jj []Job, err error) {
 	t0 := time.Now()
 	tAccepted := t0.Add(-1 * acceptedGracePeriod)
 	tReschedulable := t0.Add(-1 * reSchedulableGracePeriod)
 
 	err = s.db.
-		Where("state IN ? AND updated_at < ?", []string{string(Init), string(Accepted)}, tAccepted).
-		Or("state IN ? AND updated_at < ?", []string{string(Error), string(NoAvailableWorkers)}, tReschedulable).
+		Where("state = ? AND updated_at < ? AND (next_run_at IS NULL OR next_run_at <= ?)", Init, tAccepted, t0).
+		Or("state = ? AND next_run_at <= ?", Init, t0).
+		Or("state = ? AND lease_expires_at < ?", Accepted, t0).
+		Or("state = ? AND lease_expires_at IS NULL AND updated_at < ?", Accepted, tAccepted).
+		Or("state = ? AND updated_at < ?", NoAvailableWorkers, tReschedulable).
+		Or("state = ? AND next_run_at <= ?", Error, t0).
+		Or("state = ? AND next_run_at IS NULL AND updated_at < ?", Error, tReschedulable).
 		Model(&Job{}).
-		Order("created_at desc").
+		Order("priority desc, created_at desc").
 		Limit(o.Limit).
 		Offset(o.Offset).
 		Find(&jj).Error
@@ -100,3 +444,37 @@ func (s *GormStore) Status() ([]StatusQuery, error) {
 	}
 	return res, nil
 }
+
+func (s *GormStore) LeasesByWorker() ([]LeaseQuery, error) {
+	var res []LeaseQuery
+	err := s.db.
+		Model(&Job{}).
+		Select("worker_id, COUNT(*) as count").
+		Where("state = ? AND lease_expires_at > ?", Accepted, time.Now()).
+		Group("worker_id").
+		Order("worker_id").
+		Scan(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *GormStore) InsertDelivery(d *Delivery) error {
+	return s.db.Create(d).Error
+}
+
+func (s *GormStore) Deliveries(parentJobID uuid.UUID, o datastore.ListOptions) (dd []Delivery, err error) {
+	err = s.db.
+		Where("parent_job_id = ?", parentJobID).
+		Order("created_at desc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&dd).Error
+	return
+}
+
+func (s *GormStore) Delivery(id uuid.UUID) (d Delivery, err error) {
+	err = s.db.First(&d, "id = ?", id).Error
+	return
+}
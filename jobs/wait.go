@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// WaitRequest describes what a request waiting on a job is waiting for.
+type WaitRequest struct {
+	// States to wait for, the wait also ends when the job finishes in
+	// another state.
+	States []string
+	// Timeout as a duration string, e.g. "30s". Defaults to and is capped at
+	// the maximum wait timeout of the service.
+	Timeout string
+}
+
+// Wait blocks until the job is in one of the requested states, has finished
+// or the timeout passes, and returns the job as it is at that point.
+func (s *ServiceImpl) Wait(ctx context.Context, jobID string, req WaitRequest) (*Job, error) {
+	log.WithFields(log.Fields{"jobID": jobID, "request": req}).Trace("Wait for job")
+
+	states, timeout, err := s.parseWaitRequest(req)
+	if err != nil {
+		// Convert error to a 400 RequestError
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        err,
+		}
+		return nil, err
+	}
+
+	job, err := s.Details(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start watching before the first check so that no update is missed
+	updated, stop := updates.watch(job.ID)
+	defer stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	// Jobs processed by other instances are only seen in the database,
+	// unless the worker pool has a JobNotifier
+	poll := time.NewTicker(s.waitPollInterval)
+	defer poll.Stop()
+
+	for {
+		job, err = s.Details(jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		if states[job.State] || job.IsFinished() {
+			return job, nil
+		}
+
+		select {
+		case <-updated:
+		case <-poll.C:
+		case <-deadline.C:
+			return job, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (s *ServiceImpl) parseWaitRequest(req WaitRequest) (map[State]bool, time.Duration, error) {
+	states := make(map[State]bool, len(req.States))
+	for _, state := range req.States {
+		if !isKnownState(State(state)) {
+			return nil, 0, fmt.Errorf("invalid waitFor state: %s", state)
+		}
+		states[State(state)] = true
+	}
+
+	timeout := s.maxWaitTimeout
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d < 0 {
+			return nil, 0, fmt.Errorf("invalid timeout, expected a duration such as 30s")
+		}
+		if d < timeout {
+			timeout = d
+		}
+	}
+
+	return states, timeout, nil
+}
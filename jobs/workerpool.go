@@ -2,13 +2,17 @@ package jobs
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
+	"sort"
 
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/numeroai/flow-wallet-api/datastore"
@@ -17,8 +21,12 @@ import (
 )
 
 var (
-	ErrInvalidJobType   = errors.New("invalid job type")
-	ErrPermanentFailure = errors.New("permanent failure")
+	ErrInvalidJobType       = errors.New("invalid job type")
+	ErrPermanentFailure     = errors.New("permanent failure")
+	ErrNotCancellable       = errors.New("job can not be cancelled")
+	ErrNotRetryable         = errors.New("job can not be retried")
+	ErrTransactionSubmitted = errors.New("job has submitted a transaction")
+	ErrUnknownParent        = errors.New("unknown parent job")
 
 	// maxJobErrorCount is the maximum number of times a Job can be tried to
 	// execute before considering it completely failed.
@@ -27,25 +35,46 @@ var (
 	// Poll DB for new schedulable jobs every 30s.
 	defaultDBJobPollInterval = 30 * time.Second
 
-	// Grace time period before re-scheduling jobs that are in state INIT or
-	// ACCEPTED. These are jobs where the executor processing has been
-	// unexpectedly disrupted (such as bug, dead node, disconnected
-	// networking etc.).
+	// Grace time period before re-scheduling jobs that are in state INIT.
+	// These are jobs which were never picked up by a worker (such as bug,
+	// dead node, disconnected networking etc.). ACCEPTED jobs accepted
+	// before leases were introduced also use this grace period.
 	defaultAcceptedGracePeriod = 3 * time.Minute
 
+	// ACCEPTED jobs are leased to the worker pool instance executing them.
+	// The lease is renewed every third of its duration while the executor
+	// runs, jobs with an expired lease are re-scheduled.
+	defaultLeaseDuration = 30 * time.Second
+
 	// Grace time period before re-scheduling jobs that are up for immediate
-	// restart (such as NO_AVAILABLE_WORKERS or ERROR).
+	// restart (such as NO_AVAILABLE_WORKERS). ERROR jobs are re-scheduled
+	// according to their NextRunAt instead.
 	defaultReSchedulableGracePeriod = 1 * time.Minute
+
+	// Job notifications wake up the DB job scheduler at most this often.
+	minDBJobWakeInterval = 1 * time.Second
+
+	// Time given to cancelled executors to return when the drain deadline
+	// has been reached.
+	drainCancelGracePeriod = 5 * time.Second
 )
 
 type ExecutorFunc func(ctx context.Context, j *Job) error
 
 type WorkerPool interface {
-	RegisterExecutor(jobType string, executorF ExecutorFunc)
+	RegisterExecutor(jobType string, executorF ExecutorFunc, opts ...ExecutorOption)
+	// Schemas lists the schemas of the registered job types.
+	Schemas() []Schema
 	CreateJob(jobType, txID string, opts ...JobOption) (*Job, error)
 	Schedule(j *Job) error
+	ScheduleWebhookNotification(subscriptionID, event, parentJobID, content string) error
+	ResendNotification(notificationJobID uuid.UUID) (*Job, error)
 	Status() (WorkerPoolStatus, error)
 	Start()
+	// Drain stops taking new jobs and waits for the executing ones until
+	// ctx is done, see WorkerPoolImpl.Drain.
+	Drain(ctx context.Context) error
+	Draining() bool
 	Stop(wait bool)
 	Capacity() uint
 	QueueSize() uint
@@ -54,11 +83,14 @@ type WorkerPool interface {
 type WorkerPoolImpl struct {
 	started       bool
 	wg            *sync.WaitGroup
-	jobChan       chan *Job
+	queue         queueBackend
 	stopChan      chan struct{}
+	drainChan     chan struct{}
+	drainOnce     sync.Once
 	context       context.Context
 	cancelContext context.CancelFunc
 	executors     map[string]ExecutorFunc
+	schemas       map[string]Schema
 	logger        *log.Logger
 
 	store       Store
@@ -69,6 +101,22 @@ type WorkerPoolImpl struct {
 	dbJobPollInterval        time.Duration
 	acceptedGracePeriod      time.Duration
 	reSchedulableGracePeriod time.Duration
+	backoff                  Backoff
+
+	workerID      string
+	leaseDuration time.Duration
+	runningMu     sync.Mutex
+	running       map[uuid.UUID]struct{} // ACCEPTED jobs leased by this instance
+	workersDone   chan struct{}
+
+	notifier  JobNotifier
+	dbJobWake chan struct{}
+
+	redisQueue *RedisQueueConfig
+
+	jobTypePriorities      map[string]int
+	jobTypeConcurrency     map[string]int
+	jobTypeReservedWorkers map[string]int
 
 	notificationConfig *NotificationConfig
 	systemService      system.Service
@@ -76,8 +124,18 @@ type WorkerPoolImpl struct {
 
 type WorkerPoolStatus struct {
 	JobQueueStatus
-	Capacity    int `json:"poolCapacity"`
-	WorkerCount int `json:"workerCount"`
+	Capacity      int              `json:"poolCapacity"`
+	WorkerCount   int              `json:"workerCount"`
+	WorkerID      string           `json:"workerId"`
+	ActiveWorkers int              `json:"activeWorkers"`
+	Instances     []InstanceStatus `json:"instances"`
+}
+
+// InstanceStatus is the number of workers executing a job on a worker pool
+// instance, as seen from the job leases.
+type InstanceStatus struct {
+	WorkerID      string `json:"workerId"`
+	ActiveWorkers int    `json:"activeWorkers"`
 }
 
 func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPoolOption) WorkerPool {
@@ -85,8 +143,8 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 
 	pool := &WorkerPoolImpl{
 		wg:            &sync.WaitGroup{},
-		jobChan:       make(chan *Job, capacity),
 		stopChan:      make(chan struct{}),
+		drainChan:     make(chan struct{}),
 		context:       ctx,
 		cancelContext: cancel,
 		executors:     make(map[string]ExecutorFunc),
@@ -100,6 +158,13 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 		dbJobPollInterval:        defaultDBJobPollInterval,
 		acceptedGracePeriod:      defaultAcceptedGracePeriod,
 		reSchedulableGracePeriod: defaultReSchedulableGracePeriod,
+		backoff:                  defaultBackoff,
+
+		workerID:      defaultWorkerID(),
+		leaseDuration: defaultLeaseDuration,
+		workersDone:   make(chan struct{}),
+
+		dbJobWake: make(chan struct{}, 1),
 
 		notificationConfig: &NotificationConfig{},
 	}
@@ -109,8 +174,26 @@ func NewWorkerPool(db Store, capacity uint, workerCount uint, opts ...WorkerPool
 		opt(pool)
 	}
 
+	reserved := 0
+	for _, n := range pool.jobTypeReservedWorkers {
+		reserved += n
+	}
+	if reserved >= int(workerCount) && len(pool.jobTypeReservedWorkers) > 0 {
+		pool.logger.
+			WithFields(log.Fields{"reserved": reserved, "workerCount": workerCount}).
+			Warn("Reserved workers leave no workers for other job types, ignoring reservations")
+		pool.jobTypeReservedWorkers = nil
+	}
+
+	local := newJobQueue(capacity, workerCount, pool.jobTypeConcurrency, pool.jobTypeReservedWorkers)
+	if pool.redisQueue != nil {
+		pool.queue = newRedisQueue(*pool.redisQueue, local, db, pool.workerID, pool.leaseDuration, pool.logger)
+	} else {
+		pool.queue = local
+	}
+
 	// Register asynchronous job executor.
-	pool.RegisterExecutor(SendJobStatusJobType, pool.executeSendJobStatus)
+	pool.RegisterExecutor(SendJobStatusJobType, pool.executeSendJobStatus, WithAttributesSchema(notificationJobAttributes{}))
 
 	pool.logger.Debug(pool)
 
@@ -139,6 +222,12 @@ func (wp *WorkerPoolImpl) Status() (WorkerPoolStatus, error) {
 			status.JobsFailed = r.Count
 		case Complete:
 			status.JobsCompleted = r.Count
+		case Cancelled:
+			status.JobsCancelled = r.Count
+		case Blocked:
+			status.JobsBlocked = r.Count
+		case Skipped:
+			status.JobsSkipped = r.Count
 		default:
 			continue
 		}
@@ -146,6 +235,18 @@ func (wp *WorkerPoolImpl) Status() (WorkerPoolStatus, error) {
 
 	status.Capacity = int(wp.capacity)
 	status.WorkerCount = int(wp.workerCount)
+	status.WorkerID = wp.workerID
+	status.ActiveWorkers = len(wp.runningJobs())
+
+	leases, err := wp.store.LeasesByWorker()
+	if err != nil {
+		return status, err
+	}
+
+	status.Instances = make([]InstanceStatus, len(leases))
+	for i, l := range leases {
+		status.Instances[i] = InstanceStatus{WorkerID: l.WorkerID, ActiveWorkers: l.Count}
+	}
 
 	return status, nil
 }
@@ -157,6 +258,7 @@ func (wp *WorkerPoolImpl) CreateJob(jobType, txID string, opts ...JobOption) (*J
 		State:         Init,
 		Type:          jobType,
 		TransactionID: txID,
+		Priority:      wp.jobTypePriorities[jobType],
 	}
 
 	// Go through options
@@ -164,16 +266,53 @@ func (wp *WorkerPoolImpl) CreateJob(jobType, txID string, opts ...JobOption) (*J
 		opt(job)
 	}
 
+	if len(job.parentIDs) > 0 {
+		job.State = Blocked
+	}
+
 	// Insert job into database
 	if err := wp.store.InsertJob(job); err != nil {
 		return nil, err
 	}
 
+	if job.State == Blocked {
+		// Parents may have finished already
+		resolved, ok, err := wp.store.ResolveBlockedJob(job.ID)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			*job = resolved
+			stateChanged(*job)
+		}
+	}
+
 	return job, nil
 }
 
-func (wp *WorkerPoolImpl) RegisterExecutor(jobType string, executorF ExecutorFunc) {
+func (wp *WorkerPoolImpl) RegisterExecutor(jobType string, executorF ExecutorFunc, opts ...ExecutorOption) {
 	wp.executors[jobType] = executorF
+
+	schema := Schema{JobType: jobType}
+	for _, opt := range opts {
+		opt(&schema)
+	}
+
+	if wp.schemas == nil {
+		wp.schemas = make(map[string]Schema)
+	}
+	wp.schemas[jobType] = schema
+}
+
+func (wp *WorkerPoolImpl) Schemas() []Schema {
+	res := make([]Schema, 0, len(wp.schemas))
+	for _, s := range wp.schemas {
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].JobType < res[j].JobType
+	})
+	return res
 }
 
 // Schedule will try to immediately schedule the run of a job
@@ -185,6 +324,18 @@ func (wp *WorkerPoolImpl) Schedule(j *Job) error {
 
 	entry.Debug("Scheduling job")
 
+	if j.State == Blocked || j.State == Skipped {
+		// Dependent job; scheduled once its parents have completed
+		entry.WithFields(log.Fields{"state": j.State}).Debug("Job waiting for parent jobs")
+		return nil
+	}
+
+	if !j.IsDue(time.Now()) {
+		// Delayed job; let dbScheduler handle this job once it is due
+		entry.WithFields(log.Fields{"runAt": j.NextRunAt.Time}).Debug("Job not due yet")
+		return nil
+	}
+
 	if halted, err := wp.systemHalted(); err != nil {
 		return fmt.Errorf("error while getting system settings: %w", err)
 	} else if halted {
@@ -199,6 +350,7 @@ func (wp *WorkerPoolImpl) Schedule(j *Job) error {
 		if err := wp.store.UpdateJob(j); err != nil {
 			return err
 		}
+		stateChanged(*j)
 	} else {
 		entry.Debug("Successfully scheduled job")
 	}
@@ -210,15 +362,92 @@ func (wp *WorkerPoolImpl) Start() {
 	if !wp.started {
 		wp.started = true
 		wp.startWorkers()
+		wp.startHeartbeat()
 		wp.startDBJobScheduler()
+		wp.startJobNotificationListener()
+	}
+}
+
+// Drain stops taking new jobs: scheduled jobs are left to other instances
+// and the DB job scheduler stops. Jobs waiting in the queue are released to
+// other instances right away. Drain then waits for the executing jobs to
+// finish until ctx is done, after which their executors are cancelled and
+// their jobs released as well. It returns ctx.Err() if executions had to be
+// cancelled. Stop the worker pool once drained.
+func (wp *WorkerPoolImpl) Drain(ctx context.Context) error {
+	entry := wp.logger.WithFields(log.Fields{
+		"package":  "jobs",
+		"function": "WorkerPool.Drain",
+		"workerID": wp.workerID,
+	})
+
+	wp.drainOnce.Do(func() {
+		close(wp.drainChan)
+	})
+
+	for _, j := range wp.queue.drain() {
+		wp.release(j.ID)
+	}
+
+	// Workers exit once their current job is done
+	wp.queue.close()
+
+	workersDone := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(workersDone)
+	}()
+
+	select {
+	case <-workersDone:
+		entry.Info("Drained worker pool")
+		return nil
+	case <-ctx.Done():
+	}
+
+	entry.
+		WithFields(log.Fields{"running": len(wp.runningJobs())}).
+		Warn("Drain deadline reached, cancelling running jobs")
+
+	wp.cancelContext()
+
+	select {
+	case <-workersDone:
+	case <-time.After(drainCancelGracePeriod):
+		entry.Warn("Jobs still running after being cancelled")
+	}
+
+	return ctx.Err()
+}
+
+// Draining returns true once Drain has been called.
+func (wp *WorkerPoolImpl) Draining() bool {
+	select {
+	case <-wp.drainChan:
+		return true
+	default:
+		return false
+	}
+}
+
+// release hands a job this instance will not execute over to the other
+// instances.
+func (wp *WorkerPoolImpl) release(id uuid.UUID) {
+	job, released, err := wp.store.ReleaseJob(id)
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err, "jobID": id}).
+			Warn("Could not release job")
+		return
+	}
+	if released {
+		stateChanged(job)
 	}
 }
 
 func (wp *WorkerPoolImpl) Stop(wait bool) {
 	close(wp.stopChan)
-	// Give time for the stop channel to signal before closing job channel
-	time.Sleep(time.Millisecond * 100)
-	close(wp.jobChan)
+	wp.queue.close()
 	if wait {
 		wp.cancelContext()
 		wp.wg.Wait()
@@ -230,7 +459,7 @@ func (wp *WorkerPoolImpl) Capacity() uint {
 }
 
 func (wp *WorkerPoolImpl) QueueSize() uint {
-	return uint(len(wp.jobChan))
+	return uint(wp.queue.len())
 }
 
 func (wp *WorkerPoolImpl) accept(job *Job) bool {
@@ -239,13 +468,15 @@ func (wp *WorkerPoolImpl) accept(job *Job) bool {
 		"function": "WorkerPool.accept",
 	}))
 
-	if err := wp.store.AcceptJob(job, wp.acceptedGracePeriod); err != nil {
+	if err := wp.store.AcceptJob(job, wp.workerID, wp.leaseDuration, wp.acceptedGracePeriod); err != nil {
 		entry.
 			WithFields(log.Fields{"error": err}).
 			Warn("Failed to accept job")
 		return false
 	}
 
+	stateChanged(*job)
+
 	return true
 }
 
@@ -259,15 +490,30 @@ func (wp *WorkerPoolImpl) systemHalted() (bool, error) {
 func (wp *WorkerPoolImpl) startDBJobScheduler() {
 	go func() {
 		var restTime time.Duration
+		var lastPoll time.Time
 
 	jobPoolLoop:
 		for {
 			select {
 			case <-time.After(restTime):
+			case <-wp.dbJobWake:
+				// Woken up by a job notification, rate limited as these
+				// come with every job insert and state change
+				select {
+				case <-time.After(minDBJobWakeInterval - time.Since(lastPoll)):
+				case <-wp.stopChan:
+					break jobPoolLoop
+				case <-wp.drainChan:
+					break jobPoolLoop
+				}
 			case <-wp.stopChan:
 				break jobPoolLoop
+			case <-wp.drainChan:
+				break jobPoolLoop
 			}
 
+			lastPoll = time.Now()
+
 			if halted, err := wp.systemHalted(); err != nil {
 				wp.logger.
 					WithFields(log.Fields{"error": err}).
@@ -281,6 +527,8 @@ func (wp *WorkerPoolImpl) startDBJobScheduler() {
 
 			begin := time.Now()
 
+			wp.resolveBlockedJobs()
+
 			o := datastore.ParseListOptions(0, 0)
 			jobs, err := wp.store.SchedulableJobs(wp.acceptedGracePeriod, wp.reSchedulableGracePeriod, o)
 			if err != nil {
@@ -300,17 +548,97 @@ func (wp *WorkerPoolImpl) startDBJobScheduler() {
 	}()
 }
 
+// startJobNotificationListener reacts to jobs inserted or updated by any
+// instance. Without a notifier the DB job scheduler only polls.
+func (wp *WorkerPoolImpl) startJobNotificationListener() {
+	if wp.notifier == nil {
+		return
+	}
+
+	go func() {
+		for {
+			select {
+			case n, ok := <-wp.notifier.Notifications():
+				if !ok {
+					return
+				}
+				wp.handleJobNotification(n)
+			case <-wp.stopChan:
+				return
+			}
+		}
+	}()
+}
+
+func (wp *WorkerPoolImpl) handleJobNotification(n JobNotification) {
+	if n.ID == uuid.Nil {
+		// Notifications may have been missed
+		wp.wakeDBJobScheduler()
+		return
+	}
+
+	// Requests waiting on this instance for the job
+	updates.notify(n.ID)
+
+	switch n.State {
+	case NoAvailableWorkers:
+		// Another instance had no room for the job, take it right away
+		// instead of after the re-schedulable grace period
+		wp.tryTakeOver(n.ID)
+	case Init, Error:
+		wp.wakeDBJobScheduler()
+	}
+}
+
+// tryTakeOver enqueues a job deferred by another instance if this instance
+// has room for it. Only the instance which accepts the job executes it.
+func (wp *WorkerPoolImpl) tryTakeOver(id uuid.UUID) {
+	if halted, err := wp.systemHalted(); err != nil || halted {
+		return
+	}
+
+	job, err := wp.store.Job(id)
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err, "jobID": id}).
+			Warn("Could not fetch notified job from DB")
+		return
+	}
+
+	if job.State != NoAvailableWorkers {
+		return
+	}
+
+	if wp.tryEnqueue(&job, false) {
+		job.logEntry(wp.logger.WithFields(log.Fields{
+			"package":  "jobs",
+			"function": "WorkerPool.tryTakeOver",
+		})).Debug("Took over job from another instance")
+	}
+}
+
+func (wp *WorkerPoolImpl) wakeDBJobScheduler() {
+	select {
+	case wp.dbJobWake <- struct{}{}:
+	default:
+	}
+}
+
 func (wp *WorkerPoolImpl) startWorkers() {
 	for i := uint(0); i < wp.workerCount; i++ {
 		wp.wg.Add(1)
 		go func() {
 			defer wp.wg.Done()
-			for job := range wp.jobChan {
+			for {
+				job := wp.queue.pop()
 				if job == nil {
 					break
 				}
 
-				if err := wp.process(job); err != nil {
+				err := wp.process(job)
+				wp.queue.done(job)
+
+				if err != nil {
 					// Handle critical processing errors
 
 					entry := job.logEntry(wp.logger.WithFields(log.Fields{
@@ -341,23 +669,94 @@ func (wp *WorkerPoolImpl) startWorkers() {
 	}
 }
 
-func (wp *WorkerPoolImpl) tryEnqueue(job *Job, block bool) bool {
-	if block {
-		select {
-		case <-wp.stopChan:
-			return false
-		case wp.jobChan <- job:
-			return true
-		}
-	} else {
-		select {
-		case <-wp.stopChan:
-			return false
-		case wp.jobChan <- job:
-			return true
-		default:
-			return false
+// startHeartbeat renews the leases of the jobs being executed until all
+// workers have stopped.
+func (wp *WorkerPoolImpl) startHeartbeat() {
+	go func() {
+		wp.wg.Wait()
+		close(wp.workersDone)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(wp.leaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				wp.renewLeases()
+			case <-wp.workersDone:
+				return
+			}
 		}
+	}()
+}
+
+func (wp *WorkerPoolImpl) renewLeases() {
+	ids := wp.runningJobs()
+	if len(ids) == 0 {
+		return
+	}
+
+	renewed, err := wp.store.RenewLeases(wp.workerID, ids, wp.leaseDuration)
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err, "workerID": wp.workerID}).
+			Warn("Could not renew job leases")
+		return
+	}
+
+	if renewed < int64(len(ids)) {
+		// Jobs may have finished in the meantime, or their leases expired
+		// and were taken over by another instance
+		wp.logger.
+			WithFields(log.Fields{"workerID": wp.workerID, "running": len(ids), "renewed": renewed}).
+			Debug("Not all job leases were renewed")
+	}
+}
+
+func (wp *WorkerPoolImpl) setRunning(id uuid.UUID, running bool) {
+	wp.runningMu.Lock()
+	defer wp.runningMu.Unlock()
+
+	if !running {
+		delete(wp.running, id)
+		return
+	}
+
+	if wp.running == nil {
+		wp.running = make(map[uuid.UUID]struct{})
+	}
+	wp.running[id] = struct{}{}
+}
+
+func (wp *WorkerPoolImpl) runningJobs() []uuid.UUID {
+	wp.runningMu.Lock()
+	defer wp.runningMu.Unlock()
+
+	ids := make([]uuid.UUID, 0, len(wp.running))
+	for id := range wp.running {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func defaultWorkerID() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "worker"
+	}
+	return fmt.Sprintf("%s-%s", hostname, uuid.NewString()[:8])
+}
+
+func (wp *WorkerPoolImpl) tryEnqueue(job *Job, block bool) bool {
+	select {
+	case <-wp.stopChan:
+		return false
+	case <-wp.drainChan:
+		return false
+	default:
+		return wp.queue.push(job, block)
 	}
 }
 
@@ -372,20 +771,55 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 		return nil
 	}
 
+	// Keep the lease while the job is being executed
+	wp.setRunning(job.ID, true)
+	defer wp.setRunning(job.ID, false)
+
 	executor, exists := wp.executors[job.Type]
 	if !exists {
 		entry.Warn("Could not process job, no registered executor for type")
 
 		job.State = NoAvailableWorkers
+		job.LeaseExpiresAt = sql.NullTime{}
 
 		if err := wp.store.UpdateJob(job); err != nil {
 			return fmt.Errorf("error while updating database entry: %w", err)
 		}
 
+		stateChanged(*job)
+
 		return nil
 	}
 
-	if err := executor(wp.context, job); err != nil {
+	if err := executor(contextWithExecution(wp.context, job, wp.store), job); err != nil {
+		cancelledByDrain := wp.Draining() && wp.context.Err() != nil
+
+		if cancelledByDrain && job.TransactionSubmittedAt.Valid {
+			// Not released, another instance executing the job right away may
+			// submit another transaction (e.g. a second transfer) while the
+			// first one may still be executed. The job errors like any other
+			// job and is only executed again after the backoff.
+			err = fmt.Errorf("cancelled while draining after a transaction was submitted, the transaction may still be executed: %w", err)
+		} else if cancelledByDrain {
+			// Cancelled at the drain deadline, other instances take over
+			job.State = NoAvailableWorkers
+			job.Error = err.Error()
+			job.Errors = append(job.Errors, err.Error())
+			job.LeaseExpiresAt = sql.NullTime{}
+
+			entry.
+				WithFields(log.Fields{"error": err}).
+				Warn("Job execution cancelled while draining, releasing job")
+
+			if err := wp.store.UpdateJob(job); err != nil {
+				return fmt.Errorf("error while updating database entry: %w", err)
+			}
+
+			stateChanged(*job)
+
+			return nil
+		}
+
 		// Check for chain connection errors
 		if wallet_errors.IsChainConnectionError(err) {
 			// Stop processing this job any further, returning it to the pool.
@@ -394,8 +828,10 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 
 		if job.ExecCount > wp.maxJobErrorCount || errors.Is(err, ErrPermanentFailure) {
 			job.State = Failed
+			job.NextRunAt = sql.NullTime{}
 		} else {
 			job.State = Error
+			job.NextRunAt = sql.NullTime{Time: time.Now().Add(wp.backoff.Delay(job.ExecCount)), Valid: true}
 		}
 
 		job.Error = err.Error()
@@ -408,12 +844,17 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 	} else {
 		job.State = Complete
 		job.Error = "" // Clear the error message for the final & successful execution
+		job.NextRunAt = sql.NullTime{}
 	}
 
+	job.LeaseExpiresAt = sql.NullTime{}
+
 	if err := wp.store.UpdateJob(job); err != nil {
 		return fmt.Errorf("error while updating database entry: %w", err)
 	}
 
+	stateChanged(*job)
+
 	if (job.State == Failed || job.State == Complete) && job.ShouldSendNotification && wp.notificationConfig.ShouldSendJobStatus() {
 		if err := wp.scheduleJobStatusNotification(job); err != nil {
 			entry.
@@ -422,9 +863,71 @@ func (wp *WorkerPoolImpl) process(job *Job) error {
 		}
 	}
 
+	if job.State == Failed || job.State == Complete {
+		wp.releaseDependents(job.ID)
+	}
+
 	return nil
 }
 
+// releaseDependents resolves the jobs waiting for parent, scheduling the ones
+// whose parents have all completed. Skipped jobs release their own dependents
+// in turn.
+func (wp *WorkerPoolImpl) releaseDependents(parentID uuid.UUID) {
+	children, err := wp.store.DependentJobs(parentID)
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err, "parentJobID": parentID}).
+			Warn("Could not fetch dependent jobs from DB")
+		return
+	}
+
+	for _, c := range children {
+		wp.resolveBlockedJob(c.ID)
+	}
+}
+
+func (wp *WorkerPoolImpl) resolveBlockedJob(id uuid.UUID) {
+	job, resolved, err := wp.store.ResolveBlockedJob(id)
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err, "jobID": id}).
+			Warn("Could not resolve blocked job")
+		return
+	}
+
+	if !resolved {
+		return
+	}
+
+	stateChanged(job)
+
+	switch job.State {
+	case Init:
+		if err := wp.Schedule(&job); err != nil {
+			job.logEntry(wp.logger.WithFields(log.Fields{"error": err})).Warn("Could not schedule released job")
+		}
+	case Skipped:
+		wp.releaseDependents(job.ID)
+	}
+}
+
+// resolveBlockedJobs checks all BLOCKED jobs, this releases jobs whose
+// parents were cancelled or finished while the worker pool was not running.
+func (wp *WorkerPoolImpl) resolveBlockedJobs() {
+	jobs, err := wp.store.BlockedJobs(datastore.ParseListOptions(0, 0))
+	if err != nil {
+		wp.logger.
+			WithFields(log.Fields{"error": err}).
+			Warn("Could not fetch blocked jobs from DB")
+		return
+	}
+
+	for _, j := range jobs {
+		wp.resolveBlockedJob(j.ID)
+	}
+}
+
 func (wp *WorkerPoolImpl) executeSendJobStatus(ctx context.Context, j *Job) error {
 	if j.Type != SendJobStatusJobType {
 		return ErrInvalidJobType
@@ -432,7 +935,50 @@ func (wp *WorkerPoolImpl) executeSendJobStatus(ctx context.Context, j *Job) erro
 
 	j.ShouldSendNotification = false
 
-	return wp.notificationConfig.SendJobStatus(ctx, j.Result)
+	attrs, err := parseNotificationJobAttributes(j)
+	if err != nil {
+		return err
+	}
+
+	target, err := wp.notificationConfig.target(attrs)
+	if err != nil {
+		return err
+	}
+
+	if target == nil {
+		// Job status webhook is not configured, nothing to deliver to
+		return nil
+	}
+
+	delivery, err := sendWebhook(ctx, target, j.Result)
+	wp.recordDelivery(j, attrs, delivery, err)
+
+	return err
+}
+
+// recordDelivery stores the delivery attempt of a notification job.
+// Failing to store it is logged but does not fail the delivery.
+func (wp *WorkerPoolImpl) recordDelivery(j *Job, attrs notificationJobAttributes, delivery *Delivery, sendErr error) {
+	delivery.NotificationJobID = j.ID
+	delivery.SubscriptionID = attrs.SubscriptionID
+	delivery.Event = attrs.Event
+	delivery.Attempt = j.ExecCount
+
+	if parentID, err := uuid.Parse(attrs.ParentJobID); err == nil {
+		delivery.ParentJobID = uuid.NullUUID{UUID: parentID, Valid: true}
+	}
+
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	if err := wp.store.InsertDelivery(delivery); err != nil {
+		j.logEntry(wp.logger.WithFields(log.Fields{
+			"package":  "jobs",
+			"function": "WorkerPool.recordDelivery",
+			"error":    err,
+		})).Warn("Could not store webhook delivery")
+	}
 }
 
 func PermanentFailure(err error) error {
@@ -447,18 +993,83 @@ func (wp *WorkerPoolImpl) scheduleJobStatusNotification(parent *Job) error {
 
 	entry.Debug("Scheduling job status notification")
 
-	job, err := wp.CreateJob(SendJobStatusJobType, "")
+	b, err := json.Marshal(parent.ToJSONResponse())
 	if err != nil {
 		return err
 	}
 
-	b, err := json.Marshal(parent.ToJSONResponse())
+	attrs, err := json.Marshal(notificationJobAttributes{ParentJobID: parent.ID.String()})
+	if err != nil {
+		return err
+	}
+
+	return wp.scheduleNotification(string(b), WithAttributes(attrs))
+}
+
+// ScheduleWebhookNotification schedules the delivery of content to a webhook
+// subscription. Delivery is retried like any other job.
+// The parent job id is optional and only used to look up deliveries.
+func (wp *WorkerPoolImpl) ScheduleWebhookNotification(subscriptionID, event, parentJobID, content string) error {
+	wp.logger.
+		WithFields(log.Fields{
+			"package":        "jobs",
+			"function":       "ScheduleWebhookNotification",
+			"subscriptionID": subscriptionID,
+			"event":          event,
+		}).
+		Debug("Scheduling webhook notification")
+
+	attrs, err := json.Marshal(notificationJobAttributes{
+		SubscriptionID: subscriptionID,
+		Event:          event,
+		ParentJobID:    parentJobID,
+	})
+	if err != nil {
+		return err
+	}
+
+	return wp.scheduleNotification(content, WithAttributes(attrs))
+}
+
+// ResendNotification schedules a new notification job with the same content
+// and target as an existing one. The job status webhook and subscriptions
+// are resolved again, so the current configuration is used.
+func (wp *WorkerPoolImpl) ResendNotification(notificationJobID uuid.UUID) (*Job, error) {
+	orig, err := wp.store.Job(notificationJobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if orig.Type != SendJobStatusJobType {
+		return nil, ErrInvalidJobType
+	}
+
+	job, err := wp.CreateJob(SendJobStatusJobType, "", WithAttributes(orig.Attributes))
+	if err != nil {
+		return nil, err
+	}
+
+	job.Result = orig.Result
+
+	if err := wp.store.UpdateJob(job); err != nil {
+		return nil, err
+	}
+
+	if err := wp.Schedule(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (wp *WorkerPoolImpl) scheduleNotification(content string, opts ...JobOption) error {
+	job, err := wp.CreateJob(SendJobStatusJobType, "", opts...)
 	if err != nil {
 		return err
 	}
 
-	// Store the notification content of the parent job in Result of the new job
-	job.Result = string(b)
+	// Store the notification content in Result of the new job
+	job.Result = content
 
 	if err := wp.store.UpdateJob(job); err != nil {
 		return err
@@ -3,28 +3,35 @@ package basic
 import (
 	"context"
 
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
 	"github.com/numeroai/flow-wallet-api/keys"
 	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
 )
 
 func (s *KeyManager) InitAdminProposalKeys(ctx context.Context) (uint16, error) {
-	adminAddress := flow.HexToAddress(s.cfg.AdminAddress)
+	return s.initProposalKeys(ctx, flow.HexToAddress(s.cfg.AdminAddress), nil)
+}
 
-	adminAccount, err := s.fc.GetAccount(ctx, adminAddress)
+// initProposalKeys pools the non-revoked keys of the account. If publicKey is
+// given only the keys matching it are pooled, as they are signed with it.
+func (s *KeyManager) initProposalKeys(ctx context.Context, address flow.Address, publicKey crypto.PublicKey) (uint16, error) {
+	account, err := s.fc.GetAccount(ctx, address)
 	if err != nil {
 		return 0, err
 	}
 
 	// TODO: we should not do this
-	err = s.store.DeleteAllProposalKeys()
+	err = s.store.DeleteAllProposalKeys(flow_helpers.FormatAddress(address))
 	if err != nil {
 		return 0, err
 	}
 
 	var count uint16
-	for _, k := range adminAccount.Keys {
-		if !k.Revoked {
+	for _, k := range account.Keys {
+		if !k.Revoked && (publicKey == nil || publicKey.Equals(k.PublicKey)) {
 			err = s.store.InsertProposalKey(keys.ProposalKey{
+				Address:  flow_helpers.FormatAddress(address),
 				KeyIndex: k.Index,
 			})
 			if err != nil {
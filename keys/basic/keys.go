@@ -43,25 +43,28 @@ func NewKeyManager(cfg *configs.Config, store keys.Store, fc flow_helpers.FlowCl
 		HashAlgo: crypto.StringToHashAlgorithm(cfg.DefaultHashAlgo),
 	}
 
-	var crypter encryption.Crypter
-	switch cfg.EncryptionKeyType {
-	default:
-		crypter = encryption.NewAESCrypter([]byte(cfg.EncryptionKey))
-	case encryption.EncryptionKeyTypeGoogleKMS:
-		crypter = google.NewGoogleKMSCrypter([]byte(cfg.EncryptionKey))
-	case encryption.EncryptionKeyTypeAWSKMS:
-		crypter = aws.NewAWSKMSCrypter([]byte(cfg.EncryptionKey))
-	}
-
 	return &KeyManager{
 		store,
 		fc,
-		crypter,
+		NewCrypter(cfg),
 		adminAccountKey,
 		cfg,
 	}
 }
 
+// NewCrypter returns the crypter for the configured encryption key type, it
+// is used to store keys and other secrets encrypted.
+func NewCrypter(cfg *configs.Config) encryption.Crypter {
+	switch cfg.EncryptionKeyType {
+	default:
+		return encryption.NewAESCrypter([]byte(cfg.EncryptionKey))
+	case encryption.EncryptionKeyTypeGoogleKMS:
+		return google.NewGoogleKMSCrypter([]byte(cfg.EncryptionKey))
+	case encryption.EncryptionKeyTypeAWSKMS:
+		return aws.NewAWSKMSCrypter([]byte(cfg.EncryptionKey))
+	}
+}
+
 func (s *KeyManager) CheckAdminProposalKeyCount(ctx context.Context) error {
 	adminAddress := flow.HexToAddress(s.cfg.AdminAddress)
 
@@ -86,7 +89,7 @@ func (s *KeyManager) CheckAdminProposalKeyCount(ctx context.Context) error {
 		)
 	}
 
-	if inDBCount, err := s.store.ProposalKeyCount(); err != nil {
+	if inDBCount, err := s.store.ProposalKeyCount(flow_helpers.FormatAddress(adminAddress)); err != nil {
 		return fmt.Errorf("error while fetching admin proposal key count from database: %w", err)
 	} else if inDBCount < int64(s.cfg.AdminProposalKeyCount) {
 		return fmt.Errorf(
@@ -191,30 +194,34 @@ func (s *KeyManager) MakeAuthorizer(ctx context.Context, address flow.Address) (
 }
 
 func (s *KeyManager) AdminProposalKey(ctx context.Context) (keys.Authorizer, error) {
-	adminAcc := flow.HexToAddress(s.cfg.AdminAddress)
+	return s.proposalKey(ctx, flow.HexToAddress(s.cfg.AdminAddress), s.cfg.AdminProposalKeyCount, s.adminAccountKey)
+}
 
-	index, err := s.store.ProposalKeyIndex(int(s.cfg.AdminProposalKeyCount))
+// proposalKey returns the "least recently used" key of the proposal key pool
+// of address. All keys of the pool are signed with k.
+func (s *KeyManager) proposalKey(ctx context.Context, address flow.Address, keyCount uint16, k keys.Private) (keys.Authorizer, error) {
+	index, err := s.store.ProposalKeyIndex(flow_helpers.FormatAddress(address), int(keyCount))
 	if err != nil {
-		return keys.Authorizer{}, fmt.Errorf("unable to get admin proposal key: %w", err)
+		return keys.Authorizer{}, fmt.Errorf("unable to get proposal key: %w", err)
 	}
 
-	acc, err := s.fc.GetAccount(ctx, adminAcc)
+	acc, err := s.fc.GetAccount(ctx, address)
 	if err != nil {
 		return keys.Authorizer{}, err
 	}
 
-	sig, err := signerForKey(ctx, adminAcc, s.adminAccountKey)
+	sig, err := signerForKey(ctx, address, k)
 	if err != nil {
 		return keys.Authorizer{}, err
 	}
 
 	log.WithFields(log.Fields{
-		"address":  s.cfg.AdminAddress,
+		"address":  address.Hex(),
 		"keyIndex": index,
-	}).Debug("Using admin proposal key")
+	}).Debug("Using proposal key")
 
 	return keys.Authorizer{
-		Address: adminAcc,
+		Address: address,
 		Key:     acc.Keys[index],
 		Signer:  sig,
 	}, nil
@@ -0,0 +1,170 @@
+package basic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/keys"
+	"github.com/onflow/flow-go-sdk"
+)
+
+func (s *KeyManager) AddPayer(ctx context.Context, payer *keys.Payer, key keys.Private) error {
+	address := flow.HexToAddress(payer.Address)
+
+	acc, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return fmt.Errorf("error while fetching payer account from chain: %w", err)
+	}
+
+	if int(key.Index) >= len(acc.Keys) || acc.Keys[key.Index].Revoked {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("key %d of %s does not exist or is revoked", key.Index, address.HexWithPrefix()),
+		}
+	}
+
+	sig, err := signerForKey(ctx, address, key)
+	if err != nil {
+		return err
+	}
+
+	if !sig.PublicKey().Equals(acc.Keys[key.Index].PublicKey) {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("private key does not match key %d of %s", key.Index, address.HexWithPrefix()),
+		}
+	}
+
+	// Convert the key to storable form (encrypt it)
+	storable, err := s.Save(key)
+	if err != nil {
+		return err
+	}
+
+	payer.Address = flow_helpers.FormatAddress(address)
+	payer.KeyIndex = storable.Index
+	payer.KeyType = storable.Type
+	payer.KeyValue = storable.Value
+	payer.SignAlgo = storable.SignAlgo
+	payer.HashAlgo = storable.HashAlgo
+
+	if err := s.store.InsertPayer(payer); err != nil {
+		return err
+	}
+
+	count, err := s.InitPayerProposalKeys(ctx, payer.Name)
+	if err == nil && count < payer.ProposalKeyCount {
+		err = &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("configured %d proposal keys, onchain: %d", payer.ProposalKeyCount, count),
+		}
+	}
+
+	if err != nil {
+		if deleteErr := s.store.DeletePayer(payer.Name); deleteErr != nil {
+			return fmt.Errorf("error while removing payer: %s, after: %w", deleteErr, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *KeyManager) PayerAuthorizer(ctx context.Context, name string) (keys.Authorizer, error) {
+	if isAdminPayer(name) {
+		return s.AdminAuthorizer(ctx)
+	}
+
+	payer, k, err := s.payer(name)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	address := flow.HexToAddress(payer.Address)
+
+	acc, err := s.fc.GetAccount(ctx, address)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	sig, err := signerForKey(ctx, address, k)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	return keys.Authorizer{
+		Address: address,
+		Key:     acc.Keys[k.Index],
+		Signer:  sig,
+	}, nil
+}
+
+func (s *KeyManager) PayerProposalKey(ctx context.Context, name string) (keys.Authorizer, error) {
+	if isAdminPayer(name) {
+		return s.AdminProposalKey(ctx)
+	}
+
+	payer, k, err := s.payer(name)
+	if err != nil {
+		return keys.Authorizer{}, err
+	}
+
+	return s.proposalKey(ctx, flow.HexToAddress(payer.Address), payer.ProposalKeyCount, k)
+}
+
+func (s *KeyManager) InitPayerProposalKeys(ctx context.Context, name string) (uint16, error) {
+	if isAdminPayer(name) {
+		return s.InitAdminProposalKeys(ctx)
+	}
+
+	payer, k, err := s.payer(name)
+	if err != nil {
+		return 0, err
+	}
+
+	address := flow.HexToAddress(payer.Address)
+
+	sig, err := signerForKey(ctx, address, k)
+	if err != nil {
+		return 0, err
+	}
+
+	// Other keys of the payer account can not be signed with the payer key
+	return s.initProposalKeys(ctx, address, sig.PublicKey())
+}
+
+// payer returns the payer with the given name and its decrypted key.
+func (s *KeyManager) payer(name string) (keys.Payer, keys.Private, error) {
+	payer, err := s.store.Payer(name)
+	if err != nil {
+		if strings.Contains(err.Error(), "record not found") {
+			// Convert error to a 400 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("unknown payer: %q", name),
+			}
+		}
+		return keys.Payer{}, keys.Private{}, err
+	}
+
+	k, err := s.Load(keys.Storable{
+		Index:    payer.KeyIndex,
+		Type:     payer.KeyType,
+		Value:    payer.KeyValue,
+		SignAlgo: payer.SignAlgo,
+		HashAlgo: payer.HashAlgo,
+	})
+	if err != nil {
+		return keys.Payer{}, keys.Private{}, err
+	}
+
+	return payer, k, nil
+}
+
+func isAdminPayer(name string) bool {
+	return name == "" || name == keys.AdminPayerName
+}
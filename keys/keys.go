@@ -17,6 +17,10 @@ const (
 	AccountKeyTypeAWSKMS    = "aws_kms"
 )
 
+// AdminPayerName is the name of the admin account as a payer. Transactions
+// without a payer are paid by the admin account.
+const AdminPayerName = "admin"
+
 var ErrAdminProposalKeyCountMismatch = errors.New("admin-proposal-key count mismatch")
 
 // Manager provides the functions needed for key management.
@@ -40,6 +44,19 @@ type Manager interface {
 	InitAdminProposalKeys(ctx context.Context) (uint16, error)
 	// AdminProposalKey returns Authorizer to be used as proposer.
 	AdminProposalKey(ctx context.Context) (Authorizer, error)
+	// AddPayer checks that key is a valid key of the payer account, stores the
+	// payer with the key encrypted and inits its proposal keys.
+	AddPayer(ctx context.Context, payer *Payer, key Private) error
+	// PayerAuthorizer returns an Authorizer for the payer with the given name.
+	// The admin account is used if name is empty or AdminPayerName.
+	PayerAuthorizer(ctx context.Context, name string) (Authorizer, error)
+	// PayerProposalKey returns Authorizer of the payer with the given name to
+	// be used as proposer.
+	PayerProposalKey(ctx context.Context, name string) (Authorizer, error)
+	// InitPayerProposalKeys will init the proposal keys of the payer in the
+	// database and return current count. Only keys of the payer account with
+	// the public key of the payer key are used.
+	InitPayerProposalKeys(ctx context.Context, name string) (uint16, error)
 }
 
 // Storable struct represents a storable account private key.
@@ -66,8 +83,9 @@ func (Storable) TableName() string {
 }
 
 type ProposalKey struct {
-	ID        int `json:"-" gorm:"primaryKey"`
-	KeyIndex  uint32 `gorm:"unique"`
+	ID        int    `json:"-" gorm:"primaryKey"`
+	Address   string `gorm:"uniqueIndex:idx_proposal_keys_address_key_index"`
+	KeyIndex  uint32 `gorm:"uniqueIndex:idx_proposal_keys_address_key_index"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -76,6 +94,26 @@ func (ProposalKey) TableName() string {
 	return "proposal_keys"
 }
 
+// Payer is an account, other than the admin account, which pays for
+// transactions. Like the admin account it has a single private key and a pool
+// of proposal keys. The key is stored like a Storable.
+type Payer struct {
+	Name             string    `json:"name" gorm:"primaryKey"`
+	Address          string    `json:"address" gorm:"index"`
+	KeyIndex         uint32    `json:"keyIndex"`
+	KeyType          string    `json:"keyType"`
+	KeyValue         []byte    `json:"-"`
+	SignAlgo         string    `json:"signAlgo"`
+	HashAlgo         string    `json:"hashAlgo"`
+	ProposalKeyCount uint16    `json:"proposalKeyCount"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+func (Payer) TableName() string {
+	return "payers"
+}
+
 // Private is an "in flight" account private key meaning its Value should be the actual
 // private key or resource id (unencrypted).
 type Private struct {
@@ -3,8 +3,12 @@ package keys
 // Store is the interface required by key manager for data storage.
 type Store interface {
 	AccountKey(address string) (Storable, error)
-	ProposalKeyIndex(limitKeyCount int) (uint32, error)
-	ProposalKeyCount() (int64, error)
+	ProposalKeyIndex(address string, limitKeyCount int) (uint32, error)
+	ProposalKeyCount(address string) (int64, error)
 	InsertProposalKey(proposalKey ProposalKey) error
-	DeleteAllProposalKeys() error
+	DeleteAllProposalKeys(address string) error
+	Payers() ([]Payer, error)
+	Payer(name string) (Payer, error)
+	InsertPayer(*Payer) error
+	DeletePayer(name string) error
 }
@@ -46,14 +46,14 @@ func (s *GormStore) AccountKey(address string) (Storable, error) {
 	return k, err
 }
 
-func (s *GormStore) ProposalKeyIndex(limitKeyCount int) (uint32, error) {
+func (s *GormStore) ProposalKeyIndex(address string, limitKeyCount int) (uint32, error) {
 	s.proposalKeyMutex.Lock()
 	defer s.proposalKeyMutex.Unlock()
 
 	p := ProposalKey{}
 
 	err := lib.GormTransaction(s.db, func(tx *gorm.DB) error {
-		if err := tx.Table("(?) as p", tx.Model(p).Order("id asc").Limit(limitKeyCount)).
+		if err := tx.Table("(?) as p", tx.Model(p).Where("address = ?", address).Order("id asc").Limit(limitKeyCount)).
 			// NOWAIT so this call will fail rather than use a stale value
 			Clauses(clause.Locking{Strength: "UPDATE", Options: "NOWAIT"}).
 			Order("updated_at asc").
@@ -71,15 +71,49 @@ func (s *GormStore) ProposalKeyIndex(limitKeyCount int) (uint32, error) {
 	return p.KeyIndex, err
 }
 
-func (s *GormStore) ProposalKeyCount() (int64, error) {
+func (s *GormStore) ProposalKeyCount(address string) (int64, error) {
 	var count int64
-	return count, s.db.Table(ProposalKey{}.TableName()).Count(&count).Error
+	return count, s.db.Table(ProposalKey{}.TableName()).Where("address = ?", address).Count(&count).Error
 }
 
 func (s *GormStore) InsertProposalKey(p ProposalKey) error {
 	return s.db.Create(&p).Error
 }
 
-func (s *GormStore) DeleteAllProposalKeys() error {
-	return s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&ProposalKey{}).Error
+func (s *GormStore) DeleteAllProposalKeys(address string) error {
+	return s.db.Where("address = ?", address).Delete(&ProposalKey{}).Error
+}
+
+func (s *GormStore) Payers() (pp []Payer, err error) {
+	err = s.db.Order("name asc").Find(&pp).Error
+	return
+}
+
+func (s *GormStore) Payer(name string) (p Payer, err error) {
+	err = s.db.Where(&Payer{Name: name}).First(&p).Error
+	return
+}
+
+func (s *GormStore) InsertPayer(p *Payer) error {
+	return s.db.Create(p).Error
+}
+
+func (s *GormStore) DeletePayer(name string) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		p := Payer{}
+		if err := tx.Where(&Payer{Name: name}).First(&p).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("address = ?", p.Address).Delete(&ProposalKey{}).Error; err != nil {
+			return err
+		}
+
+		// Accounts of the payer fall back to the admin payer
+		if err := tx.Table("accounts").Where("payer = ?", name).Update("payer", "").Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&p).Error
+	})
 }
\ No newline at end of file
@@ -7,20 +7,28 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/numeroai/flow-wallet-api/accounts"
+	"github.com/numeroai/flow-wallet-api/auth"
 	"github.com/numeroai/flow-wallet-api/chain_events"
 	"github.com/numeroai/flow-wallet-api/configs"
 	"github.com/numeroai/flow-wallet-api/datastore/gorm"
+	"github.com/numeroai/flow-wallet-api/events"
 	"github.com/numeroai/flow-wallet-api/handlers"
 	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/numeroai/flow-wallet-api/keys"
 	"github.com/numeroai/flow-wallet-api/keys/basic"
+	"github.com/numeroai/flow-wallet-api/payers"
+	"github.com/numeroai/flow-wallet-api/retention"
+	"github.com/numeroai/flow-wallet-api/schedules"
+	"github.com/numeroai/flow-wallet-api/subscriptions"
 	"github.com/numeroai/flow-wallet-api/system"
 	"github.com/numeroai/flow-wallet-api/templates"
 	"github.com/numeroai/flow-wallet-api/tokens"
 	"github.com/numeroai/flow-wallet-api/transactions"
+	"github.com/numeroai/flow-wallet-api/workflows"
 	"github.com/gomodule/redigo/redis"
 	"github.com/gorilla/mux"
 	"github.com/onflow/flow-go-sdk/access/grpc"
@@ -100,17 +108,88 @@ func runServer(cfg *configs.Config) {
 		system.WithPauseDuration(cfg.PauseDuration),
 	)
 
+	subscriptionStore := subscriptions.NewGormStore(db)
+	crypter := basic.NewCrypter(cfg)
+
+	jobTypePriorities, err := jobs.ParseJobTypeValues(cfg.JobTypePriorities)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobTypeConcurrency, err := jobs.ParseJobTypeValues(cfg.JobTypeConcurrency)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobTypeReservedWorkers, err := jobs.ParseJobTypeValues(cfg.JobTypeReservedWorkers)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	jobRetentionRules, err := retention.ParseRules(cfg.JobRetention)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch retention.Mode(cfg.JobRetentionMode) {
+	case retention.ModeSoftDelete, retention.ModeArchive:
+	default:
+		log.Fatalf("job retention mode '%s' not supported", cfg.JobRetentionMode)
+	}
+
+	var jobNotifier jobs.JobNotifier
+	if cfg.DatabaseType == "psql" && !cfg.DisableJobNotifications {
+		n, err := jobs.NewPqNotifier(cfg.DatabaseDSN)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer n.Close()
+		jobNotifier = n
+	}
+
+	var jobQueueRedis jobs.RedisQueueConfig
+	switch cfg.JobQueueType {
+	case "local":
+	case "redis":
+		if cfg.IdempotencyMiddlewareRedisURL == "" {
+			log.Fatal("job queue set to redis but Redis URL is empty")
+		}
+		jobQueueRedis = jobs.RedisQueueConfig{
+			Pool: &redis.Pool{
+				MaxIdle: 80,
+				Dial: func() (redis.Conn, error) {
+					return redis.DialURL(cfg.IdempotencyMiddlewareRedisURL)
+				},
+			},
+			Stream: cfg.JobQueueRedisStream,
+			Group:  cfg.JobQueueRedisGroup,
+		}
+		defer jobQueueRedis.Pool.Close()
+	default:
+		log.Fatalf("job queue type '%s' not supported", cfg.JobQueueType)
+	}
+
 	// Create a worker pool
 	wp := jobs.NewWorkerPool(
 		jobs.NewGormStore(db),
 		cfg.WorkerQueueCapacity,
 		cfg.WorkerCount,
 		jobs.WithJobStatusWebhook(cfg.JobStatusWebhookUrl, cfg.JobStatusWebhookTimeout),
+		jobs.WithJobStatusWebhookSecret(cfg.JobStatusWebhookSecret),
+		jobs.WithWebhookTargetResolver(subscriptions.NewTargetResolver(subscriptionStore, crypter)),
 		jobs.WithSystemService(systemService),
 		jobs.WithMaxJobErrorCount(cfg.MaxJobErrorCount),
 		jobs.WithDbJobPollInterval(cfg.DBJobPollInterval),
 		jobs.WithAcceptedGracePeriod(cfg.AcceptedGracePeriod),
+		jobs.WithLeaseDuration(cfg.JobLeaseDuration),
+		jobs.WithWorkerID(cfg.WorkerID),
 		jobs.WithReSchedulableGracePeriod(cfg.ReSchedulableGracePeriod),
+		jobs.WithBackoff(cfg.JobBackoffBase, cfg.JobBackoffMax, cfg.JobBackoffJitter),
+		jobs.WithJobTypePriorities(jobTypePriorities),
+		jobs.WithJobTypeConcurrency(jobTypeConcurrency),
+		jobs.WithJobTypeReservedWorkers(jobTypeReservedWorkers),
+		jobs.WithJobNotifier(jobNotifier),
+		jobs.WithRedisQueue(jobQueueRedis),
 	)
 
 	defer func() {
@@ -121,14 +200,30 @@ func runServer(cfg *configs.Config) {
 	txRatelimiter := ratelimit.New(cfg.TransactionMaxSendRate, ratelimit.WithoutSlack)
 
 	// Key manager
-	km := basic.NewKeyManager(cfg, keys.NewGormStore(db), fc)
+	keyStore := keys.NewGormStore(db)
+	km := basic.NewKeyManager(cfg, keyStore, fc)
 
 	// Services
+	authService := auth.NewService(auth.NewGormStore(db), auth.WithAdminKey(cfg.AdminAPIKey))
 	templateService := templates.NewService(cfg, templates.NewGormStore(db))
-	jobsService := jobs.NewService(jobs.NewGormStore(db))
+	jobsService := jobs.NewService(jobs.NewGormStore(db), wp,
+		jobs.WithMaxWaitTimeout(cfg.JobWaitMaxTimeout),
+		jobs.WithWaitPollInterval(cfg.JobWaitPollInterval),
+	)
 	transactionService := transactions.NewService(cfg, transactions.NewGormStore(db), km, fc, wp, transactions.WithTxRatelimiter(txRatelimiter))
+	payerService := payers.NewService(cfg, keyStore, km, transactionService)
 	accountService := accounts.NewService(cfg, accounts.NewGormStore(db), km, fc, wp, transactionService, accounts.WithTxRatelimiter(txRatelimiter))
 	tokenService := tokens.NewService(cfg, tokens.NewGormStore(db), km, fc, wp, transactionService, templateService, accountService)
+	subscriptionService := subscriptions.NewService(subscriptionStore, wp, crypter)
+	scheduleService := schedules.NewService(cfg, schedules.NewGormStore(db), tokenService)
+	workflowService := workflows.NewService(workflows.NewGormStore(db), wp, jobsService, accountService, tokenService)
+	retentionService := retention.NewService(retention.NewGormStore(db), wp,
+		retention.WithRules(jobRetentionRules),
+		retention.WithMode(retention.Mode(cfg.JobRetentionMode)),
+		retention.WithInterval(cfg.JobRetentionInterval),
+		retention.WithExportDir(cfg.JobRetentionExportDir),
+		retention.WithEventTTL(cfg.EventStreamRetention),
+	)
 
 	// Register a handler for account added events
 	accounts.AccountAdded.Register(&tokens.AccountAddedHandler{
@@ -136,11 +231,37 @@ func runServer(cfg *configs.Config) {
 		TokenService:    tokenService,
 	})
 
+	// Register handlers publishing events to webhook subscriptions
+	jobs.JobFinished.Register(&subscriptions.JobFinishedHandler{Service: subscriptionService})
+	accounts.AccountAdded.Register(&subscriptions.AccountAddedHandler{Service: subscriptionService})
+	tokens.DepositRegistered.Register(&subscriptions.DepositRegisteredHandler{Service: subscriptionService})
+
+	// Register handlers publishing events to the event stream
+	eventService := events.NewService(events.NewGormStore(db),
+		events.WithPollInterval(cfg.EventStreamPollInterval),
+		events.WithCommitGrace(cfg.EventStreamCommitGrace),
+	)
+	if !cfg.DisableEventStream {
+		jobs.JobUpdated.Register(&events.JobUpdatedHandler{Service: eventService})
+		accounts.AccountAdded.Register(&events.AccountAddedHandler{Service: eventService})
+		tokens.DepositRegistered.Register(&events.DepositRegisteredHandler{Service: eventService})
+	}
+
 	err = accountService.InitAdminAccount(context.Background())
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Secrets of subscriptions created before secrets were encrypted
+	if err := subscriptionService.EncryptPlaintextSecrets(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Schedule pruning of expired jobs
+	if err := retentionService.Schedule(); err != nil {
+		log.Fatal(err)
+	}
+
 	wp.Start()
 	log.Info("Started workerpool")
 
@@ -151,6 +272,20 @@ func runServer(cfg *configs.Config) {
 	accountHandler := handlers.NewAccounts(accountService)
 	transactionHandler := handlers.NewTransactions(transactionService)
 	tokenHandler := handlers.NewTokens(tokenService)
+	apiKeyHandler := handlers.NewAPIKeys(authService)
+	subscriptionHandler := handlers.NewSubscriptions(subscriptionService)
+	payerHandler := handlers.NewPayers(payerService)
+	transferScheduleHandler := handlers.NewTransferSchedules(scheduleService)
+	workflowHandler := handlers.NewWorkflows(workflowService)
+	eventHandler := handlers.NewEvents(eventService, cfg.EventStreamKeepAlive)
+
+	// Every route declares the API key scope it requires
+	scoped := func(scope auth.Scope, h http.Handler) http.Handler {
+		if cfg.DisableAuth {
+			return h
+		}
+		return handlers.RequireScope(scope, h)
+	}
 
 	r := mux.NewRouter()
 
@@ -158,92 +293,146 @@ func runServer(cfg *configs.Config) {
 	rv := r.PathPrefix("/{apiVersion}").Subrouter()
 
 	// Debug
-	rv.Handle("/debug", handlers.Debug("https://github.com/numeroai/flow-wallet-api", sha1ver, buildTime)).Methods(http.MethodGet)
+	rv.Handle("/debug", scoped(auth.ScopeSystemAdmin, handlers.Debug("https://github.com/numeroai/flow-wallet-api", sha1ver, buildTime))).Methods(http.MethodGet)
 
 	// Health
-	rv.HandleFunc("/health/ready", handlers.HandleHealthReady).Methods(http.MethodGet)
+	rv.Handle("/health/ready", handlers.Readiness(func() bool {
+		return !wp.Draining()
+	})).Methods(http.MethodGet)
 	rv.Handle("/health/liveness", handlers.Liveness(func() (interface{}, error) {
 		return wp.Status()
 	})).Methods(http.MethodGet)
 
 	// System
-	rv.Handle("/system/settings", systemHandler.GetSettings()).Methods(http.MethodGet)
-	rv.Handle("/system/settings", systemHandler.SetSettings()).Methods(http.MethodPost)
-
-	rv.Handle("/system/sync-account-key-count", accountHandler.SyncAccountKeyCount()).Methods(http.MethodPost)
+	rv.Handle("/system/settings", scoped(auth.ScopeSystemAdmin, systemHandler.GetSettings())).Methods(http.MethodGet)
+	rv.Handle("/system/settings", scoped(auth.ScopeSystemAdmin, systemHandler.SetSettings())).Methods(http.MethodPost)
+
+	rv.Handle("/system/sync-account-key-count", scoped(auth.ScopeAccountsWrite, accountHandler.SyncAccountKeyCount())).Methods(http.MethodPost)
+
+	// API keys
+	rv.Handle("/system/api-keys", scoped(auth.ScopeSystemAdmin, apiKeyHandler.List())).Methods(http.MethodGet)           // list
+	rv.Handle("/system/api-keys", scoped(auth.ScopeSystemAdmin, apiKeyHandler.Create())).Methods(http.MethodPost)        // create
+	rv.Handle("/system/api-keys/{id}", scoped(auth.ScopeSystemAdmin, apiKeyHandler.Revoke())).Methods(http.MethodDelete) // revoke
+
+	// Payers
+	rv.Handle("/payers", scoped(auth.ScopeSystemAdmin, payerHandler.List())).Methods(http.MethodGet)             // list
+	rv.Handle("/payers", scoped(auth.ScopeSystemAdmin, payerHandler.Create())).Methods(http.MethodPost)          // create
+	rv.Handle("/payers/{name}", scoped(auth.ScopeSystemAdmin, payerHandler.Details())).Methods(http.MethodGet)   // details
+	rv.Handle("/payers/{name}", scoped(auth.ScopeSystemAdmin, payerHandler.Delete())).Methods(http.MethodDelete) // delete
+
+	// Webhook subscriptions
+	rv.Handle("/webhooks/subscriptions", scoped(auth.ScopeWebhooksRead, subscriptionHandler.List())).Methods(http.MethodGet)            // list
+	rv.Handle("/webhooks/subscriptions", scoped(auth.ScopeWebhooksWrite, subscriptionHandler.Create())).Methods(http.MethodPost)        // create
+	rv.Handle("/webhooks/subscriptions/{id}", scoped(auth.ScopeWebhooksRead, subscriptionHandler.Details())).Methods(http.MethodGet)    // details
+	rv.Handle("/webhooks/subscriptions/{id}", scoped(auth.ScopeWebhooksWrite, subscriptionHandler.Update())).Methods(http.MethodPut)    // update
+	rv.Handle("/webhooks/subscriptions/{id}", scoped(auth.ScopeWebhooksWrite, subscriptionHandler.Delete())).Methods(http.MethodDelete) // delete
+
+	// Recurring transfer schedules
+	rv.Handle("/transfer-schedules", scoped(auth.ScopeTokensRead, transferScheduleHandler.List())).Methods(http.MethodGet)                    // list
+	rv.Handle("/transfer-schedules", scoped(auth.ScopeTokensWithdraw, transferScheduleHandler.Create())).Methods(http.MethodPost)             // create
+	rv.Handle("/transfer-schedules/{id}", scoped(auth.ScopeTokensRead, transferScheduleHandler.Details())).Methods(http.MethodGet)            // details
+	rv.Handle("/transfer-schedules/{id}", scoped(auth.ScopeTokensWithdraw, transferScheduleHandler.Delete())).Methods(http.MethodDelete)      // delete
+	rv.Handle("/transfer-schedules/{id}/pause", scoped(auth.ScopeTokensWithdraw, transferScheduleHandler.Pause())).Methods(http.MethodPost)   // pause
+	rv.Handle("/transfer-schedules/{id}/resume", scoped(auth.ScopeTokensWithdraw, transferScheduleHandler.Resume())).Methods(http.MethodPost) // resume
+	rv.Handle("/transfer-schedules/{id}/runs", scoped(auth.ScopeTokensRead, transferScheduleHandler.Runs())).Methods(http.MethodGet)          // run history
+
+	// Event stream
+	if !cfg.DisableEventStream {
+		rv.Handle("/events/stream", scoped(auth.ScopeEventsRead, eventHandler.Stream())).Methods(http.MethodGet) // server-sent events
+	} else {
+		log.Info("event stream disabled")
+	}
 
 	// Jobs
-	rv.Handle("/jobs", jobsHandler.List()).Methods(http.MethodGet)            // list
-	rv.Handle("/jobs/{jobId}", jobsHandler.Details()).Methods(http.MethodGet) // details
+	rv.Handle("/jobs", scoped(auth.ScopeJobsRead, jobsHandler.List())).Methods(http.MethodGet)                                                    // list
+	rv.Handle("/jobs/retry", scoped(auth.ScopeJobsWrite, jobsHandler.RetryFailed())).Methods(http.MethodPost)                                     // bulk retry failed
+	rv.Handle("/jobs/schemas", scoped(auth.ScopeJobsRead, jobsHandler.Schemas())).Methods(http.MethodGet)                                         // job type schemas
+	rv.Handle("/jobs/schemas/{jobType}", scoped(auth.ScopeJobsRead, jobsHandler.Schema())).Methods(http.MethodGet)                                // job type schema
+	rv.Handle("/jobs/{jobId}", scoped(auth.ScopeJobsRead, jobsHandler.Details())).Methods(http.MethodGet)                                         // details
+	rv.Handle("/jobs/{jobId}/retry", scoped(auth.ScopeJobsWrite, jobsHandler.Retry())).Methods(http.MethodPost)                                   // retry failed
+	rv.Handle("/jobs/{jobId}/cancel", scoped(auth.ScopeJobsWrite, jobsHandler.Cancel())).Methods(http.MethodPost)                                 // cancel
+	rv.Handle("/jobs/{jobId}/deliveries", scoped(auth.ScopeJobsRead, jobsHandler.Deliveries())).Methods(http.MethodGet)                           // webhook deliveries
+	rv.Handle("/jobs/{jobId}/deliveries/{deliveryId}/replay", scoped(auth.ScopeJobsWrite, jobsHandler.ReplayDelivery())).Methods(http.MethodPost) // replay webhook delivery
+
+	// Workflows, the scopes of the step operations are checked by the handler
+	rv.Handle("/workflows", scoped(auth.ScopeJobsWrite, workflowHandler.Create())).Methods(http.MethodPost)     // create
+	rv.Handle("/workflows/{id}", scoped(auth.ScopeJobsRead, workflowHandler.Details())).Methods(http.MethodGet) // details
 
 	// Token templates
-	rv.Handle("/tokens", templateHandler.ListTokens(templates.NotSpecified)).Methods(http.MethodGet) // list
-	rv.Handle("/tokens", templateHandler.AddToken()).Methods(http.MethodPost)                        // create
-	rv.Handle("/tokens/{id_or_name}", templateHandler.GetToken()).Methods(http.MethodGet)            // details
-	rv.Handle("/tokens/{id}", templateHandler.RemoveToken()).Methods(http.MethodDelete)              // delete
+	rv.Handle("/tokens", scoped(auth.ScopeTokensRead, templateHandler.ListTokens(templates.NotSpecified))).Methods(http.MethodGet) // list
+	rv.Handle("/tokens", scoped(auth.ScopeSystemAdmin, templateHandler.AddToken())).Methods(http.MethodPost)                       // create
+	rv.Handle("/tokens/{id_or_name}", scoped(auth.ScopeTokensRead, templateHandler.GetToken())).Methods(http.MethodGet)            // details
+	rv.Handle("/tokens/{id}", scoped(auth.ScopeSystemAdmin, templateHandler.RemoveToken())).Methods(http.MethodDelete)             // delete
 
 	// List enabled tokens by type
-	rv.Handle("/fungible-tokens", templateHandler.ListTokens(templates.FT)).Methods(http.MethodGet)      // list
-	rv.Handle("/non-fungible-tokens", templateHandler.ListTokens(templates.NFT)).Methods(http.MethodGet) // list
+	rv.Handle("/fungible-tokens", scoped(auth.ScopeTokensRead, templateHandler.ListTokens(templates.FT))).Methods(http.MethodGet)      // list
+	rv.Handle("/non-fungible-tokens", scoped(auth.ScopeTokensRead, templateHandler.ListTokens(templates.NFT))).Methods(http.MethodGet) // list
 
 	// Transactions
-	rv.Handle("/transactions", transactionHandler.List()).Methods(http.MethodGet)                    // list
-	rv.Handle("/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet) // details
+	rv.Handle("/transactions", scoped(auth.ScopeTransactionsRead, transactionHandler.List())).Methods(http.MethodGet)                    // list
+	rv.Handle("/transactions/estimate", scoped(auth.ScopeTransactionsRead, transactionHandler.Estimate())).Methods(http.MethodPost)      // estimate
+	rv.Handle("/transactions/{transactionId}", scoped(auth.ScopeTransactionsRead, transactionHandler.Details())).Methods(http.MethodGet) // details
 
 	// Account
-	rv.Handle("/accounts", accountHandler.List()).Methods(http.MethodGet)                                    // list
-	rv.Handle("/accounts", accountHandler.Create()).Methods(http.MethodPost)                                 // create
-	rv.Handle("/accounts/{address}", accountHandler.Details()).Methods(http.MethodGet)                       // details
-	rv.Handle("/accounts/{address}/add-new-key", accountHandler.AddNewKey()).Methods(http.MethodPost)        // add new key
-	rv.Handle("/accounts/{address}/revoke-key/{index}", accountHandler.RevokeKey()).Methods(http.MethodPost) // add new key
-	rv.Handle("/get-keys/{type}", accountHandler.GetKeysByType()).Methods(http.MethodGet) // add new key
+	rv.Handle("/accounts", scoped(auth.ScopeAccountsRead, accountHandler.List())).Methods(http.MethodGet)                                     // list
+	rv.Handle("/accounts", scoped(auth.ScopeAccountsWrite, accountHandler.Create())).Methods(http.MethodPost)                                 // create
+	rv.Handle("/accounts/{address}", scoped(auth.ScopeAccountsRead, accountHandler.Details())).Methods(http.MethodGet)                        // details
+	rv.Handle("/accounts/{address}/add-new-key", scoped(auth.ScopeAccountsWrite, accountHandler.AddNewKey())).Methods(http.MethodPost)        // add new key
+	rv.Handle("/accounts/{address}/payer", scoped(auth.ScopeAccountsWrite, accountHandler.SetPayer())).Methods(http.MethodPut)                // set payer
+	rv.Handle("/accounts/{address}/revoke-key/{index}", scoped(auth.ScopeAccountsWrite, accountHandler.RevokeKey())).Methods(http.MethodPost) // add new key
+	rv.Handle("/get-keys/{type}", scoped(auth.ScopeAccountsRead, accountHandler.GetKeysByType())).Methods(http.MethodGet)                     // add new key
 
 	// Account raw transactions
 	if !cfg.DisableRawTransactions {
-		rv.Handle("/accounts/{address}/sign", transactionHandler.Sign()).Methods(http.MethodPost)                           // sign
-		rv.Handle("/accounts/{address}/transactions", transactionHandler.List()).Methods(http.MethodGet)                    // list
-		rv.Handle("/accounts/{address}/transactions", transactionHandler.Create()).Methods(http.MethodPost)                 // create
-		rv.Handle("/accounts/{address}/transactions/{transactionId}", transactionHandler.Details()).Methods(http.MethodGet) // details
+		rv.Handle("/accounts/{address}/sign", scoped(auth.ScopeTransactionsWrite, transactionHandler.Sign())).Methods(http.MethodPost)                          // sign
+		rv.Handle("/accounts/{address}/transactions", scoped(auth.ScopeTransactionsRead, transactionHandler.List())).Methods(http.MethodGet)                    // list
+		rv.Handle("/accounts/{address}/transactions", scoped(auth.ScopeTransactionsWrite, transactionHandler.Create())).Methods(http.MethodPost)                // create
+		rv.Handle("/accounts/{address}/transactions/{transactionId}", scoped(auth.ScopeTransactionsRead, transactionHandler.Details())).Methods(http.MethodGet) // details
+
+		// Externally signed transactions, co-signed by the admin account as payer
+		rv.Handle("/transactions/co-sign", scoped(auth.ScopeTransactionsWrite, transactionHandler.CoSign())).Methods(http.MethodPost) // co-sign and send
 	} else {
 		log.Info("raw transactions disabled")
 	}
 
 	// Non-custodial watchlist accounts
-	rv.Handle("/watchlist/accounts", accountHandler.AddNonCustodialAccount()).Methods(http.MethodPost)                // add
-	rv.Handle("/watchlist/accounts/{address}", accountHandler.DeleteNonCustodialAccount()).Methods(http.MethodDelete) // delete
+	rv.Handle("/watchlist/accounts", scoped(auth.ScopeAccountsWrite, accountHandler.AddNonCustodialAccount())).Methods(http.MethodPost)                // add
+	rv.Handle("/watchlist/accounts/{address}", scoped(auth.ScopeAccountsWrite, accountHandler.DeleteNonCustodialAccount())).Methods(http.MethodDelete) // delete
 
 	// Scripts
-	rv.Handle("/scripts", transactionHandler.ExecuteScript()).Methods(http.MethodPost) // execute
+	rv.Handle("/scripts", scoped(auth.ScopeScriptsExecute, transactionHandler.ExecuteScript())).Methods(http.MethodPost) // execute
 
 	// Fungible tokens
 	if !cfg.DisableFungibleTokens {
-		rv.Handle("/accounts/{address}/fungible-tokens", tokenHandler.AccountTokens(templates.FT)).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", tokenHandler.Details()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", tokenHandler.Setup()).Methods(http.MethodPost)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", tokenHandler.ListWithdrawals()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", tokenHandler.CreateWithdrawal()).Methods(http.MethodPost)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals/{transactionId}", tokenHandler.GetWithdrawal()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/deposits", tokenHandler.ListDeposits()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/deposits/{transactionId}", tokenHandler.GetDeposit()).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/fungible-tokens", scoped(auth.ScopeTokensRead, tokenHandler.AccountTokens(templates.FT))).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", scoped(auth.ScopeTokensRead, tokenHandler.Details())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}", scoped(auth.ScopeTokensWrite, tokenHandler.Setup())).Methods(http.MethodPost)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", scoped(auth.ScopeTokensRead, tokenHandler.ListWithdrawals())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals", scoped(auth.ScopeTokensWithdraw, tokenHandler.CreateWithdrawal())).Methods(http.MethodPost)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/withdrawals/{transactionId}", scoped(auth.ScopeTokensRead, tokenHandler.GetWithdrawal())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/deposits", scoped(auth.ScopeTokensRead, tokenHandler.ListDeposits())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/fungible-tokens/{tokenName}/deposits/{transactionId}", scoped(auth.ScopeTokensRead, tokenHandler.GetDeposit())).Methods(http.MethodGet)
 	} else {
 		log.Info("fungible tokens disabled")
 	}
 
 	// Non-Fungible tokens
 	if !cfg.DisableNonFungibleTokens {
-		rv.Handle("/accounts/{address}/non-fungible-tokens", tokenHandler.AccountTokens(templates.NFT)).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", tokenHandler.Details()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", tokenHandler.Setup()).Methods(http.MethodPost)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", tokenHandler.ListWithdrawals()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", tokenHandler.CreateWithdrawal()).Methods(http.MethodPost)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals/{transactionId}", tokenHandler.GetWithdrawal()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/deposits", tokenHandler.ListDeposits()).Methods(http.MethodGet)
-		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/deposits/{transactionId}", tokenHandler.GetDeposit()).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/non-fungible-tokens", scoped(auth.ScopeTokensRead, tokenHandler.AccountTokens(templates.NFT))).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", scoped(auth.ScopeTokensRead, tokenHandler.Details())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}", scoped(auth.ScopeTokensWrite, tokenHandler.Setup())).Methods(http.MethodPost)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", scoped(auth.ScopeTokensRead, tokenHandler.ListWithdrawals())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals", scoped(auth.ScopeTokensWithdraw, tokenHandler.CreateWithdrawal())).Methods(http.MethodPost)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/withdrawals/{transactionId}", scoped(auth.ScopeTokensRead, tokenHandler.GetWithdrawal())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/deposits", scoped(auth.ScopeTokensRead, tokenHandler.ListDeposits())).Methods(http.MethodGet)
+		rv.Handle("/accounts/{address}/non-fungible-tokens/{tokenName}/deposits/{transactionId}", scoped(auth.ScopeTokensRead, tokenHandler.GetDeposit())).Methods(http.MethodGet)
 	} else {
 		log.Info("non-fungible tokens disabled")
 	}
 
-	h := http.TimeoutHandler(r, cfg.ServerRequestTimeout, "request timed out")
+	h := handlers.UseTimeout(r, cfg.ServerRequestTimeout, handlers.TimeoutHandlerOptions{
+		IgnorePaths: []string{"/v1/events/stream"}, // Streams stay open until the client disconnects
+	})
 	h = handlers.UseCors(h)
 	h = handlers.UseLogging(h)
 	h = handlers.UseCompress(h)
@@ -289,10 +478,26 @@ func runServer(cfg *configs.Config) {
 
 		h = handlers.UseIdempotency(h, handlers.IdempotencyHandlerOptions{
 			Expiry:      1 * time.Hour,
-			IgnorePaths: []string{"/v1/scripts"}, // Scripts are read-only
+			IgnorePaths: []string{"/v1/scripts", "/v1/transactions/estimate"}, // Scripts and estimates are read-only
 		}, is)
 	}
 
+	// Reject new work while draining, before idempotency keys are stored
+	h = handlers.UseDrain(h, wp.Draining)
+
+	// Setup API key authentication unless it's disabled
+	if !cfg.DisableAuth {
+		if cfg.AdminAPIKey == "" {
+			log.Fatal("api key authentication enabled but admin api key is empty")
+		}
+
+		h = handlers.UseAuth(h, handlers.AuthHandlerOptions{
+			IgnorePaths: []string{"/v1/health"}, // Health checks are used by orchestrators
+		}, authService)
+	} else {
+		log.Info("api key authentication disabled")
+	}
+
 	// Server boilerplate
 	srv := &http.Server{
 		Handler:      h,
@@ -361,16 +566,42 @@ func runServer(cfg *configs.Config) {
 		log.Info("Started chain events listener")
 	}
 
+	// Recurring transfer schedule runner
+	scheduleRunner := schedules.NewRunner(
+		scheduleService,
+		cfg.TransferSchedulePollInterval,
+		schedules.WithSystemService(systemService),
+	)
+
+	defer func() {
+		scheduleRunner.Stop()
+		log.Info("Stopped transfer schedule runner")
+	}()
+
+	scheduleRunner.Start()
+
+	log.Info("Started transfer schedule runner")
+
 	// Trap interupt or sigterm and gracefully shutdown the server
 	c := make(chan os.Signal, 1)
-	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C)
-	// SIGKILL, SIGQUIT or SIGTERM (Ctrl+/) will not be caught.
-	signal.Notify(c, os.Interrupt)
+	// We'll accept graceful shutdowns when quit via SIGINT (Ctrl+C) or
+	// SIGTERM (e.g. Kubernetes). SIGKILL or SIGQUIT (Ctrl+/) will not be caught.
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	// Block until we receive our signal.
 	sig := <-c
 
-	log.Infof("Got signal: %s. Shutting down..", sig)
+	log.Infof("Got signal: %s. Draining..", sig)
+
+	// Readiness reports not ready and new work is rejected while the
+	// running jobs finish, unfinished jobs are released to other instances
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.ShutdownDrainTimeout)
+	defer cancelDrain()
+	if err := wp.Drain(drainCtx); err != nil {
+		log.Warnf("Error while draining workerpool: %s", err)
+	}
+
+	log.Info("Shutting down..")
 
 	// Create a deadline to wait for.
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*60)
@@ -106,7 +106,7 @@ func TestAccountServices(t *testing.T) {
 	})
 
 	t.Run("sync create", func(t *testing.T) {
-		_, account, err := svc.Create(context.Background(), true)
+		_, account, err := svc.Create(context.Background(), true, "")
 		fatal(t, err)
 
 		if _, err := flow_helpers.ValidateAddress(account.Address, flow.Emulator); err != nil {
@@ -115,7 +115,7 @@ func TestAccountServices(t *testing.T) {
 	})
 
 	t.Run("async create", func(t *testing.T) {
-		job, _, err := svc.Create(context.Background(), false)
+		job, _, err := svc.Create(context.Background(), false, "")
 		fatal(t, err)
 
 		job, err = test.WaitForJob(app.GetJobs(), job.ID.String())
@@ -152,7 +152,7 @@ func TestAccountServices(t *testing.T) {
 		expected := "Account initialized with custom script"
 
 		// Use the new service to create an account
-		job, _, err := svc2.Create(context.Background(), false)
+		job, _, err := svc2.Create(context.Background(), false, "")
 		fatal(t, err)
 
 		if job, err := test.WaitForJob(app2.GetJobs(), job.ID.String()); err != nil {
@@ -171,7 +171,7 @@ func TestAccountServices(t *testing.T) {
 		app2 := test.GetServices(t, cfg2)
 		svc2 := app2.GetAccounts()
 
-		_, acc, err := svc2.Create(context.Background(), true)
+		_, acc, err := svc2.Create(context.Background(), true, "")
 		fatal(t, err)
 
 		if len(acc.Keys) != int(cfg2.DefaultAccountKeyCount) {
@@ -204,7 +204,7 @@ func TestAccountServices(t *testing.T) {
 		app2 := test.GetServices(t, cfg2)
 		svc2 := app2.GetAccounts()
 
-		job, _, err := svc2.Create(context.Background(), false)
+		job, _, err := svc2.Create(context.Background(), false, "")
 		fatal(t, err)
 
 		job, err = test.WaitForJob(app2.GetJobs(), job.ID.String())
@@ -614,6 +614,7 @@ func TestTransactionHandlers(t *testing.T) {
 		context.Background(),
 		true,
 		cfg.AdminAddress,
+		nil,
 		"transaction() { prepare(signer: &Account){} execute { log(\"Hello World!\") }}",
 		nil,
 		transactions.General,
@@ -624,6 +625,7 @@ func TestTransactionHandlers(t *testing.T) {
 		context.Background(),
 		true,
 		cfg.AdminAddress,
+		nil,
 		transferFlow,
 		[]transactions.Argument{
 			cadence.UFix64(1.0),
@@ -798,7 +800,7 @@ func TestTokenServices(t *testing.T) {
 
 	t.Run("account can make a transaction", func(t *testing.T) {
 		// Create an account
-		_, account, err := accountSvc.Create(context.Background(), true)
+		_, account, err := accountSvc.Create(context.Background(), true, "")
 		fatal(t, err)
 
 		// Fund the account from service account
@@ -835,7 +837,7 @@ func TestTokenServices(t *testing.T) {
 
 	t.Run("account can not make a transaction without funds", func(t *testing.T) {
 		// Create an account
-		_, account, err := accountSvc.Create(context.Background(), true)
+		_, account, err := accountSvc.Create(context.Background(), true, "")
 		fatal(t, err)
 
 		_, _, err = svc.CreateWithdrawal(
@@ -876,7 +878,7 @@ func TestTokenServices(t *testing.T) {
 		}
 
 		// Create an account
-		_, account, err := accountSvc.Create(ctx, true)
+		_, account, err := accountSvc.Create(ctx, true, "")
 		fatal(t, err)
 
 		// Setup the new account to be able to handle FUSD
@@ -912,7 +914,7 @@ func TestTokenServices(t *testing.T) {
 		ctx := context.Background()
 
 		// Create an account
-		_, account, err := accountSvc.Create(ctx, true)
+		_, account, err := accountSvc.Create(ctx, true, "")
 		fatal(t, err)
 
 		// Setup the new account to be able to handle the non-existent token
@@ -1010,13 +1012,13 @@ func TestTokenHandlers(t *testing.T) {
 	// Create a few accounts
 	testAccounts := make([]*accounts.Account, 2)
 	for i := 0; i < 2; i++ {
-		_, a, err := accountSvc.Create(context.Background(), true)
+		_, a, err := accountSvc.Create(context.Background(), true, "")
 		fatal(t, err)
 
 		testAccounts[i] = a
 	}
 
-	_, testAccount, err := accountSvc.Create(context.Background(), true)
+	_, testAccount, err := accountSvc.Create(context.Background(), true, "")
 	fatal(t, err)
 
 	_, testTransferFT, err := svc.CreateWithdrawal(
@@ -1096,7 +1098,7 @@ func TestTokenHandlers(t *testing.T) {
 	// Mint ExampleNFTs for account 0
 	mintCode := templates.TokenCode(cfg.ChainID, &exampleNft, string(mintBytes))
 	for i := 0; i < 3; i++ {
-		_, _, err := transactionSvc.Create(context.Background(), true, cfg.AdminAddress, mintCode,
+		_, _, err := transactionSvc.Create(context.Background(), true, cfg.AdminAddress, nil, mintCode,
 			[]transactions.Argument{cadence.NewAddress(flow.HexToAddress(testAccounts[0].Address))},
 			transactions.General)
 		fatal(t, err)
@@ -0,0 +1,45 @@
+// m20220301 handles adding the `api_keys` table
+package m20220301
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const ID = "20220301"
+
+// APIKey database model
+type APIKey struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Name      string         `gorm:"column:name"`
+	Prefix    string         `gorm:"column:prefix"`
+	Hash      string         `gorm:"column:hash;uniqueIndex"`
+	Scopes    pq.StringArray `gorm:"column:scopes;type:text[]"`
+	RevokedAt sql.NullTime   `gorm:"column:revoked_at"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&APIKey{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&APIKey{}); err != nil {
+		return err
+	}
+
+	return nil
+}
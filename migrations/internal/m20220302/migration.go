@@ -0,0 +1,46 @@
+// m20220302 handles adding the `webhook_subscriptions` table
+package m20220302
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const ID = "20220302"
+
+// Subscription database model
+type Subscription struct {
+	ID         uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	URL        string         `gorm:"column:url"`
+	Secret     string         `gorm:"column:secret"`
+	Timeout    time.Duration  `gorm:"column:timeout"`
+	EventKinds pq.StringArray `gorm:"column:event_kinds;type:text[]"`
+	JobTypes   pq.StringArray `gorm:"column:job_types;type:text[]"`
+	JobStates  pq.StringArray `gorm:"column:job_states;type:text[]"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Subscription{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Subscription{}); err != nil {
+		return err
+	}
+
+	return nil
+}
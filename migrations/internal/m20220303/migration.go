@@ -0,0 +1,47 @@
+// m20220303 handles adding the `webhook_deliveries` table
+package m20220303
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ID = "20220303"
+
+// Delivery database model
+type Delivery struct {
+	ID                uuid.UUID     `gorm:"column:id;primary_key;type:uuid;"`
+	NotificationJobID uuid.UUID     `gorm:"column:notification_job_id;type:uuid;index"`
+	ParentJobID       uuid.NullUUID `gorm:"column:parent_job_id;type:uuid;index"`
+	SubscriptionID    string        `gorm:"column:subscription_id"`
+	Event             string        `gorm:"column:event"`
+	URL               string        `gorm:"column:url"`
+	Attempt           int           `gorm:"column:attempt"`
+	StatusCode        int           `gorm:"column:status_code"`
+	Latency           time.Duration `gorm:"column:latency"`
+	ResponseSnippet   string        `gorm:"column:response_snippet"`
+	Error             string        `gorm:"column:error"`
+	CreatedAt         time.Time     `gorm:"column:created_at"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Delivery{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Delivery{}); err != nil {
+		return err
+	}
+
+	return nil
+}
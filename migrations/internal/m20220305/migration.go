@@ -0,0 +1,55 @@
+// m20220305 handles adding an index for listing jobs by creation time
+package m20220305
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220305"
+
+// State is a type for Job state.
+type State string
+
+// Job database model
+type Job struct {
+	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid;index:idx_jobs_created_at_id,priority:2"`
+	Type                   string         `gorm:"column:type"`
+	State                  State          `gorm:"column:state;default:INIT;index:idx_jobs_state_updated_at"`
+	Error                  string         `gorm:"column:error"`
+	Errors                 pq.StringArray `gorm:"column:errors;type:text[]"`
+	Result                 string         `gorm:"column:result"`
+	TransactionID          string         `gorm:"column:transaction_id"`
+	ExecCount              int            `gorm:"column:exec_count;default:0"`
+	CreatedAt              time.Time      `gorm:"column:created_at;index:idx_jobs_created_at_id,priority:1"`
+	UpdatedAt              time.Time      `gorm:"column:updated_at;index:idx_jobs_state_updated_at"`
+	DeletedAt              gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	ShouldSendNotification bool           `gorm:"-"` // Whether or not to notify admin (via webhook for example)
+	Attributes             datatypes.JSON `gorm:"attributes"`
+	TransactionSubmittedAt sql.NullTime   `gorm:"column:transaction_submitted_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.Migrator().CreateIndex(&Job{}, "idx_jobs_created_at_id"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&Job{}, "idx_jobs_created_at_id"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// m20220308 handles adding the `transfer_schedules` and `transfer_schedule_runs` tables
+package m20220308
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ID = "20220308"
+
+// State is a type for Schedule state.
+type State string
+
+// Schedule database model
+type Schedule struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Sender    string         `gorm:"column:sender;index"`
+	TokenName string         `gorm:"column:token_name"`
+	Recipient string         `gorm:"column:recipient"`
+	FtAmount  string         `gorm:"column:ft_amount"`
+	NftID     uint64         `gorm:"column:nft_id"`
+	Cron      string         `gorm:"column:cron"`
+	StartAt   time.Time      `gorm:"column:start_at"`
+	EndAt     sql.NullTime   `gorm:"column:end_at"`
+	State     State          `gorm:"column:state;index:idx_transfer_schedules_state_next_run_at"`
+	NextRunAt sql.NullTime   `gorm:"column:next_run_at;index:idx_transfer_schedules_state_next_run_at"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Schedule) TableName() string {
+	return "transfer_schedules"
+}
+
+// Run database model
+type Run struct {
+	ID           uuid.UUID     `gorm:"column:id;primary_key;type:uuid;"`
+	ScheduleID   uuid.UUID     `gorm:"column:schedule_id;type:uuid;index"`
+	ScheduledFor time.Time     `gorm:"column:scheduled_for"`
+	JobID        uuid.NullUUID `gorm:"column:job_id;type:uuid"`
+	Error        string        `gorm:"column:error"`
+	CreatedAt    time.Time     `gorm:"column:created_at"`
+}
+
+func (Run) TableName() string {
+	return "transfer_schedule_runs"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Schedule{}, &Run{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Run{}, &Schedule{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+// m20220309 handles adding the `job_dependencies`, `workflows` and `workflow_steps` tables
+package m20220309
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+const ID = "20220309"
+
+// JobDependency database model
+type JobDependency struct {
+	JobID       uuid.UUID `gorm:"column:job_id;primary_key;type:uuid"`
+	ParentJobID uuid.UUID `gorm:"column:parent_job_id;primary_key;type:uuid;index"`
+}
+
+func (JobDependency) TableName() string {
+	return "job_dependencies"
+}
+
+// Workflow database model
+type Workflow struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Workflow) TableName() string {
+	return "workflows"
+}
+
+// Step database model
+type Step struct {
+	ID         uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	WorkflowID uuid.UUID      `gorm:"column:workflow_id;type:uuid;index"`
+	Position   int            `gorm:"column:position"`
+	Name       string         `gorm:"column:name"`
+	Operation  string         `gorm:"column:operation"`
+	DependsOn  pq.StringArray `gorm:"column:depends_on;type:text[]"`
+	JobID      uuid.UUID      `gorm:"column:job_id;type:uuid"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+}
+
+func (Step) TableName() string {
+	return "workflow_steps"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&JobDependency{}, &Workflow{}, &Step{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Step{}, &Workflow{}, &JobDependency{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+// m20220310 handles adding the `stream_events` table
+package m20220310
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220310"
+
+// Event database model
+type Event struct {
+	ID        uint64         `gorm:"column:id;primaryKey;autoIncrement"`
+	Kind      string         `gorm:"column:kind"`
+	Addresses pq.StringArray `gorm:"column:addresses;type:text[]"`
+	JobType   string         `gorm:"column:job_type"`
+	TokenName string         `gorm:"column:token_name"`
+	Data      datatypes.JSON `gorm:"column:data"`
+	CreatedAt time.Time      `gorm:"column:created_at;index"`
+}
+
+func (Event) TableName() string {
+	return "stream_events"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Event{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&Event{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+// m20220312 handles adding the `jobs_archive` table
+package m20220312
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220312"
+
+// State is a type for Job state.
+type State string
+
+// ArchivedJob database model
+type ArchivedJob struct {
+	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid"`
+	Type                   string         `gorm:"column:type"`
+	State                  State          `gorm:"column:state"`
+	Error                  string         `gorm:"column:error"`
+	Errors                 pq.StringArray `gorm:"column:errors;type:text[]"`
+	Result                 string         `gorm:"column:result"`
+	TransactionID          string         `gorm:"column:transaction_id"`
+	ExecCount              int            `gorm:"column:exec_count"`
+	CreatedAt              time.Time      `gorm:"column:created_at"`
+	UpdatedAt              time.Time      `gorm:"column:updated_at"`
+	Attributes             datatypes.JSON `gorm:"column:attributes"`
+	TransactionSubmittedAt sql.NullTime   `gorm:"column:transaction_submitted_at"`
+	Priority               int            `gorm:"column:priority"`
+	WorkerID               string         `gorm:"column:worker_id"`
+	ArchivedAt             time.Time      `gorm:"column:archived_at;index"`
+}
+
+func (ArchivedJob) TableName() string {
+	return "jobs_archive"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&ArchivedJob{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropTable(&ArchivedJob{}); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// m20220313 handles adding the `typed_result` column to jobs and jobs_archive
+package m20220313
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220313"
+
+// State is a type for Job state.
+type State string
+
+// Job database model
+type Job struct {
+	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid;index:idx_jobs_created_at_id,priority:2"`
+	Type                   string         `gorm:"column:type"`
+	State                  State          `gorm:"column:state;default:INIT;index:idx_jobs_state_updated_at"`
+	Error                  string         `gorm:"column:error"`
+	Errors                 pq.StringArray `gorm:"column:errors;type:text[]"`
+	Result                 string         `gorm:"column:result"`
+	TransactionID          string         `gorm:"column:transaction_id"`
+	ExecCount              int            `gorm:"column:exec_count;default:0"`
+	CreatedAt              time.Time      `gorm:"column:created_at;index:idx_jobs_created_at_id,priority:1"`
+	UpdatedAt              time.Time      `gorm:"column:updated_at;index:idx_jobs_state_updated_at"`
+	DeletedAt              gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	ShouldSendNotification bool           `gorm:"-"` // Whether or not to notify admin (via webhook for example)
+	Attributes             datatypes.JSON `gorm:"attributes"`
+	TransactionSubmittedAt sql.NullTime   `gorm:"column:transaction_submitted_at"`
+	NextRunAt              sql.NullTime   `gorm:"column:next_run_at;index"`
+	Priority               int            `gorm:"column:priority;default:0"`
+	WorkerID               string         `gorm:"column:worker_id"`
+	LeaseExpiresAt         sql.NullTime   `gorm:"column:lease_expires_at;index"`
+	TypedResult            datatypes.JSON `gorm:"column:typed_result"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// ArchivedJob database model
+type ArchivedJob struct {
+	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid"`
+	Type                   string         `gorm:"column:type"`
+	State                  State          `gorm:"column:state"`
+	Error                  string         `gorm:"column:error"`
+	Errors                 pq.StringArray `gorm:"column:errors;type:text[]"`
+	Result                 string         `gorm:"column:result"`
+	TransactionID          string         `gorm:"column:transaction_id"`
+	ExecCount              int            `gorm:"column:exec_count"`
+	CreatedAt              time.Time      `gorm:"column:created_at"`
+	UpdatedAt              time.Time      `gorm:"column:updated_at"`
+	Attributes             datatypes.JSON `gorm:"column:attributes"`
+	TransactionSubmittedAt sql.NullTime   `gorm:"column:transaction_submitted_at"`
+	Priority               int            `gorm:"column:priority"`
+	WorkerID               string         `gorm:"column:worker_id"`
+	TypedResult            datatypes.JSON `gorm:"column:typed_result"`
+	ArchivedAt             time.Time      `gorm:"column:archived_at;index"`
+}
+
+func (ArchivedJob) TableName() string {
+	return "jobs_archive"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Job{}); err != nil {
+		return err
+	}
+
+	if err := tx.AutoMigrate(&ArchivedJob{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&ArchivedJob{}, "typed_result"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Job{}, "typed_result"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+// m20220314 handles adding the payers table, the address of proposal keys,
+// the default payer of accounts and the payer and fee of transactions
+package m20220314
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const ID = "20220314"
+
+type Payer struct {
+	Name             string `gorm:"primaryKey"`
+	Address          string `gorm:"index"`
+	KeyIndex         uint32
+	KeyType          string
+	KeyValue         []byte
+	SignAlgo         string
+	HashAlgo         string
+	ProposalKeyCount uint16
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+func (Payer) TableName() string {
+	return "payers"
+}
+
+type ProposalKey struct {
+	ID        int    `gorm:"primaryKey"`
+	Address   string `gorm:"uniqueIndex:idx_proposal_keys_address_key_index"`
+	KeyIndex  uint32 `gorm:"uniqueIndex:idx_proposal_keys_address_key_index"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (ProposalKey) TableName() string {
+	return "proposal_keys"
+}
+
+// adminProposalKey is the proposal key model before payers
+type adminProposalKey struct {
+	ID        int    `gorm:"primaryKey"`
+	KeyIndex  uint32 `gorm:"unique"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (adminProposalKey) TableName() string {
+	return "proposal_keys"
+}
+
+type Account struct {
+	Address string `gorm:"primaryKey"`
+	Payer   string `gorm:"column:payer"`
+}
+
+func (Account) TableName() string {
+	return "accounts"
+}
+
+type Transaction struct {
+	TransactionId string `gorm:"column:transaction_id;primaryKey"`
+	Payer         string `gorm:"column:payer;index"`
+	Fee           uint64 `gorm:"column:fee"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	// The admin proposal keys are added again by address on startup
+	if err := tx.Migrator().DropTable("proposal_keys"); err != nil {
+		return err
+	}
+
+	if err := tx.AutoMigrate(&ProposalKey{}, &Payer{}, &Account{}, &Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&Transaction{}, "fee"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Transaction{}, "payer"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Account{}, "payer"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropTable(&Payer{}); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropTable(&ProposalKey{}); err != nil {
+		return err
+	}
+
+	return tx.AutoMigrate(&adminProposalKey{})
+}
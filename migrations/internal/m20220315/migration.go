@@ -0,0 +1,43 @@
+// m20220315 handles adding the script hash and the computation used of
+// transactions
+package m20220315
+
+import (
+	"gorm.io/gorm"
+)
+
+const ID = "20220315"
+
+type Transaction struct {
+	TransactionId   string `gorm:"column:transaction_id;primaryKey"`
+	ScriptHash      string `gorm:"column:script_hash;index"`
+	ComputationUsed uint64 `gorm:"column:computation_used"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&Transaction{}, "computation_used"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropIndex(&Transaction{}, "ScriptHash"); err != nil {
+		return err
+	}
+
+	if err := tx.Migrator().DropColumn(&Transaction{}, "script_hash"); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+// m20220316 handles adding the intent of transactions, to rebuild them
+// before sending, and the ids of the rebuilt transactions
+package m20220316
+
+import (
+	"github.com/lib/pq"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const ID = "20220316"
+
+type Transaction struct {
+	TransactionId     string         `gorm:"column:transaction_id;primaryKey"`
+	Code              string         `gorm:"column:code"`
+	Arguments         datatypes.JSON `gorm:"column:arguments"`
+	Authorizers       pq.StringArray `gorm:"column:authorizers;type:text[]"`
+	ComputeLimit      uint64         `gorm:"column:compute_limit"`
+	FlowTransactionId string         `gorm:"column:flow_transaction_id;index"`
+	SupersededIds     pq.StringArray `gorm:"column:superseded_ids;type:text[]"`
+}
+
+func (Transaction) TableName() string {
+	return "transactions"
+}
+
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Transaction{}); err != nil {
+		return err
+	}
+
+	// Existing transactions were built when created and are sent as built.
+	// Transactions created asynchronously from now on have no flow transaction
+	// id until their job builds them.
+	return tx.Model(&Transaction{}).
+		Where("flow_transaction_id IS NULL OR flow_transaction_id = ''").
+		Update("flow_transaction_id", gorm.Expr("transaction_id")).Error
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropIndex(&Transaction{}, "FlowTransactionId"); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"code", "arguments", "authorizers", "compute_limit", "flow_transaction_id", "superseded_ids"} {
+		if err := tx.Migrator().DropColumn(&Transaction{}, column); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
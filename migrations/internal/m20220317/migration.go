@@ -0,0 +1,35 @@
+// m20220317 handles adding the encrypted secret of webhook subscriptions
+package m20220317
+
+import (
+	"gorm.io/gorm"
+)
+
+const ID = "20220317"
+
+// Subscription database model
+type Subscription struct {
+	EncryptedSecret []byte `gorm:"column:encrypted_secret"`
+}
+
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Migrate adds the column, existing secrets need the encryption key and are
+// encrypted by the subscriptions service on startup.
+func Migrate(tx *gorm.DB) error {
+	if err := tx.AutoMigrate(&Subscription{}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func Rollback(tx *gorm.DB) error {
+	if err := tx.Migrator().DropColumn(&Subscription{}, "encrypted_secret"); err != nil {
+		return err
+	}
+
+	return nil
+}
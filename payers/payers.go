@@ -0,0 +1,29 @@
+// Package payers provides the management of accounts which pay for
+// transactions on behalf of the admin account.
+package payers
+
+import "github.com/numeroai/flow-wallet-api/keys"
+
+// JSONRequest is the JSON HTTP request to register a payer.
+type JSONRequest struct {
+	// Unique name of the payer, used to select it in requests
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	// Index of the key on the payer account
+	KeyIndex uint32 `json:"keyIndex"`
+	// "local", "google_kms" or "aws_kms", defaults to "local"
+	KeyType string `json:"keyType"`
+	// Private key as hex for "local", resource name of the key for KMS types
+	PrivateKey string `json:"privateKey"`
+	// Number of proposal keys on the payer account, defaults to 1
+	ProposalKeyCount uint16 `json:"proposalKeyCount"`
+}
+
+// JSONResponse is the JSON HTTP response of a payer, including the fees it
+// has paid.
+type JSONResponse struct {
+	keys.Payer
+	// Sum of the fees paid by the payer in FLOW
+	Fees             string `json:"fees"`
+	TransactionCount int64  `json:"transactionCount"`
+}
@@ -0,0 +1,181 @@
+package payers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/numeroai/flow-wallet-api/configs"
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/keys"
+	"github.com/numeroai/flow-wallet-api/transactions"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	log "github.com/sirupsen/logrus"
+)
+
+var nameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+type Service interface {
+	// List returns all payers, including the admin account.
+	List() ([]JSONResponse, error)
+	// Create registers a new payer after checking its key against the chain.
+	Create(ctx context.Context, req JSONRequest) (*JSONResponse, error)
+	Details(name string) (*JSONResponse, error)
+	// Delete removes a payer. Accounts using it as their default payer fall
+	// back to the admin account.
+	Delete(name string) error
+}
+
+type ServiceImpl struct {
+	cfg   *configs.Config
+	store keys.Store
+	km    keys.Manager
+	txs   transactions.Service
+}
+
+func NewService(cfg *configs.Config, store keys.Store, km keys.Manager, txs transactions.Service) Service {
+	return &ServiceImpl{cfg, store, km, txs}
+}
+
+func (s *ServiceImpl) List() ([]JSONResponse, error) {
+	pp, err := s.store.Payers()
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]JSONResponse, 0, len(pp)+1)
+
+	admin, err := s.toJSONResponse(s.adminPayer())
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, *admin)
+
+	for _, p := range pp {
+		r, err := s.toJSONResponse(p)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *r)
+	}
+
+	return res, nil
+}
+
+func (s *ServiceImpl) Create(ctx context.Context, req JSONRequest) (*JSONResponse, error) {
+	log.WithFields(log.Fields{"name": req.Name, "address": req.Address}).Trace("Create payer")
+
+	if !nameRegexp.MatchString(req.Name) || req.Name == keys.AdminPayerName {
+		return nil, badRequest("invalid payer name: %q", req.Name)
+	}
+
+	address, err := flow_helpers.ValidateAddress(req.Address, s.cfg.ChainID)
+	if err != nil {
+		return nil, badRequest("invalid payer address: %q", req.Address)
+	}
+
+	if address == flow_helpers.FormatAddress(flow.HexToAddress(s.cfg.AdminAddress)) {
+		return nil, badRequest("the admin account is already the %q payer", keys.AdminPayerName)
+	}
+
+	if _, err := s.store.Payer(req.Name); err == nil {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusConflict,
+			Err:        fmt.Errorf("payer %q already exists", req.Name),
+		}
+	}
+
+	if req.KeyType == "" {
+		req.KeyType = keys.AccountKeyTypeLocal
+	}
+
+	switch req.KeyType {
+	case keys.AccountKeyTypeLocal, keys.AccountKeyTypeGoogleKMS, keys.AccountKeyTypeAWSKMS:
+	default:
+		return nil, badRequest("invalid key type: %q", req.KeyType)
+	}
+
+	if req.PrivateKey == "" {
+		return nil, badRequest("private key is required")
+	}
+
+	if req.ProposalKeyCount == 0 {
+		req.ProposalKeyCount = 1
+	}
+
+	payer := &keys.Payer{
+		Name:             req.Name,
+		Address:          address,
+		ProposalKeyCount: req.ProposalKeyCount,
+	}
+
+	key := keys.Private{
+		Index:    req.KeyIndex,
+		Type:     req.KeyType,
+		Value:    strings.TrimPrefix(req.PrivateKey, "0x"),
+		SignAlgo: crypto.StringToSignatureAlgorithm(s.cfg.DefaultSignAlgo),
+		HashAlgo: crypto.StringToHashAlgorithm(s.cfg.DefaultHashAlgo),
+	}
+
+	if err := s.km.AddPayer(ctx, payer, key); err != nil {
+		return nil, err
+	}
+
+	return s.toJSONResponse(*payer)
+}
+
+func (s *ServiceImpl) Details(name string) (*JSONResponse, error) {
+	if name == keys.AdminPayerName {
+		return s.toJSONResponse(s.adminPayer())
+	}
+
+	p, err := s.store.Payer(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toJSONResponse(p)
+}
+
+func (s *ServiceImpl) Delete(name string) error {
+	log.WithFields(log.Fields{"name": name}).Trace("Delete payer")
+
+	if name == keys.AdminPayerName {
+		return badRequest("the %q payer can not be deleted", keys.AdminPayerName)
+	}
+
+	return s.store.DeletePayer(name)
+}
+
+// adminPayer returns the admin account as a payer.
+func (s *ServiceImpl) adminPayer() keys.Payer {
+	return keys.Payer{
+		Name:             keys.AdminPayerName,
+		Address:          flow_helpers.FormatAddress(flow.HexToAddress(s.cfg.AdminAddress)),
+		KeyIndex:         s.cfg.AdminKeyIndex,
+		KeyType:          s.cfg.AdminKeyType,
+		SignAlgo:         s.cfg.DefaultSignAlgo,
+		HashAlgo:         s.cfg.DefaultHashAlgo,
+		ProposalKeyCount: s.cfg.AdminProposalKeyCount,
+	}
+}
+
+func (s *ServiceImpl) toJSONResponse(p keys.Payer) (*JSONResponse, error) {
+	fees, count, err := s.txs.PayerFees(p.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONResponse{Payer: p, Fees: fees, TransactionCount: count}, nil
+}
+
+func badRequest(format string, a ...interface{}) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf(format, a...),
+	}
+}
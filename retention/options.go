@@ -0,0 +1,47 @@
+package retention
+
+import (
+	"time"
+)
+
+type ServiceOption func(*ServiceImpl)
+
+func WithRules(rules []Rule) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.rules = rules
+	}
+}
+
+func WithMode(mode Mode) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.mode = mode
+	}
+}
+
+// WithExportDir appends the pruned jobs to JSONL files in dir before they
+// are deleted or archived.
+func WithExportDir(dir string) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.exportDir = dir
+	}
+}
+
+func WithInterval(d time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.interval = d
+	}
+}
+
+func WithBatchSize(size int) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.batchSize = size
+	}
+}
+
+// WithEventTTL prunes the events of the event stream once they are older
+// than ttl. Events are kept forever if ttl is 0.
+func WithEventTTL(ttl time.Duration) ServiceOption {
+	return func(svc *ServiceImpl) {
+		svc.eventTTL = ttl
+	}
+}
@@ -0,0 +1,166 @@
+// Package retention prunes finished jobs once they are older than the time
+// to live of their job type and state, and the events of the event stream
+// once they are older than their time to live, so that neither table grows
+// forever.
+package retention
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"gorm.io/datatypes"
+)
+
+const PruneJobType = "prune_jobs"
+
+// PruneJobResult is the typed result of prune jobs, the legacy result is the
+// number of pruned jobs.
+type PruneJobResult struct {
+	Pruned       int    `json:"pruned"`
+	PrunedEvents int    `json:"prunedEvents"`
+	EventsError  string `json:"eventsError,omitempty"`
+}
+
+// Mode is a type for what happens to pruned jobs.
+type Mode string
+
+const (
+	ModeSoftDelete Mode = "soft-delete" // Set DeletedAt, the rows stay in the jobs table
+	ModeArchive    Mode = "archive"     // Move the rows to the jobs_archive table
+)
+
+// FinishedStates lists the states of jobs which can be pruned.
+var FinishedStates = []jobs.State{jobs.Complete, jobs.Failed, jobs.Cancelled, jobs.Skipped}
+
+// Rule keeps finished jobs for TTL after their last update. An empty job
+// type or state matches all job types or finished states.
+type Rule struct {
+	JobType string
+	State   jobs.State
+	TTL     time.Duration
+}
+
+func (r Rule) specificity() int {
+	s := 0
+	if r.JobType != "" {
+		s += 2
+	}
+	if r.State != "" {
+		s++
+	}
+	return s
+}
+
+// overlaps is true if some jobs match both rules.
+func (r Rule) overlaps(o Rule) bool {
+	return (r.JobType == "" || o.JobType == "" || r.JobType == o.JobType) &&
+		(r.State == "" || o.State == "" || r.State == o.State)
+}
+
+// moreSpecific lists the rules which take precedence over r for some of the
+// jobs r matches. A job type is more specific than a state.
+func (r Rule) moreSpecific(rules []Rule) []Rule {
+	var res []Rule
+	for _, o := range rules {
+		if o != r && o.specificity() > r.specificity() && r.overlaps(o) {
+			res = append(res, o)
+		}
+	}
+	return res
+}
+
+// ParseRules parses a list of "<job type>:<state>:<ttl>" rules. "*" matches
+// any job type or finished state, e.g. "send_job_status:*:24h".
+func ParseRules(values []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(values))
+	for _, v := range values {
+		ss := strings.Split(v, ":")
+		if len(ss) != 3 || ss[0] == "" || ss[1] == "" {
+			return nil, fmt.Errorf("invalid job retention rule %q, expected <job type>:<state>:<ttl>", v)
+		}
+
+		r := Rule{JobType: ss[0], State: jobs.State(strings.ToUpper(ss[1]))}
+		if r.JobType == "*" {
+			r.JobType = ""
+		}
+		if r.State == "*" {
+			r.State = ""
+		} else if !isFinished(r.State) {
+			return nil, fmt.Errorf("invalid job retention rule %q, only jobs in a finished state can be pruned", v)
+		}
+
+		ttl, err := time.ParseDuration(ss[2])
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid job retention rule %q, expected a positive duration", v)
+		}
+		r.TTL = ttl
+
+		for _, o := range rules {
+			if o.JobType == r.JobType && o.State == r.State {
+				return nil, fmt.Errorf("duplicate job retention rule %q", v)
+			}
+		}
+
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func isFinished(s jobs.State) bool {
+	for _, f := range FinishedStates {
+		if s == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchivedJob database model, a job moved out of the jobs table.
+type ArchivedJob struct {
+	ID                     uuid.UUID      `gorm:"column:id;primary_key;type:uuid" json:"jobId"`
+	Type                   string         `gorm:"column:type" json:"type"`
+	State                  jobs.State     `gorm:"column:state" json:"state"`
+	Error                  string         `gorm:"column:error" json:"error"`
+	Errors                 pq.StringArray `gorm:"column:errors;type:text[]" json:"errors"`
+	Result                 string         `gorm:"column:result" json:"result"`
+	TransactionID          string         `gorm:"column:transaction_id" json:"transactionId"`
+	ExecCount              int            `gorm:"column:exec_count" json:"execCount"`
+	CreatedAt              time.Time      `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt              time.Time      `gorm:"column:updated_at" json:"updatedAt"`
+	Attributes             datatypes.JSON `gorm:"column:attributes" json:"attributes"`
+	TransactionSubmittedAt sql.NullTime   `gorm:"column:transaction_submitted_at" json:"-"`
+	Priority               int            `gorm:"column:priority" json:"priority"`
+	WorkerID               string         `gorm:"column:worker_id" json:"workerId"`
+	TypedResult            datatypes.JSON `gorm:"column:typed_result" json:"typedResult"`
+	ArchivedAt             time.Time      `gorm:"column:archived_at;index" json:"archivedAt"`
+}
+
+func (ArchivedJob) TableName() string {
+	return "jobs_archive"
+}
+
+func newArchivedJob(j jobs.Job, now time.Time) ArchivedJob {
+	return ArchivedJob{
+		ID:                     j.ID,
+		Type:                   j.Type,
+		State:                  j.State,
+		Error:                  j.Error,
+		Errors:                 j.Errors,
+		Result:                 j.Result,
+		TransactionID:          j.TransactionID,
+		ExecCount:              j.ExecCount,
+		CreatedAt:              j.CreatedAt,
+		UpdatedAt:              j.UpdatedAt,
+		Attributes:             j.Attributes,
+		TransactionSubmittedAt: j.TransactionSubmittedAt,
+		Priority:               j.Priority,
+		WorkerID:               j.WorkerID,
+		TypedResult:            j.TypedResult,
+		ArchivedAt:             now,
+	}
+}
@@ -0,0 +1,260 @@
+package retention
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultInterval  = time.Hour
+	defaultBatchSize = 1000
+)
+
+type Service interface {
+	// Prune soft deletes or archives the finished jobs which have outlived
+	// the TTL of their rule, and returns how many jobs were pruned.
+	Prune(ctx context.Context) (int, error)
+	// PruneEvents deletes the events of the event stream which have outlived
+	// the event TTL, and returns how many events were deleted.
+	PruneEvents(ctx context.Context) (int, error)
+	// Schedule creates the periodic prune job unless one is already waiting
+	// or running. Each prune job schedules the next one after the interval.
+	Schedule() error
+}
+
+type ServiceImpl struct {
+	store     Store
+	wp        jobs.WorkerPool
+	rules     []Rule
+	mode      Mode
+	exportDir string
+	interval  time.Duration
+	batchSize int
+	eventTTL  time.Duration
+}
+
+func NewService(store Store, wp jobs.WorkerPool, opts ...ServiceOption) Service {
+	svc := &ServiceImpl{
+		store:     store,
+		wp:        wp,
+		mode:      ModeSoftDelete,
+		interval:  defaultInterval,
+		batchSize: defaultBatchSize,
+	}
+
+	// Go through options
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	// Register asynchronous job executor.
+	wp.RegisterExecutor(PruneJobType, svc.executePruneJob,
+		jobs.WithResultSchema(PruneJobResult{}))
+
+	return svc
+}
+
+func (s *ServiceImpl) Prune(ctx context.Context) (int, error) {
+	return s.prune(ctx, fmt.Sprintf("pruned-jobs-%s.jsonl", time.Now().UTC().Format("20060102T150405Z")))
+}
+
+// prune appends the pruned jobs to exportName in the export directory, if
+// one is configured, before they are deleted or archived.
+func (s *ServiceImpl) prune(ctx context.Context, exportName string) (int, error) {
+	if len(s.rules) == 0 {
+		return 0, nil
+	}
+
+	var export *os.File
+	if s.exportDir != "" {
+		f, err := os.OpenFile(filepath.Join(s.exportDir, exportName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+		if err != nil {
+			return 0, fmt.Errorf("error while opening job export file: %w", err)
+		}
+		defer f.Close()
+		export = f
+	}
+
+	now := time.Now()
+	pruned := 0
+
+	for _, rule := range s.rules {
+		before := now.Add(-rule.TTL)
+		except := rule.moreSpecific(s.rules)
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return pruned, err
+			}
+
+			jj, err := s.store.ExpiredJobs(rule, except, before, s.mode == ModeArchive, s.batchSize)
+			if err != nil {
+				return pruned, err
+			}
+
+			if len(jj) == 0 {
+				break
+			}
+
+			if export != nil {
+				if err := exportJobs(export, jj, now); err != nil {
+					return pruned, fmt.Errorf("error while exporting jobs: %w", err)
+				}
+			}
+
+			if err := s.pruneJobs(jj, now); err != nil {
+				return pruned, err
+			}
+
+			pruned += len(jj)
+
+			if len(jj) < s.batchSize {
+				break
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{"pruned": pruned, "mode": s.mode}).Debug("Pruned jobs")
+
+	return pruned, nil
+}
+
+func (s *ServiceImpl) PruneEvents(ctx context.Context) (int, error) {
+	if s.eventTTL <= 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-s.eventTTL)
+	pruned := 0
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return pruned, err
+		}
+
+		n, err := s.store.DeleteStreamEvents(before, s.batchSize)
+		if err != nil {
+			return pruned, err
+		}
+
+		pruned += int(n)
+
+		if int(n) < s.batchSize {
+			break
+		}
+	}
+
+	log.WithFields(log.Fields{"pruned": pruned}).Debug("Pruned stream events")
+
+	return pruned, nil
+}
+
+func (s *ServiceImpl) pruneJobs(jj []jobs.Job, now time.Time) error {
+	if s.mode == ModeArchive {
+		return s.store.ArchiveJobs(jj, now)
+	}
+
+	ids := make([]uuid.UUID, len(jj))
+	for i, j := range jj {
+		ids[i] = j.ID
+	}
+	return s.store.SoftDeleteJobs(ids)
+}
+
+// exportJobs appends a line per job to f and flushes it to disk, so that no
+// job is pruned before it has been exported.
+func exportJobs(f *os.File, jj []jobs.Job, now time.Time) error {
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+
+	for _, j := range jj {
+		if err := enc.Encode(newArchivedJob(j, now)); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+func (s *ServiceImpl) Schedule() error {
+	if len(s.rules) == 0 && s.eventTTL <= 0 {
+		return nil
+	}
+
+	// A running prune job schedules the next one
+	pending, err := s.store.PendingPruneJobs(uuid.Nil, true)
+	if err != nil {
+		return err
+	}
+
+	if pending > 0 {
+		return nil
+	}
+
+	j, err := s.wp.CreateJob(PruneJobType, "")
+	if err != nil {
+		return err
+	}
+
+	return s.wp.Schedule(j)
+}
+
+// scheduleNext creates the next prune job unless another instance has done
+// so already.
+func (s *ServiceImpl) scheduleNext(current uuid.UUID) error {
+	pending, err := s.store.PendingPruneJobs(current, false)
+	if err != nil {
+		return err
+	}
+
+	if pending > 0 {
+		return nil
+	}
+
+	_, err = s.wp.CreateJob(PruneJobType, "", jobs.WithRunAt(time.Now().Add(s.interval)))
+	return err
+}
+
+func (s *ServiceImpl) executePruneJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != PruneJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	// Retries of the same job append to the same export file
+	pruned, err := s.prune(ctx, fmt.Sprintf("pruned-jobs-%s-%s.jsonl", j.CreatedAt.UTC().Format("20060102T150405Z"), j.ID))
+	if err != nil {
+		return err
+	}
+
+	// Failing to prune events must not stop the pruning of jobs, the next
+	// prune job tries again
+	result := PruneJobResult{Pruned: pruned}
+	result.PrunedEvents, err = s.PruneEvents(ctx)
+	if err != nil {
+		log.
+			WithFields(log.Fields{"error": err, "jobID": j.ID}).
+			Warn("Could not prune stream events")
+		result.EventsError = err.Error()
+	}
+
+	j.Result = strconv.Itoa(pruned)
+	if err := j.SetTypedResult(result); err != nil {
+		return err
+	}
+
+	return s.scheduleNext(j.ID)
+}
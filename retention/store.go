@@ -0,0 +1,26 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/jobs"
+)
+
+// Store manages data regarding job retention.
+type Store interface {
+	// ExpiredJobs lists up to limit finished jobs matching rule, and none of
+	// except, which were last updated before. Jobs which BLOCKED jobs depend
+	// on are kept. Soft deleted jobs are listed only if deleted is true.
+	ExpiredJobs(rule Rule, except []Rule, before time.Time, deleted bool, limit int) ([]jobs.Job, error)
+	SoftDeleteJobs(ids []uuid.UUID) error
+	// ArchiveJobs moves the jobs, and their dependencies, out of the jobs
+	// table into the jobs_archive table.
+	ArchiveJobs(jj []jobs.Job, now time.Time) error
+	// DeleteStreamEvents deletes up to limit of the oldest events of the
+	// event stream created before, and returns how many were deleted.
+	DeleteStreamEvents(before time.Time, limit int) (int64, error)
+	// PendingPruneJobs counts the prune jobs waiting to be executed, or being
+	// executed if accepted is true, other than exceptID.
+	PendingPruneJobs(exceptID uuid.UUID, accepted bool) (int64, error)
+}
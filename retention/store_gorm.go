@@ -0,0 +1,120 @@
+package retention
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/datastore/lib"
+	"github.com/numeroai/flow-wallet-api/events"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"gorm.io/gorm"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db}
+}
+
+func (s *GormStore) ExpiredJobs(rule Rule, except []Rule, before time.Time, deleted bool, limit int) (jj []jobs.Job, err error) {
+	blocked := s.db.Model(&jobs.Job{}).Select("id").Where("state = ?", jobs.Blocked)
+	parentsOfBlocked := s.db.Model(&jobs.JobDependency{}).Select("parent_job_id").Where("job_id IN (?)", blocked)
+
+	q := s.db
+	if deleted {
+		q = q.Unscoped()
+	}
+
+	q = q.
+		Where("state IN ?", FinishedStates).
+		Where("updated_at < ?", before).
+		Where("id NOT IN (?)", parentsOfBlocked)
+
+	q = whereRule(q, rule, false)
+	for _, e := range except {
+		q = whereRule(q, e, true)
+	}
+
+	err = q.
+		Order("updated_at asc").
+		Limit(limit).
+		Find(&jj).Error
+	return
+}
+
+func whereRule(q *gorm.DB, r Rule, not bool) *gorm.DB {
+	switch {
+	case r.JobType != "" && r.State != "" && not:
+		return q.Where("NOT (type = ? AND state = ?)", r.JobType, r.State)
+	case r.JobType != "" && r.State != "":
+		return q.Where("type = ? AND state = ?", r.JobType, r.State)
+	case r.JobType != "" && not:
+		return q.Where("type <> ?", r.JobType)
+	case r.JobType != "":
+		return q.Where("type = ?", r.JobType)
+	case r.State != "" && not:
+		return q.Where("state <> ?", r.State)
+	case r.State != "":
+		return q.Where("state = ?", r.State)
+	}
+	return q
+}
+
+func (s *GormStore) SoftDeleteJobs(ids []uuid.UUID) error {
+	return s.db.Where("id IN ?", ids).Delete(&jobs.Job{}).Error
+}
+
+func (s *GormStore) ArchiveJobs(jj []jobs.Job, now time.Time) error {
+	if len(jj) == 0 {
+		return nil
+	}
+
+	archived := make([]ArchivedJob, len(jj))
+	ids := make([]uuid.UUID, len(jj))
+	for i, j := range jj {
+		archived[i] = newArchivedJob(j, now)
+		ids[i] = j.ID
+	}
+
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		if err := tx.Create(&archived).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("job_id IN ? OR parent_job_id IN ?", ids, ids).Delete(&jobs.JobDependency{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&jobs.Job{}).Error
+	})
+}
+
+// The ids are selected first, MySQL does not support a LIMIT in a subquery
+// of the table being deleted from
+func (s *GormStore) DeleteStreamEvents(before time.Time, limit int) (int64, error) {
+	var ids []uint64
+	err := s.db.Model(&events.Event{}).
+		Where("created_at < ?", before).
+		Order("id asc").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	res := s.db.Where("id IN ?", ids).Delete(&events.Event{})
+	return res.RowsAffected, res.Error
+}
+
+func (s *GormStore) PendingPruneJobs(exceptID uuid.UUID, accepted bool) (count int64, err error) {
+	states := []jobs.State{jobs.Init, jobs.NoAvailableWorkers, jobs.Error}
+	if accepted {
+		states = append(states, jobs.Accepted)
+	}
+
+	err = s.db.
+		Model(&jobs.Job{}).
+		Where("type = ? AND state IN ? AND id <> ?", PruneJobType, states, exceptID).
+		Count(&count).Error
+	return
+}
@@ -0,0 +1,13 @@
+package schedules
+
+import (
+	"github.com/numeroai/flow-wallet-api/system"
+)
+
+type RunnerOption func(*RunnerImpl)
+
+func WithSystemService(svc system.Service) RunnerOption {
+	return func(runner *RunnerImpl) {
+		runner.systemService = svc
+	}
+}
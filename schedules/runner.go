@@ -0,0 +1,103 @@
+package schedules
+
+import (
+	"context"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/system"
+	log "github.com/sirupsen/logrus"
+)
+
+// Runner periodically creates the withdrawals of due transfer schedules.
+type Runner interface {
+	Start() Runner
+	Stop()
+}
+
+type RunnerImpl struct {
+	ticker   *time.Ticker
+	stopChan chan struct{}
+	service  Service
+	interval time.Duration
+
+	systemService system.Service
+}
+
+func NewRunner(service Service, interval time.Duration, opts ...RunnerOption) Runner {
+	runner := &RunnerImpl{
+		stopChan: make(chan struct{}),
+		service:  service,
+		interval: interval,
+	}
+
+	// Go through options
+	for _, opt := range opts {
+		opt(runner)
+	}
+
+	return runner
+}
+
+func (r *RunnerImpl) Start() Runner {
+	if r.ticker != nil {
+		// Already started
+		return r
+	}
+
+	r.ticker = time.NewTicker(r.interval)
+
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		entry := log.WithFields(log.Fields{
+			"package":  "schedules",
+			"function": "Runner.Start.goroutine",
+		})
+
+		for {
+			select {
+			case <-r.stopChan:
+				return
+			case <-r.ticker.C:
+				// Check for maintenance mode
+				if halted, err := r.systemHalted(); err != nil {
+					entry.
+						WithFields(log.Fields{"error": err}).
+						Warn("Could not get system settings from DB")
+					continue
+				} else if halted {
+					entry.Debug("System halted")
+					continue
+				}
+
+				if err := r.service.RunDue(ctx); err != nil {
+					entry.
+						WithFields(log.Fields{"error": err}).
+						Warn("Error while running transfer schedules")
+				}
+			}
+		}
+	}()
+
+	log.Debug("Started transfer schedule runner")
+
+	return r
+}
+
+func (r *RunnerImpl) Stop() {
+	log.Debug("Stopping transfer schedule runner")
+
+	close(r.stopChan)
+
+	if r.ticker != nil {
+		r.ticker.Stop()
+	}
+}
+
+func (r *RunnerImpl) systemHalted() (bool, error) {
+	if r.systemService != nil {
+		return r.systemService.IsHalted()
+	}
+	return false, nil
+}
@@ -0,0 +1,170 @@
+// Package schedules provides recurring transfer schedules which withdraw
+// tokens from a custodial account on a cron schedule.
+package schedules
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// State is a type for Schedule state.
+type State string
+
+const (
+	Active State = "ACTIVE"
+	Paused State = "PAUSED"
+	Ended  State = "ENDED"
+)
+
+// Schedule database model
+type Schedule struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Sender    string         `gorm:"column:sender;index"`
+	TokenName string         `gorm:"column:token_name"`
+	Recipient string         `gorm:"column:recipient"`
+	FtAmount  string         `gorm:"column:ft_amount"`
+	NftID     uint64         `gorm:"column:nft_id"`
+	Cron      string         `gorm:"column:cron"`
+	StartAt   time.Time      `gorm:"column:start_at"`
+	EndAt     sql.NullTime   `gorm:"column:end_at"`
+	State     State          `gorm:"column:state;index:idx_transfer_schedules_state_next_run_at"`
+	NextRunAt sql.NullTime   `gorm:"column:next_run_at;index:idx_transfer_schedules_state_next_run_at"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Schedule) TableName() string {
+	return "transfer_schedules"
+}
+
+func (s *Schedule) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// advance sets NextRunAt to the first occurrence after 'after', never before
+// the start date. The schedule ends if there are no occurrences left before
+// its end date.
+func (s *Schedule) advance(after time.Time) error {
+	spec, err := cron.ParseStandard(s.Cron)
+	if err != nil {
+		return err
+	}
+
+	if start := s.StartAt.Add(-time.Second); after.Before(start) {
+		after = start
+	}
+
+	next := spec.Next(after.UTC())
+	if next.IsZero() || (s.EndAt.Valid && next.After(s.EndAt.Time)) {
+		s.State = Ended
+		s.NextRunAt = sql.NullTime{}
+		return nil
+	}
+
+	s.NextRunAt = sql.NullTime{Time: next, Valid: true}
+
+	return nil
+}
+
+// Run records an occurrence of a schedule and the withdrawal job it produced.
+// A run without a job and without an error is pending, its withdrawal is
+// being created.
+type Run struct {
+	ID           uuid.UUID     `gorm:"column:id;primary_key;type:uuid;"`
+	ScheduleID   uuid.UUID     `gorm:"column:schedule_id;type:uuid;index"`
+	ScheduledFor time.Time     `gorm:"column:scheduled_for"`
+	JobID        uuid.NullUUID `gorm:"column:job_id;type:uuid"`
+	Error        string        `gorm:"column:error"`
+	CreatedAt    time.Time     `gorm:"column:created_at"`
+}
+
+func (Run) TableName() string {
+	return "transfer_schedule_runs"
+}
+
+func (r *Run) BeforeCreate(tx *gorm.DB) (err error) {
+	r.ID = uuid.New()
+	return nil
+}
+
+// Schedule HTTP request
+type JSONRequest struct {
+	Sender    string     `json:"sender"`
+	TokenName string     `json:"tokenName"`
+	Recipient string     `json:"recipient"`
+	FtAmount  string     `json:"amount,omitempty"`
+	NftID     uint64     `json:"nftId,omitempty"`
+	Cron      string     `json:"cron"`
+	StartAt   *time.Time `json:"startAt,omitempty"`
+	EndAt     *time.Time `json:"endAt,omitempty"`
+}
+
+// Schedule HTTP response
+type JSONResponse struct {
+	ID        uuid.UUID  `json:"id"`
+	Sender    string     `json:"sender"`
+	TokenName string     `json:"tokenName"`
+	Recipient string     `json:"recipient"`
+	FtAmount  string     `json:"amount,omitempty"`
+	NftID     uint64     `json:"nftId,omitempty"`
+	Cron      string     `json:"cron"`
+	StartAt   time.Time  `json:"startAt"`
+	EndAt     *time.Time `json:"endAt,omitempty"`
+	State     State      `json:"state"`
+	NextRunAt *time.Time `json:"nextRunAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+func (s Schedule) ToJSONResponse() JSONResponse {
+	return JSONResponse{
+		ID:        s.ID,
+		Sender:    s.Sender,
+		TokenName: s.TokenName,
+		Recipient: s.Recipient,
+		FtAmount:  s.FtAmount,
+		NftID:     s.NftID,
+		Cron:      s.Cron,
+		StartAt:   s.StartAt,
+		EndAt:     nullTime(s.EndAt),
+		State:     s.State,
+		NextRunAt: nullTime(s.NextRunAt),
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// Run HTTP response
+type RunJSONResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	ScheduledFor time.Time  `json:"scheduledFor"`
+	JobID        *uuid.UUID `json:"jobId,omitempty"`
+	Error        string     `json:"error,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+func (r Run) ToJSONResponse() RunJSONResponse {
+	res := RunJSONResponse{
+		ID:           r.ID,
+		ScheduledFor: r.ScheduledFor,
+		Error:        r.Error,
+		CreatedAt:    r.CreatedAt,
+	}
+	if r.JobID.Valid {
+		res.JobID = &r.JobID.UUID
+	}
+	return res
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,326 @@
+package schedules
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/configs"
+	"github.com/numeroai/flow-wallet-api/datastore"
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	"github.com/robfig/cron/v3"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// pendingRunTimeout is how long a run may be pending before it is
+	// considered interrupted
+	pendingRunTimeout = 10 * time.Minute
+	errRunInterrupted = "interrupted before the withdrawal was recorded, check the withdrawal jobs of the sender"
+)
+
+type Service interface {
+	Create(req JSONRequest) (*Schedule, error)
+	List(limit, offset int) ([]Schedule, error)
+	Details(id string) (*Schedule, error)
+	// Pause stops creating withdrawals until the schedule is resumed.
+	Pause(id string) (*Schedule, error)
+	// Resume continues a paused schedule from its next occurrence, missed
+	// occurrences are skipped.
+	Resume(id string) (*Schedule, error)
+	Delete(id string) error
+	Runs(id string, limit, offset int) ([]Run, error)
+	// RunDue creates a withdrawal for every schedule whose next occurrence
+	// has passed. Occurrences missed while the wallet was down are coalesced
+	// into a single withdrawal.
+	//
+	// Withdrawals are created at most once per occurrence. The occurrence is
+	// recorded as a pending run when it is claimed, and the run is finished
+	// with the withdrawal job or the error of creating it. Runs left pending
+	// by an instance which stopped in between are not retried, as their
+	// withdrawal may have been created, they fail after pendingRunTimeout.
+	RunDue(ctx context.Context) error
+}
+
+type ServiceImpl struct {
+	cfg    *configs.Config
+	store  Store
+	tokens tokens.Service
+}
+
+func NewService(cfg *configs.Config, store Store, tokens tokens.Service) Service {
+	return &ServiceImpl{cfg, store, tokens}
+}
+
+func (s *ServiceImpl) Create(req JSONRequest) (*Schedule, error) {
+	log.WithFields(log.Fields{"sender": req.Sender, "cron": req.Cron}).Trace("Create transfer schedule")
+
+	sender, err := flow_helpers.ValidateAddress(req.Sender, s.cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	recipient, err := flow_helpers.ValidateAddress(req.Recipient, s.cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.TokenName == "" {
+		return nil, badRequest("tokenName is required")
+	}
+
+	if req.FtAmount == "" && req.NftID == 0 {
+		return nil, badRequest("amount or nftId is required")
+	}
+
+	if _, err := cron.ParseStandard(req.Cron); err != nil {
+		return nil, badRequest(fmt.Sprintf("invalid cron expression: %s", err))
+	}
+
+	now := time.Now()
+
+	sch := &Schedule{
+		Sender:    sender,
+		TokenName: req.TokenName,
+		Recipient: recipient,
+		FtAmount:  req.FtAmount,
+		NftID:     req.NftID,
+		Cron:      req.Cron,
+		StartAt:   now,
+		State:     Active,
+	}
+
+	if req.StartAt != nil {
+		sch.StartAt = *req.StartAt
+	}
+
+	if req.EndAt != nil {
+		if !req.EndAt.After(sch.StartAt) {
+			return nil, badRequest("endAt must be after startAt")
+		}
+		sch.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+
+	if err := sch.advance(now); err != nil {
+		return nil, err
+	}
+
+	if sch.State == Ended {
+		return nil, badRequest("schedule has no occurrences before endAt")
+	}
+
+	if err := s.store.InsertSchedule(sch); err != nil {
+		return nil, err
+	}
+
+	return sch, nil
+}
+
+func (s *ServiceImpl) List(limit, offset int) ([]Schedule, error) {
+	o := datastore.ParseListOptions(limit, offset)
+	return s.store.Schedules(o)
+}
+
+func (s *ServiceImpl) Details(id string) (*Schedule, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := s.store.Schedule(uid)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &sch, nil
+}
+
+func (s *ServiceImpl) Pause(id string) (*Schedule, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Pause transfer schedule")
+
+	return s.setState(id, func(sch *Schedule) error {
+		switch sch.State {
+		case Active:
+			sch.State = Paused
+		case Ended:
+			return conflict("schedule has ended")
+		}
+		return nil
+	})
+}
+
+func (s *ServiceImpl) Resume(id string) (*Schedule, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Resume transfer schedule")
+
+	return s.setState(id, func(sch *Schedule) error {
+		switch sch.State {
+		case Paused:
+			sch.State = Active
+			return sch.advance(time.Now())
+		case Ended:
+			return conflict("schedule has ended")
+		}
+		return nil
+	})
+}
+
+func (s *ServiceImpl) setState(id string, fn func(*Schedule) error) (*Schedule, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var sch Schedule
+
+	err = s.store.LockedSchedule(uid, func(locked *Schedule) error {
+		if err := fn(locked); err != nil {
+			return err
+		}
+		sch = *locked
+		return nil
+	})
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &sch, nil
+}
+
+func (s *ServiceImpl) Delete(id string) error {
+	log.WithFields(log.Fields{"id": id}).Trace("Delete transfer schedule")
+
+	sch, err := s.Details(id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.DeleteSchedule(sch.ID)
+}
+
+func (s *ServiceImpl) Runs(id string, limit, offset int) ([]Run, error) {
+	sch, err := s.Details(id)
+	if err != nil {
+		return nil, err
+	}
+
+	o := datastore.ParseListOptions(limit, offset)
+
+	return s.store.Runs(sch.ID, o)
+}
+
+func (s *ServiceImpl) RunDue(ctx context.Context) error {
+	now := time.Now()
+
+	if n, err := s.store.FailPendingRuns(now.Add(-pendingRunTimeout), errRunInterrupted); err != nil {
+		log.
+			WithFields(log.Fields{"error": err}).
+			Warn("Could not fail pending transfer schedule runs")
+	} else if n > 0 {
+		log.
+			WithFields(log.Fields{"count": n}).
+			Warn("Failed interrupted transfer schedule runs")
+	}
+
+	due, err := s.store.DueSchedules(now, datastore.ParseListOptions(0, 0))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range due {
+		var (
+			sch Schedule
+			run *Run
+		)
+
+		// Claim the occurrence, record it and advance the schedule in one
+		// transaction so that concurrent instances never produce the same
+		// occurrence twice and an interrupted occurrence is not lost
+		err := s.store.LockedScheduleRun(d.ID, func(locked *Schedule) (*Run, error) {
+			if locked.State != Active || !locked.NextRunAt.Valid || locked.NextRunAt.Time.After(now) {
+				return nil, nil
+			}
+			sch = *locked
+			run = &Run{
+				ScheduleID:   locked.ID,
+				ScheduledFor: locked.NextRunAt.Time,
+			}
+			return run, locked.advance(now)
+		})
+
+		if err != nil {
+			log.
+				WithFields(log.Fields{"error": err, "scheduleID": d.ID}).
+				Warn("Could not advance transfer schedule")
+			continue
+		}
+
+		if run != nil {
+			s.createWithdrawal(ctx, sch, run)
+		}
+	}
+
+	return nil
+}
+
+// createWithdrawal creates the withdrawal of a pending run and finishes the
+// run with its job or error
+func (s *ServiceImpl) createWithdrawal(ctx context.Context, sch Schedule, run *Run) {
+	job, _, err := s.tokens.CreateWithdrawal(ctx, false, sch.Sender, tokens.WithdrawalRequest{
+		TokenName: sch.TokenName,
+		Recipient: sch.Recipient,
+		FtAmount:  sch.FtAmount,
+		NftID:     sch.NftID,
+	})
+
+	if err != nil {
+		log.
+			WithFields(log.Fields{"error": err, "scheduleID": sch.ID}).
+			Warn("Could not create scheduled withdrawal")
+		run.Error = err.Error()
+	} else {
+		run.JobID = uuid.NullUUID{UUID: job.ID, Valid: true}
+	}
+
+	if err := s.store.UpdateRun(run); err != nil {
+		log.
+			WithFields(log.Fields{"error": err, "scheduleID": sch.ID}).
+			Warn("Could not record transfer schedule run")
+	}
+}
+
+func badRequest(msg string) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("%s", msg),
+	}
+}
+
+func conflict(msg string) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusConflict,
+		Err:        fmt.Errorf("%s", msg),
+	}
+}
+
+func parseID(id string) (uuid.UUID, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return uid, badRequest("invalid transfer schedule id")
+	}
+	return uid, nil
+}
+
+func notFound(err error) error {
+	if err.Error() == "record not found" {
+		return &errors.RequestError{
+			StatusCode: http.StatusNotFound,
+			Err:        fmt.Errorf("transfer schedule not found"),
+		}
+	}
+	return err
+}
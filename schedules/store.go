@@ -0,0 +1,31 @@
+package schedules
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/datastore"
+)
+
+// Store manages data regarding transfer schedules.
+type Store interface {
+	Schedules(o datastore.ListOptions) ([]Schedule, error)
+	Schedule(id uuid.UUID) (Schedule, error)
+	InsertSchedule(*Schedule) error
+	UpdateSchedule(*Schedule) error
+	DeleteSchedule(id uuid.UUID) error
+	// DueSchedules lists active schedules whose next occurrence is at or before now.
+	DueSchedules(now time.Time, o datastore.ListOptions) ([]Schedule, error)
+	// LockedSchedule runs fn with the schedule locked for update and saves
+	// the schedule afterwards.
+	LockedSchedule(id uuid.UUID, fn func(*Schedule) error) error
+	// LockedScheduleRun is like LockedSchedule, the run returned by fn is
+	// inserted in the same transaction.
+	LockedScheduleRun(id uuid.UUID, fn func(*Schedule) (*Run, error)) error
+	InsertRun(*Run) error
+	UpdateRun(*Run) error
+	// FailPendingRuns records msg as the error of runs created before
+	// 'before' which have neither a withdrawal job nor an error.
+	FailPendingRuns(before time.Time, msg string) (int64, error)
+	Runs(scheduleID uuid.UUID, o datastore.ListOptions) ([]Run, error)
+}
@@ -0,0 +1,115 @@
+package schedules
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/datastore"
+	"github.com/numeroai/flow-wallet-api/datastore/lib"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db}
+}
+
+func (s *GormStore) Schedules(o datastore.ListOptions) (ss []Schedule, err error) {
+	err = s.db.
+		Order("created_at desc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&ss).Error
+	return
+}
+
+func (s *GormStore) Schedule(id uuid.UUID) (sch Schedule, err error) {
+	err = s.db.First(&sch, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) InsertSchedule(sch *Schedule) error {
+	return s.db.Create(sch).Error
+}
+
+func (s *GormStore) UpdateSchedule(sch *Schedule) error {
+	return s.db.Save(sch).Error
+}
+
+func (s *GormStore) DeleteSchedule(id uuid.UUID) error {
+	return s.db.Delete(&Schedule{}, "id = ?", id).Error
+}
+
+func (s *GormStore) DueSchedules(now time.Time, o datastore.ListOptions) (ss []Schedule, err error) {
+	err = s.db.
+		Where("state = ? AND next_run_at <= ?", Active, now).
+		Order("next_run_at asc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&ss).Error
+	return
+}
+
+func (s *GormStore) LockedSchedule(id uuid.UUID, fn func(*Schedule) error) error {
+	return s.LockedScheduleRun(id, func(sch *Schedule) (*Run, error) {
+		return nil, fn(sch)
+	})
+}
+
+func (s *GormStore) LockedScheduleRun(id uuid.UUID, fn func(*Schedule) (*Run, error)) error {
+	return lib.GormTransaction(s.db, func(tx *gorm.DB) error {
+		sch := Schedule{}
+
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&sch, "id = ?", id).Error; err != nil {
+			return err // rollback
+		}
+
+		run, err := fn(&sch)
+		if err != nil {
+			return err // rollback
+		}
+
+		if err := tx.Save(&sch).Error; err != nil {
+			return err // rollback
+		}
+
+		if run != nil {
+			if err := tx.Create(run).Error; err != nil {
+				return err // rollback
+			}
+		}
+
+		return nil // commit
+	})
+}
+
+func (s *GormStore) InsertRun(r *Run) error {
+	return s.db.Create(r).Error
+}
+
+func (s *GormStore) UpdateRun(r *Run) error {
+	return s.db.Save(r).Error
+}
+
+func (s *GormStore) FailPendingRuns(before time.Time, msg string) (int64, error) {
+	res := s.db.Model(&Run{}).
+		Where("job_id IS NULL AND (error IS NULL OR error = '') AND created_at < ?", before).
+		Update("error", msg)
+	return res.RowsAffected, res.Error
+}
+
+func (s *GormStore) Runs(scheduleID uuid.UUID, o datastore.ListOptions) (rr []Run, err error) {
+	err = s.db.
+		Where("schedule_id = ?", scheduleID).
+		Order("scheduled_for desc").
+		Limit(o.Limit).
+		Offset(o.Offset).
+		Find(&rr).Error
+	return
+}
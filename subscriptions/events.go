@@ -0,0 +1,87 @@
+package subscriptions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/accounts"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/templates"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	log "github.com/sirupsen/logrus"
+)
+
+type accountCreatedData struct {
+	Address string `json:"address"`
+}
+
+type depositRegisteredData struct {
+	TransactionId    string              `json:"transactionId"`
+	RecipientAddress string              `json:"recipientAddress"`
+	SenderAddress    string              `json:"senderAddress"`
+	TokenName        string              `json:"tokenName"`
+	TokenType        templates.TokenType `json:"tokenType"`
+	FtAmount         string              `json:"amount,omitempty"`
+	NftID            uint64              `json:"nftId,omitempty"`
+}
+
+func newEvent(kind EventKind, data interface{}) Event {
+	return Event{
+		ID:        uuid.New(),
+		Kind:      kind,
+		CreatedAt: time.Now(),
+		Data:      data,
+	}
+}
+
+func NewJobFinishedEvent(job jobs.Job) Event {
+	e := newEvent(EventJobFinished, job.ToJSONResponse())
+	e.jobID = job.ID.String()
+	e.jobType = job.Type
+	e.jobState = string(job.State)
+	return e
+}
+
+func NewAccountCreatedEvent(address string) Event {
+	return newEvent(EventAccountCreated, accountCreatedData{Address: address})
+}
+
+func NewDepositRegisteredEvent(p tokens.DepositRegisteredPayload) Event {
+	return newEvent(EventDepositRegistered, depositRegisteredData(p))
+}
+
+// JobFinishedHandler publishes finished jobs to subscriptions.
+type JobFinishedHandler struct {
+	Service Service
+}
+
+func (h *JobFinishedHandler) Handle(payload jobs.JobFinishedPayload) {
+	publish(h.Service, NewJobFinishedEvent(payload.Job))
+}
+
+// AccountAddedHandler publishes created accounts to subscriptions.
+type AccountAddedHandler struct {
+	Service Service
+}
+
+func (h *AccountAddedHandler) Handle(payload accounts.AccountAddedPayload) {
+	publish(h.Service, NewAccountCreatedEvent(flow_helpers.FormatAddress(payload.Address)))
+}
+
+// DepositRegisteredHandler publishes registered deposits to subscriptions.
+type DepositRegisteredHandler struct {
+	Service Service
+}
+
+func (h *DepositRegisteredHandler) Handle(payload tokens.DepositRegisteredPayload) {
+	publish(h.Service, NewDepositRegisteredEvent(payload))
+}
+
+func publish(svc Service, e Event) {
+	if err := svc.Publish(e); err != nil {
+		log.
+			WithFields(log.Fields{"error": err, "event": e.Kind}).
+			Warn("Error while publishing event to webhook subscriptions")
+	}
+}
@@ -0,0 +1,49 @@
+package subscriptions
+
+import (
+	"net/url"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/keys/encryption"
+)
+
+// TargetResolver resolves the delivery target of webhook notification jobs
+// from the subscription they were scheduled for. The secret of the
+// subscription is decrypted with crypter.
+type TargetResolver struct {
+	store   Store
+	crypter encryption.Crypter
+}
+
+func NewTargetResolver(store Store, crypter encryption.Crypter) *TargetResolver {
+	return &TargetResolver{store, crypter}
+}
+
+func (r *TargetResolver) WebhookTarget(subscriptionID string) (*jobs.WebhookTarget, error) {
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := r.store.Subscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.ParseRequestURI(sub.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := r.crypter.Decrypt(sub.Secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jobs.WebhookTarget{
+		URL:     u,
+		Secret:  secret,
+		Timeout: sub.Timeout,
+	}, nil
+}
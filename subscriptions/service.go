@@ -0,0 +1,281 @@
+package subscriptions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/keys/encryption"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTimeout    = 30 * time.Second
+	secretRandomBytes = 32
+)
+
+type Service interface {
+	// Create adds a new subscription. If the request has no secret, one is
+	// generated and included in the returned response.
+	Create(req JSONRequest) (*JSONResponse, error)
+	List() ([]Subscription, error)
+	Details(id string) (*Subscription, error)
+	Update(id string, req JSONRequest) (*Subscription, error)
+	Delete(id string) error
+	// Publish schedules the delivery of an event to all matching subscriptions.
+	Publish(e Event) error
+	// EncryptPlaintextSecrets encrypts the secrets stored before secrets were
+	// encrypted.
+	EncryptPlaintextSecrets() error
+}
+
+type ServiceImpl struct {
+	store   Store
+	wp      jobs.WorkerPool
+	crypter encryption.Crypter
+}
+
+// NewService returns a subscription service which encrypts secrets with
+// crypter.
+func NewService(store Store, wp jobs.WorkerPool, crypter encryption.Crypter) Service {
+	return &ServiceImpl{store, wp, crypter}
+}
+
+func (s *ServiceImpl) Create(req JSONRequest) (*JSONResponse, error) {
+	log.WithFields(log.Fields{"url": req.URL, "events": req.Events}).Trace("Create webhook subscription")
+
+	sub := &Subscription{}
+	if err := applyRequest(sub, req); err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	generated := false
+	if secret == "" {
+		var err error
+		secret, err = generateSecret()
+		if err != nil {
+			return nil, err
+		}
+		generated = true
+	}
+
+	if err := s.setSecret(sub, secret); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.InsertSubscription(sub); err != nil {
+		return nil, err
+	}
+
+	res := sub.ToJSONResponse()
+	if generated {
+		res.Secret = secret
+	}
+
+	return &res, nil
+}
+
+func (s *ServiceImpl) List() ([]Subscription, error) {
+	return s.store.Subscriptions()
+}
+
+func (s *ServiceImpl) Details(id string) (*Subscription, error) {
+	uid, err := parseID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, err := s.store.Subscription(uid)
+	if err != nil {
+		return nil, notFound(err)
+	}
+
+	return &sub, nil
+}
+
+// Update replaces the subscription with the request. The existing secret
+// is kept if the request has no secret.
+func (s *ServiceImpl) Update(id string, req JSONRequest) (*Subscription, error) {
+	log.WithFields(log.Fields{"id": id}).Trace("Update webhook subscription")
+
+	sub, err := s.Details(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyRequest(sub, req); err != nil {
+		return nil, err
+	}
+
+	if req.Secret != "" {
+		if err := s.setSecret(sub, req.Secret); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.store.UpdateSubscription(sub); err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (s *ServiceImpl) Delete(id string) error {
+	log.WithFields(log.Fields{"id": id}).Trace("Delete webhook subscription")
+
+	sub, err := s.Details(id)
+	if err != nil {
+		return err
+	}
+
+	return s.store.DeleteSubscription(sub.ID)
+}
+
+func (s *ServiceImpl) Publish(e Event) error {
+	subs, err := s.store.Subscriptions()
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+
+	for _, sub := range subs {
+		if !sub.Matches(e) {
+			continue
+		}
+
+		// Only marshal the event if someone is listening
+		if b == nil {
+			if b, err = json.Marshal(e); err != nil {
+				return err
+			}
+		}
+
+		if err := s.wp.ScheduleWebhookNotification(sub.ID.String(), string(e.Kind), e.jobID, string(b)); err != nil {
+			log.
+				WithFields(log.Fields{"error": err, "subscriptionID": sub.ID, "event": e.Kind}).
+				Warn("Could not schedule webhook notification")
+		}
+	}
+
+	return nil
+}
+
+func applyRequest(sub *Subscription, req JSONRequest) error {
+	u, err := url.ParseRequestURI(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid url"),
+		}
+	}
+
+	timeout := defaultTimeout
+	if req.Timeout != "" {
+		timeout, err = time.ParseDuration(req.Timeout)
+		if err != nil || timeout <= 0 {
+			return &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid timeout"),
+			}
+		}
+	}
+
+	events := make([]string, len(req.Events))
+	for i, k := range req.Events {
+		if !k.IsValid() {
+			return &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("unknown event: %s", k),
+			}
+		}
+		events[i] = string(k)
+	}
+
+	for _, state := range req.JobStates {
+		if !(jobs.Job{State: jobs.State(state)}).IsFinished() {
+			return &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid job state filter, jobs finish in state %s, %s, %s or %s", jobs.Complete, jobs.Failed, jobs.Cancelled, jobs.Skipped),
+			}
+		}
+	}
+
+	sub.URL = u.String()
+	sub.Timeout = timeout
+	sub.EventKinds = events
+	sub.JobTypes = req.JobTypes
+	sub.JobStates = req.JobStates
+
+	return nil
+}
+
+// setSecret stores the secret encrypted, like the private keys of accounts
+func (s *ServiceImpl) setSecret(sub *Subscription, secret string) error {
+	encrypted, err := s.crypter.Encrypt([]byte(secret))
+	if err != nil {
+		return err
+	}
+	sub.Secret = encrypted
+	return nil
+}
+
+func (s *ServiceImpl) EncryptPlaintextSecrets() error {
+	secrets, err := s.store.PlaintextSecrets()
+	if err != nil {
+		return err
+	}
+
+	for id, secret := range secrets {
+		encrypted, err := s.crypter.Encrypt([]byte(secret))
+		if err != nil {
+			return err
+		}
+		if err := s.store.ReplacePlaintextSecret(id, encrypted); err != nil {
+			return err
+		}
+	}
+
+	if len(secrets) > 0 {
+		log.WithFields(log.Fields{"count": len(secrets)}).Info("Encrypted webhook subscription secrets")
+	}
+
+	return nil
+}
+
+func parseID(id string) (uuid.UUID, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return uid, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid subscription id"),
+		}
+	}
+	return uid, nil
+}
+
+func notFound(err error) error {
+	if err.Error() == "record not found" {
+		return &errors.RequestError{
+			StatusCode: http.StatusNotFound,
+			Err:        fmt.Errorf("subscription not found"),
+		}
+	}
+	return err
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, secretRandomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
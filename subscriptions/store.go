@@ -0,0 +1,19 @@
+package subscriptions
+
+import (
+	"github.com/google/uuid"
+)
+
+// Store manages data regarding webhook subscriptions.
+type Store interface {
+	Subscriptions() ([]Subscription, error)
+	Subscription(id uuid.UUID) (Subscription, error)
+	InsertSubscription(*Subscription) error
+	UpdateSubscription(*Subscription) error
+	DeleteSubscription(id uuid.UUID) error
+	// PlaintextSecrets returns the secrets stored before they were encrypted.
+	PlaintextSecrets() (map[uuid.UUID]string, error)
+	// ReplacePlaintextSecret stores the encrypted secret of the subscription
+	// and removes its plaintext secret.
+	ReplacePlaintextSecret(id uuid.UUID, encrypted []byte) error
+}
@@ -0,0 +1,59 @@
+package subscriptions
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db}
+}
+
+func (s *GormStore) Subscriptions() (ss []Subscription, err error) {
+	err = s.db.Order("created_at asc").Find(&ss).Error
+	return
+}
+
+func (s *GormStore) Subscription(id uuid.UUID) (sub Subscription, err error) {
+	err = s.db.First(&sub, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) InsertSubscription(sub *Subscription) error {
+	return s.db.Create(sub).Error
+}
+
+func (s *GormStore) UpdateSubscription(sub *Subscription) error {
+	return s.db.Save(sub).Error
+}
+
+func (s *GormStore) DeleteSubscription(id uuid.UUID) error {
+	return s.db.Delete(&Subscription{}, "id = ?", id).Error
+}
+
+// The plaintext secret column predates the encrypted secret and is not part
+// of the model
+func (s *GormStore) PlaintextSecrets() (map[uuid.UUID]string, error) {
+	var rows []struct {
+		ID     uuid.UUID
+		Secret string
+	}
+	err := s.db.Model(&Subscription{}).Unscoped().Select("id, secret").Where("secret <> ''").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make(map[uuid.UUID]string, len(rows))
+	for _, r := range rows {
+		secrets[r.ID] = r.Secret
+	}
+	return secrets, nil
+}
+
+func (s *GormStore) ReplacePlaintextSecret(id uuid.UUID, encrypted []byte) error {
+	return s.db.Exec("UPDATE webhook_subscriptions SET encrypted_secret = ?, secret = '' WHERE id = ?", encrypted, id).Error
+}
@@ -0,0 +1,148 @@
+// Package subscriptions provides webhook subscriptions which receive
+// notifications about events in the wallet.
+package subscriptions
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+// EventKind is a type for the kinds of events a subscription can receive.
+type EventKind string
+
+const (
+	EventJobFinished       EventKind = "job.finished"
+	EventDepositRegistered EventKind = "deposit.registered"
+	EventAccountCreated    EventKind = "account.created"
+)
+
+// EventKinds lists all known event kinds.
+var EventKinds = []EventKind{
+	EventJobFinished,
+	EventDepositRegistered,
+	EventAccountCreated,
+}
+
+func (k EventKind) IsValid() bool {
+	for _, known := range EventKinds {
+		if k == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscription database model. Empty filters match everything.
+// Secret is encrypted, it is only decrypted to sign deliveries.
+type Subscription struct {
+	ID         uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	URL        string         `gorm:"column:url"`
+	Secret     []byte         `gorm:"column:encrypted_secret"`
+	Timeout    time.Duration  `gorm:"column:timeout"`
+	EventKinds pq.StringArray `gorm:"column:event_kinds;type:text[]"`
+	JobTypes   pq.StringArray `gorm:"column:job_types;type:text[]"`
+	JobStates  pq.StringArray `gorm:"column:job_states;type:text[]"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+	UpdatedAt  time.Time      `gorm:"column:updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+func (s *Subscription) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// Matches returns true if the subscription should receive the event.
+// Job type and state filters only apply to job events.
+func (s Subscription) Matches(e Event) bool {
+	if !matchesFilter(s.EventKinds, string(e.Kind)) {
+		return false
+	}
+
+	if e.Kind == EventJobFinished {
+		if !matchesFilter(s.JobTypes, e.jobType) {
+			return false
+		}
+		if !matchesFilter(s.JobStates, e.jobState) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesFilter(filter []string, value string) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is the envelope delivered to subscriptions.
+type Event struct {
+	ID        uuid.UUID   `json:"id"`
+	Kind      EventKind   `json:"event"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+
+	// Filterable attributes of job events
+	jobID    string
+	jobType  string
+	jobState string
+}
+
+// Subscription HTTP request
+type JSONRequest struct {
+	URL       string      `json:"url"`
+	Secret    string      `json:"secret"`
+	Timeout   string      `json:"timeout"`
+	Events    []EventKind `json:"events"`
+	JobTypes  []string    `json:"jobTypes"`
+	JobStates []string    `json:"jobStates"`
+}
+
+// Subscription HTTP response, the secret is only included when it was
+// generated by the wallet on create.
+type JSONResponse struct {
+	ID        uuid.UUID `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Timeout   string    `json:"timeout"`
+	Events    []string  `json:"events"`
+	JobTypes  []string  `json:"jobTypes"`
+	JobStates []string  `json:"jobStates"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (s Subscription) ToJSONResponse() JSONResponse {
+	return JSONResponse{
+		ID:        s.ID,
+		URL:       s.URL,
+		Timeout:   s.Timeout.String(),
+		Events:    nonNil(s.EventKinds),
+		JobTypes:  nonNil(s.JobTypes),
+		JobStates: nonNil(s.JobStates),
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+func nonNil(ss []string) []string {
+	if ss == nil {
+		return []string{}
+	}
+	return ss
+}
@@ -80,7 +80,7 @@ func Test_Delete_Fails_On_Custodial_Account(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	svc := test.GetServices(t, cfg).GetAccounts()
 
-	_, a, err := svc.Create(context.Background(), true)
+	_, a, err := svc.Create(context.Background(), true, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -151,7 +151,7 @@ func Test_Add_Multiple_New_Custodial_Accounts(t *testing.T) {
 			svc := svcs[i%instanceCount].GetAccounts()
 			jobSvc := svcs[i%instanceCount].GetJobs()
 
-			job, _, err := svc.Create(context.Background(), false)
+			job, _, err := svc.Create(context.Background(), false, "")
 			if err != nil {
 				errChan <- err
 				return
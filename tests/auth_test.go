@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/auth"
+	"github.com/numeroai/flow-wallet-api/handlers"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestAuthService(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	svc := auth.NewService(auth.NewGormStore(db), auth.WithAdminKey("admin-key"))
+
+	t.Run("admin key has admin scope", func(t *testing.T) {
+		k, err := svc.Authenticate("admin-key")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !k.HasScope(auth.ScopeAccountsWrite) {
+			t.Fatal("expected admin key to have every scope")
+		}
+	})
+
+	t.Run("create requires valid scopes", func(t *testing.T) {
+		if _, _, err := svc.CreateKey("test", nil); err == nil {
+			t.Fatal("expected an error without scopes")
+		}
+		if _, _, err := svc.CreateKey("test", []auth.Scope{"accounts:delete"}); err == nil {
+			t.Fatal("expected an error with an unknown scope")
+		}
+	})
+
+	t.Run("create, authenticate and revoke", func(t *testing.T) {
+		k, key, err := svc.CreateKey("test", []auth.Scope{auth.ScopeAccountsRead})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if k.Hash == key {
+			t.Fatal("expected plain text key not to be stored")
+		}
+
+		authenticated, err := svc.Authenticate(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if authenticated.ID != k.ID {
+			t.Fatalf("expected key %s, got %s", k.ID, authenticated.ID)
+		}
+		if authenticated.HasScope(auth.ScopeAccountsWrite) {
+			t.Fatal("expected key not to have accounts:write scope")
+		}
+
+		if _, err := svc.RevokeKey(k.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := svc.Authenticate(key); err != auth.ErrInvalidAPIKey {
+			t.Fatalf("expected %s, got %v", auth.ErrInvalidAPIKey, err)
+		}
+	})
+
+	t.Run("unknown key is rejected", func(t *testing.T) {
+		if _, err := svc.Authenticate("fwa_unknown"); err != auth.ErrInvalidAPIKey {
+			t.Fatalf("expected %s, got %v", auth.ErrInvalidAPIKey, err)
+		}
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	svc := auth.NewService(auth.NewGormStore(db), auth.WithAdminKey("admin-key"))
+
+	_, readKey, err := svc.CreateKey("reader", []auth.Scope{auth.ScopeAccountsRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dummy endpoint for testing
+	testHandler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	})
+
+	router := mux.NewRouter()
+	router.Handle("/read", handlers.RequireScope(auth.ScopeAccountsRead, testHandler)).Methods(http.MethodGet)
+	router.Handle("/write", handlers.RequireScope(auth.ScopeAccountsWrite, testHandler)).Methods(http.MethodGet)
+	router.Handle("/health", testHandler).Methods(http.MethodGet)
+
+	h := handlers.UseAuth(router, handlers.AuthHandlerOptions{
+		IgnorePaths: []string{"/health"},
+	}, svc)
+
+	var steps = []struct {
+		name           string
+		path           string
+		key            string
+		expectedStatus int
+	}{
+		{"ignored path", "/health", "", http.StatusOK},
+		{"missing key", "/read", "", http.StatusUnauthorized},
+		{"invalid key", "/read", "fwa_invalid", http.StatusUnauthorized},
+		{"scoped key", "/read", readKey, http.StatusOK},
+		{"missing scope", "/write", readKey, http.StatusForbidden},
+		{"admin key", "/write", "admin-key", http.StatusOK},
+	}
+
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.key != "" {
+				headers[handlers.APIKeyHeader] = "Bearer " + tt.key
+			}
+			res := sendWithHeaders(h, http.MethodGet, tt.path, nil, headers)
+			assertStatusCode(t, res, tt.expectedStatus)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestWebhookDeliveryLogAndReplay(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	// Fail the first delivery, accept the rest
+	var mu sync.Mutex
+	calls := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("downstream outage")) // nolint
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(svr.Close)
+
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithJobStatusWebhook(svr.URL, time.Second))
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	jobType := "job"
+	wp.RegisterExecutor(jobType, func(ctx context.Context, j *jobs.Job) error {
+		j.ShouldSendNotification = true
+		return nil
+	})
+
+	parent, err := wp.CreateJob(jobType, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := wp.Schedule(parent); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries := waitForDeliveries(t, jobSvc, parent.ID.String(), 1)
+
+	first := deliveries[0]
+	if first.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected status code %d, got %d", http.StatusServiceUnavailable, first.StatusCode)
+	}
+	if first.ResponseSnippet != "downstream outage" {
+		t.Fatalf("expected response snippet to be stored, got %q", first.ResponseSnippet)
+	}
+	if first.Attempt != 1 || first.URL != svr.URL || first.Error == "" {
+		t.Fatalf("unexpected delivery %+v", first)
+	}
+
+	replay, err := jobSvc.ReplayDelivery(parent.ID.String(), first.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := test.WaitForJob(jobSvc, replay.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	deliveries = waitForDeliveries(t, jobSvc, parent.ID.String(), 2)
+
+	latest := deliveries[0]
+	if latest.StatusCode != http.StatusOK || latest.Error != "" {
+		t.Fatalf("expected replayed delivery to succeed, got %+v", latest)
+	}
+	if latest.NotificationJobID != replay.ID {
+		t.Fatalf("expected replayed delivery to belong to job %s, got %s", replay.ID, latest.NotificationJobID)
+	}
+
+	if _, err := jobSvc.ReplayDelivery(replay.ID.String(), first.ID.String()); err == nil {
+		t.Fatal("expected an error when replaying a delivery of another job")
+	}
+}
+
+func waitForDeliveries(t *testing.T, jobSvc jobs.Service, jobID string, count int) []jobs.Delivery {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries, err := jobSvc.Deliveries(jobID, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*deliveries) >= count {
+			return *deliveries
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %d deliveries", count)
+	return nil
+}
@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/events"
+	"github.com/numeroai/flow-wallet-api/handlers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/tokens"
+)
+
+func TestEventStream(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := events.NewGormStore(db)
+	svc := events.NewService(store, events.WithPollInterval(time.Hour))
+
+	publish := func(t *testing.T) func(*events.Event, error) {
+		return func(e *events.Event, err error) {
+			t.Helper()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := svc.Publish(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	withdrawal := func(t *testing.T, sender, recipient, tokenName string) jobs.Job {
+		t.Helper()
+		attrs, err := json.Marshal(map[string]interface{}{
+			"Sender":  sender,
+			"Request": tokens.WithdrawalRequest{TokenName: tokenName, Recipient: recipient, FtAmount: "1.0"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return jobs.Job{Type: tokens.WithdrawalCreateJobType, State: jobs.Complete, Attributes: attrs}
+	}
+
+	next := func(t *testing.T, st *events.Stream) []events.Event {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		ee, err := st.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ee
+	}
+
+	t.Run("filters by address, job type and token name", func(t *testing.T) {
+		st, err := svc.Stream(events.StreamRequest{Addresses: []string{"1cf0e2f2f715450"}, TokenNames: []string{"FUSD"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+
+		publish(t)(events.NewJobUpdatedEvent(withdrawal(t, "0xf8d6e0586b0a20c7", "0x01cf0e2f2f715450", "FlowToken")))
+		publish(t)(events.NewAccountCreatedEvent("0x01cf0e2f2f715450"))
+		publish(t)(events.NewDepositRegisteredEvent(tokens.DepositRegisteredPayload{RecipientAddress: "0xf8d6e0586b0a20c7", TokenName: "FUSD"}))
+		publish(t)(events.NewJobUpdatedEvent(withdrawal(t, "0xf8d6e0586b0a20c7", "0x01cf0e2f2f715450", "FUSD")))
+
+		ee := next(t, st)
+		if len(ee) != 1 || ee[0].Kind != events.KindJobUpdated || ee[0].TokenName != "FUSD" {
+			t.Fatalf("expected only the FUSD withdrawal to the address, got %+v", ee)
+		}
+	})
+
+	t.Run("wakes up on publish and resumes from the last event id", func(t *testing.T) {
+		st, err := svc.Stream(events.StreamRequest{Kinds: []string{string(events.KindAccountCreated)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+
+		time.AfterFunc(100*time.Millisecond, func() {
+			e, _ := events.NewAccountCreatedEvent("0x01")
+			_ = svc.Publish(e)
+		})
+
+		first := next(t, st)[0]
+
+		publish(t)(events.NewAccountCreatedEvent("0x02"))
+		publish(t)(events.NewAccountCreatedEvent("0x03"))
+
+		resumed, err := svc.Stream(events.StreamRequest{
+			Kinds:       []string{string(events.KindAccountCreated)},
+			LastEventID: strconv.FormatUint(first.ID, 10),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resumed.Close()
+
+		ee := next(t, resumed)
+		if len(ee) != 2 || ee[0].Addresses[0] != "0x0000000000000002" || ee[1].Addresses[0] != "0x0000000000000003" {
+			t.Fatalf("expected the two events after the last event id, got %+v", ee)
+		}
+	})
+
+	t.Run("sees events published by other instances", func(t *testing.T) {
+		other := events.NewService(store, events.WithPollInterval(50*time.Millisecond))
+		st, err := other.Stream(events.StreamRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+
+		publish(t)(events.NewAccountCreatedEvent("0x04"))
+
+		if ee := next(t, st); len(ee) != 1 {
+			t.Fatalf("expected one event, got %+v", ee)
+		}
+	})
+
+	t.Run("holds back events after a missing id", func(t *testing.T) {
+		latest, err := store.LatestEventID()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		st, err := svc.Stream(events.StreamRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.Close()
+
+		// The event with id latest+1 is still being committed
+		if err := store.InsertEvent(&events.Event{ID: latest + 2, Kind: events.KindAccountCreated}); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		if ee, err := st.Next(ctx); err == nil {
+			t.Fatalf("expected the event to be held back, got %+v", ee)
+		}
+
+		if err := store.InsertEvent(&events.Event{ID: latest + 1, Kind: events.KindAccountCreated}); err != nil {
+			t.Fatal(err)
+		}
+
+		if ee := next(t, st); len(ee) != 2 || ee[0].ID != latest+1 || ee[1].ID != latest+2 {
+			t.Fatalf("expected both events in order, got %+v", ee)
+		}
+
+		// An event whose predecessor is missing for longer than the commit
+		// grace period is not held back
+		if err := store.InsertEvent(&events.Event{ID: latest + 4, Kind: events.KindAccountCreated, CreatedAt: time.Now().Add(-time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+
+		if ee := next(t, st); len(ee) != 1 || ee[0].ID != latest+4 {
+			t.Fatalf("expected the event after the gap, got %+v", ee)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		requests := []events.StreamRequest{
+			{Kinds: []string{"job.finished"}},
+			{Addresses: []string{"not-an-address"}},
+			{LastEventID: "abc"},
+		}
+		for _, req := range requests {
+			if _, err := svc.Stream(req); err == nil {
+				t.Fatalf("expected an error for %+v", req)
+			}
+		}
+	})
+
+	t.Run("server-sent events", func(t *testing.T) {
+		server := httptest.NewServer(handlers.NewEvents(svc, time.Hour).Stream())
+		defer server.Close()
+
+		latest, err := store.LatestEventID()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		publish(t)(events.NewAccountCreatedEvent("0x05"))
+
+		req, err := http.NewRequest(http.MethodGet, server.URL+"?event=account.created", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(handlers.LastEventIDHeader, strconv.FormatUint(latest, 10))
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+
+		if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("expected Content-Type text/event-stream, got %q", ct)
+		}
+
+		var lines []string
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() && scanner.Text() != "" {
+			lines = append(lines, scanner.Text())
+		}
+
+		if len(lines) != 3 ||
+			lines[0] != "id: "+strconv.FormatUint(latest+1, 10) ||
+			lines[1] != "event: account.created" ||
+			!strings.Contains(lines[2], `"address":"0x05"`) {
+			t.Fatalf("unexpected event %q", lines)
+		}
+	})
+}
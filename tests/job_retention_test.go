@@ -0,0 +1,363 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/events"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/retention"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"gorm.io/gorm"
+)
+
+func TestJobRetention(t *testing.T) {
+	cfg := test.LoadConfig(t)
+
+	setup := func(t *testing.T, rules []string, opts ...retention.ServiceOption) (*gorm.DB, jobs.WorkerPool, retention.Service) {
+		t.Helper()
+
+		db := test.GetDatabase(t, cfg)
+		// Not started, jobs stay in INIT
+		wp := jobs.NewWorkerPool(jobs.NewGormStore(db), 10, 1)
+		t.Cleanup(func() {
+			wp.Stop(false)
+		})
+
+		rr, err := retention.ParseRules(rules)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts = append(opts, retention.WithRules(rr))
+		return db, wp, retention.NewService(retention.NewGormStore(db), wp, opts...)
+	}
+
+	insert := func(t *testing.T, db *gorm.DB, wp jobs.WorkerPool, jobType string, state jobs.State, age time.Duration, opts ...jobs.JobOption) uuid.UUID {
+		t.Helper()
+		j, err := wp.CreateJob(jobType, "", opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Model(&jobs.Job{}).Where("id = ?", j.ID).UpdateColumns(map[string]interface{}{
+			"state":      state,
+			"updated_at": time.Now().Add(-age),
+		}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j.ID
+	}
+
+	exists := func(t *testing.T, db *gorm.DB, id uuid.UUID) (visible, stored bool) {
+		t.Helper()
+		var count int64
+		if err := db.Model(&jobs.Job{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		visible = count > 0
+		if err := db.Unscoped().Model(&jobs.Job{}).Where("id = ?", id).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		stored = count > 0
+		return
+	}
+
+	t.Run("invalid rules", func(t *testing.T) {
+		for _, v := range []string{"send_job_status:24h", "*:INIT:1h", "*:*:-1h", "*:*:forever"} {
+			if _, err := retention.ParseRules([]string{v}); err == nil {
+				t.Errorf("expected rule %q to be invalid", v)
+			}
+		}
+		if _, err := retention.ParseRules([]string{"*:*:1h", "*:*:2h"}); err == nil {
+			t.Error("expected duplicate rules to be invalid")
+		}
+	})
+
+	t.Run("soft delete expired jobs", func(t *testing.T) {
+		db, wp, svc := setup(t, []string{"send_job_status:*:1h", "*:complete:24h"})
+
+		pruned := []uuid.UUID{
+			insert(t, db, wp, jobs.SendJobStatusJobType, jobs.Complete, 2*time.Hour),
+			insert(t, db, wp, jobs.SendJobStatusJobType, jobs.Failed, 2*time.Hour),
+			insert(t, db, wp, "account_create", jobs.Complete, 48*time.Hour),
+		}
+		kept := []uuid.UUID{
+			insert(t, db, wp, "account_create", jobs.Complete, 2*time.Hour),
+			insert(t, db, wp, "account_create", jobs.Failed, 48*time.Hour), // No rule
+			insert(t, db, wp, jobs.SendJobStatusJobType, jobs.Init, 48*time.Hour),
+			insert(t, db, wp, jobs.SendJobStatusJobType, jobs.Complete, 30*time.Minute),
+		}
+
+		n, err := svc.Prune(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(pruned) {
+			t.Fatalf("expected %d pruned jobs, got %d", len(pruned), n)
+		}
+
+		for _, id := range pruned {
+			if visible, stored := exists(t, db, id); visible || !stored {
+				t.Errorf("expected job %s to be soft deleted", id)
+			}
+		}
+		for _, id := range kept {
+			if visible, _ := exists(t, db, id); !visible {
+				t.Errorf("expected job %s to be kept", id)
+			}
+		}
+
+		// Soft deleted jobs are not pruned again
+		if n, err := svc.Prune(context.Background()); err != nil || n != 0 {
+			t.Fatalf("expected nothing to prune, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("most specific rule applies", func(t *testing.T) {
+		db, wp, svc := setup(t, []string{"*:*:1h", "*:failed:2h", "send_job_status:*:3h", "send_job_status:failed:4h"})
+
+		cases := []struct {
+			jobType string
+			state   jobs.State
+			ttl     time.Duration
+		}{
+			{"account_create", jobs.Complete, time.Hour},
+			{"account_create", jobs.Failed, 2 * time.Hour},
+			{jobs.SendJobStatusJobType, jobs.Complete, 3 * time.Hour},
+			{jobs.SendJobStatusJobType, jobs.Failed, 4 * time.Hour},
+		}
+
+		type pair struct{ before, after uuid.UUID }
+		ids := make([]pair, len(cases))
+		for i, c := range cases {
+			ids[i] = pair{
+				before: insert(t, db, wp, c.jobType, c.state, c.ttl-10*time.Minute),
+				after:  insert(t, db, wp, c.jobType, c.state, c.ttl+10*time.Minute),
+			}
+		}
+
+		if _, err := svc.Prune(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		for i, c := range cases {
+			if visible, _ := exists(t, db, ids[i].before); !visible {
+				t.Errorf("expected %s %s job younger than %s to be kept", c.jobType, c.state, c.ttl)
+			}
+			if visible, _ := exists(t, db, ids[i].after); visible {
+				t.Errorf("expected %s %s job older than %s to be pruned", c.jobType, c.state, c.ttl)
+			}
+		}
+	})
+
+	t.Run("archive and export", func(t *testing.T) {
+		dir := t.TempDir()
+		db, wp, svc := setup(t, []string{"*:*:1h"},
+			retention.WithMode(retention.ModeArchive),
+			retention.WithExportDir(dir),
+			retention.WithBatchSize(2),
+		)
+
+		pruned := []uuid.UUID{
+			insert(t, db, wp, "account_create", jobs.Complete, 2*time.Hour),
+			insert(t, db, wp, "account_create", jobs.Cancelled, 3*time.Hour),
+			insert(t, db, wp, "account_create", jobs.Skipped, 4*time.Hour),
+		}
+		parent := insert(t, db, wp, "account_create", jobs.Init, 2*time.Hour)
+		blocked, err := wp.CreateJob("account_create", "", jobs.WithParents(parent))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if blocked.State != jobs.Blocked {
+			t.Fatalf("expected dependent job to be blocked, got %q", blocked.State)
+		}
+		// Parent finished while the dependent job has not been resolved yet
+		if err := db.Model(&jobs.Job{}).Where("id = ?", parent).UpdateColumn("state", jobs.Complete).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		n, err := svc.Prune(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != len(pruned) {
+			t.Fatalf("expected %d pruned jobs, got %d", len(pruned), n)
+		}
+
+		for _, id := range pruned {
+			if _, stored := exists(t, db, id); stored {
+				t.Errorf("expected job %s to be moved out of the jobs table", id)
+			}
+		}
+		if visible, _ := exists(t, db, parent); !visible {
+			t.Error("expected parent of a blocked job to be kept")
+		}
+
+		var archived []retention.ArchivedJob
+		if err := db.Order("updated_at desc").Find(&archived).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(archived) != len(pruned) {
+			t.Fatalf("expected %d archived jobs, got %d", len(pruned), len(archived))
+		}
+		for i, a := range archived {
+			if a.ID != pruned[i] || a.ArchivedAt.IsZero() {
+				t.Errorf("unexpected archived job %+v", a)
+			}
+		}
+
+		files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 1 {
+			t.Fatalf("expected one export file, got %v", files)
+		}
+
+		f, err := os.Open(files[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		exported := make(map[uuid.UUID]bool)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var j retention.ArchivedJob
+			if err := json.Unmarshal(scanner.Bytes(), &j); err != nil {
+				t.Fatal(err)
+			}
+			exported[j.ID] = true
+		}
+		for _, id := range pruned {
+			if !exported[id] {
+				t.Errorf("expected job %s to be exported", id)
+			}
+		}
+	})
+
+	t.Run("periodic job", func(t *testing.T) {
+		db, wp, svc := setup(t, []string{"*:*:1h"}, retention.WithInterval(time.Hour))
+		jobSvc := jobs.NewService(jobs.NewGormStore(db), wp)
+
+		expired := insert(t, db, wp, "account_create", jobs.Complete, 2*time.Hour)
+
+		countPending := func(t *testing.T) (count int64) {
+			t.Helper()
+			err := db.Model(&jobs.Job{}).
+				Where("type = ? AND state = ?", retention.PruneJobType, jobs.Init).
+				Count(&count).Error
+			if err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+
+		if err := svc.Schedule(); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.Schedule(); err != nil {
+			t.Fatal(err)
+		}
+		if c := countPending(t); c != 1 {
+			t.Fatalf("expected one prune job, got %d", c)
+		}
+
+		var first jobs.Job
+		if err := db.Where("type = ?", retention.PruneJobType).First(&first).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		wp.Start()
+
+		job := waitForJobState(t, jobSvc, first.ID, jobs.Complete)
+		if job.Result != "1" {
+			t.Fatalf("expected one pruned job, got %q", job.Result)
+		}
+		if visible, _ := exists(t, db, expired); visible {
+			t.Fatal("expected expired job to be pruned")
+		}
+
+		var next jobs.Job
+		if err := db.Where("type = ? AND state = ?", retention.PruneJobType, jobs.Init).First(&next).Error; err != nil {
+			t.Fatal(err)
+		}
+		if !next.NextRunAt.Valid || next.NextRunAt.Time.Before(time.Now().Add(50*time.Minute)) {
+			t.Fatalf("expected next prune job to run after the interval, got %v", next.NextRunAt)
+		}
+	})
+
+	t.Run("failed event pruning does not stop job pruning", func(t *testing.T) {
+		db, wp, svc := setup(t, []string{"*:*:1h"}, retention.WithInterval(time.Hour), retention.WithEventTTL(time.Hour))
+		jobSvc := jobs.NewService(jobs.NewGormStore(db), wp)
+
+		expired := insert(t, db, wp, "account_create", jobs.Complete, 2*time.Hour)
+
+		if err := db.Migrator().DropTable(&events.Event{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := svc.Schedule(); err != nil {
+			t.Fatal(err)
+		}
+
+		var first jobs.Job
+		if err := db.Where("type = ?", retention.PruneJobType).First(&first).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		wp.Start()
+
+		job := waitForJobState(t, jobSvc, first.ID, jobs.Complete)
+
+		var result retention.PruneJobResult
+		if err := json.Unmarshal(job.TypedResult, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.Pruned != 1 || result.EventsError == "" {
+			t.Fatalf("expected the job to be pruned and the event pruning error in the result, got %+v", result)
+		}
+		if visible, _ := exists(t, db, expired); visible {
+			t.Fatal("expected expired job to be pruned")
+		}
+
+		var next jobs.Job
+		if err := db.Where("type = ? AND state = ?", retention.PruneJobType, jobs.Init).First(&next).Error; err != nil {
+			t.Fatalf("expected the next prune job to be scheduled, got %v", err)
+		}
+	})
+
+	t.Run("stream events", func(t *testing.T) {
+		db, _, svc := setup(t, nil, retention.WithEventTTL(time.Hour), retention.WithBatchSize(1))
+
+		store := events.NewGormStore(db)
+		for _, age := range []time.Duration{3 * time.Hour, 2 * time.Hour, time.Minute} {
+			if err := store.InsertEvent(&events.Event{Kind: events.KindAccountCreated, CreatedAt: time.Now().Add(-age)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pruned, err := svc.PruneEvents(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pruned != 2 {
+			t.Fatalf("expected two pruned events, got %d", pruned)
+		}
+
+		ee, err := store.EventsAfter(0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ee) != 1 || time.Since(ee[0].CreatedAt) > time.Hour {
+			t.Fatalf("expected only the recent event to be kept, got %+v", ee)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/datastore"
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+// finishedJobs records the states of jobs reported by JobFinished events
+type finishedJobs struct {
+	mu     sync.Mutex
+	states map[uuid.UUID]jobs.State
+}
+
+func newFinishedJobs() *finishedJobs {
+	f := &finishedJobs{states: make(map[uuid.UUID]jobs.State)}
+	jobs.JobFinished.Register(f)
+	return f
+}
+
+func (f *finishedJobs) Handle(payload jobs.JobFinishedPayload) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states[payload.Job.ID] = payload.Job.State
+}
+
+// wait returns the state the job finished in
+func (f *finishedJobs) wait(t *testing.T, id uuid.UUID) jobs.State {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		f.mu.Lock()
+		state, ok := f.states[id]
+		f.mu.Unlock()
+		if ok {
+			return state
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected a JobFinished event for job %s", id)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobCancel(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1)
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	finished := newFinishedJobs()
+
+	wp.RegisterExecutor("succeed", func(ctx context.Context, j *jobs.Job) error {
+		return nil
+	})
+
+	wp.RegisterExecutor("submit_and_fail", func(ctx context.Context, j *jobs.Job) error {
+		if err := jobs.MarkTransactionSubmitted(ctx); err != nil {
+			return err
+		}
+		return errors.New("transaction was not sealed in time")
+	})
+
+	assertConflict := func(t *testing.T, err error) {
+		t.Helper()
+		if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != http.StatusConflict {
+			t.Fatalf("expected a conflict error, got %v", err)
+		}
+	}
+
+	t.Run("waiting job is cancelled and never executed", func(t *testing.T) {
+		// Not scheduled, stays in INIT
+		j, err := wp.CreateJob("succeed", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job, err := jobSvc.Cancel(j.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Cancelled {
+			t.Fatalf("expected job.State = %q, got %q", jobs.Cancelled, job.State)
+		}
+
+		if state := finished.wait(t, job.ID); state != jobs.Cancelled {
+			t.Fatalf("expected a JobFinished event with state %q, got %q", jobs.Cancelled, state)
+		}
+
+		if err := jobStore.AcceptJob(job, "test", time.Minute, time.Minute); err == nil {
+			t.Fatal("expected cancelled job not to be acceptable")
+		}
+
+		schedulable, err := jobStore.SchedulableJobs(0, 0, datastore.ParseListOptions(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range schedulable {
+			if s.ID == job.ID {
+				t.Fatal("expected cancelled job not to be schedulable")
+			}
+		}
+
+		_, err = jobSvc.Cancel(j.ID.String())
+		assertConflict(t, err)
+	})
+
+	t.Run("completed job can not be cancelled", func(t *testing.T) {
+		j, err := wp.CreateJob("succeed", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := test.WaitForJob(jobSvc, j.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = jobSvc.Cancel(j.ID.String())
+		assertConflict(t, err)
+	})
+
+	t.Run("job with a submitted transaction can not be cancelled", func(t *testing.T) {
+		j, err := wp.CreateJob("submit_and_fail", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		for {
+			job, err := jobSvc.Details(j.ID.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if job.State == jobs.Error {
+				if !job.TransactionSubmittedAt.Valid {
+					t.Fatal("expected transaction submission to be recorded")
+				}
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		_, err = jobSvc.Cancel(j.ID.String())
+		assertConflict(t, err)
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		_, err := jobSvc.Cancel("8c3fb6f1-8c1a-4f7d-9d0f-0e6b1c2d3e4f")
+		if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	})
+}
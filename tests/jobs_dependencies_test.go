@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func waitForJobState(t *testing.T, jobSvc jobs.Service, jobID uuid.UUID, state jobs.State) *jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobSvc.Details(jobID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected job %s to reach state %q, got %q", jobID, state, job.State)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestJobDependencies(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 2, jobs.WithMaxJobErrorCount(0), jobs.WithDbJobPollInterval(100*time.Millisecond))
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	finished := newFinishedJobs()
+
+	release := make(chan struct{})
+	wp.RegisterExecutor("gated", func(ctx context.Context, j *jobs.Job) error {
+		<-release
+		return nil
+	})
+	wp.RegisterExecutor("ok", func(ctx context.Context, j *jobs.Job) error {
+		return nil
+	})
+	wp.RegisterExecutor("broken", func(ctx context.Context, j *jobs.Job) error {
+		return errors.New("invalid script")
+	})
+
+	create := func(t *testing.T, jobType string, parents ...uuid.UUID) *jobs.Job {
+		t.Helper()
+		j, err := wp.CreateJob(jobType, "", jobs.WithParents(parents...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	t.Run("child waits for all parents to complete", func(t *testing.T) {
+		gated := create(t, "gated")
+		ok := create(t, "ok")
+		child := create(t, "ok", gated.ID, ok.ID)
+
+		if child.State != jobs.Blocked {
+			t.Fatalf("expected child to be %q, got %q", jobs.Blocked, child.State)
+		}
+
+		waitForJobState(t, jobSvc, ok.ID, jobs.Complete)
+		waitForJobState(t, jobSvc, child.ID, jobs.Blocked)
+
+		close(release)
+
+		waitForJobState(t, jobSvc, child.ID, jobs.Complete)
+	})
+
+	t.Run("child of a completed parent is not blocked", func(t *testing.T) {
+		parent := create(t, "ok")
+		waitForJobState(t, jobSvc, parent.ID, jobs.Complete)
+
+		child := create(t, "ok", parent.ID)
+		if child.State != jobs.Init {
+			t.Fatalf("expected child to be %q, got %q", jobs.Init, child.State)
+		}
+		waitForJobState(t, jobSvc, child.ID, jobs.Complete)
+	})
+
+	t.Run("failed parent skips all descendants", func(t *testing.T) {
+		parent := create(t, "broken")
+		child := create(t, "ok", parent.ID)
+		grandchild := create(t, "ok", child.ID)
+
+		waitForJobState(t, jobSvc, parent.ID, jobs.Failed)
+		skipped := waitForJobState(t, jobSvc, child.ID, jobs.Skipped)
+		waitForJobState(t, jobSvc, grandchild.ID, jobs.Skipped)
+
+		if skipped.Error == "" {
+			t.Fatal("expected skipped job to have an error")
+		}
+
+		for _, id := range []uuid.UUID{child.ID, grandchild.ID} {
+			if state := finished.wait(t, id); state != jobs.Skipped {
+				t.Errorf("expected JobFinished with state %q for job %s, got %q", jobs.Skipped, id, state)
+			}
+		}
+	})
+
+	t.Run("child of a failed parent is skipped on creation", func(t *testing.T) {
+		parent := create(t, "broken")
+		waitForJobState(t, jobSvc, parent.ID, jobs.Failed)
+
+		child := create(t, "ok", parent.ID)
+		if child.State != jobs.Skipped {
+			t.Fatalf("expected child to be %q, got %q", jobs.Skipped, child.State)
+		}
+
+		if state := finished.wait(t, child.ID); state != jobs.Skipped {
+			t.Errorf("expected JobFinished with state %q, got %q", jobs.Skipped, state)
+		}
+	})
+
+	t.Run("cancelled parent skips child", func(t *testing.T) {
+		parent, err := wp.CreateJob("ok", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		child := create(t, "ok", parent.ID)
+
+		if _, err := jobSvc.Cancel(parent.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		waitForJobState(t, jobSvc, child.ID, jobs.Skipped)
+	})
+
+	t.Run("unknown parent", func(t *testing.T) {
+		if _, err := wp.CreateJob("ok", "", jobs.WithParents(uuid.New())); !errors.Is(err, jobs.ErrUnknownParent) {
+			t.Fatalf("expected error %q, got %v", jobs.ErrUnknownParent, err)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/handlers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestWorkerPoolDrain(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	jobSvc := jobs.NewService(jobStore, nil)
+
+	newPool := func(t *testing.T) jobs.WorkerPool {
+		wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(time.Hour))
+		t.Cleanup(func() {
+			wp.Stop(true)
+		})
+		return wp
+	}
+
+	schedule := func(t *testing.T, wp jobs.WorkerPool, jobType string) *jobs.Job {
+		t.Helper()
+		j, err := wp.CreateJob(jobType, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	t.Run("running jobs finish and waiting jobs are released", func(t *testing.T) {
+		release := make(chan struct{})
+		var releaseOnce sync.Once
+		unblock := func() { releaseOnce.Do(func() { close(release) }) }
+		defer unblock()
+
+		wp := newPool(t)
+		wp.RegisterExecutor("slow", func(ctx context.Context, j *jobs.Job) error {
+			<-release
+			return nil
+		})
+		wp.Start()
+
+		running := schedule(t, wp, "slow")
+		waitForJobState(t, jobSvc, running.ID, jobs.Accepted)
+
+		// Waits for the only worker
+		waiting := schedule(t, wp, "slow")
+
+		drained := make(chan error, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			drained <- wp.Drain(ctx)
+		}()
+
+		waitForJobState(t, jobSvc, waiting.ID, jobs.NoAvailableWorkers)
+		if !wp.Draining() {
+			t.Fatal("expected worker pool to be draining")
+		}
+
+		// New jobs are left to other instances
+		late := schedule(t, wp, "slow")
+		if late.State != jobs.NoAvailableWorkers {
+			t.Fatalf("expected job scheduled while draining to be left to other instances, got %q", late.State)
+		}
+
+		select {
+		case err := <-drained:
+			t.Fatalf("expected drain to wait for the running job, got %v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		unblock()
+
+		if err := <-drained; err != nil {
+			t.Fatal(err)
+		}
+
+		waitForJobState(t, jobSvc, running.ID, jobs.Complete)
+	})
+
+	t.Run("running jobs are released at the deadline", func(t *testing.T) {
+		wp := newPool(t)
+		wp.RegisterExecutor("stuck", func(ctx context.Context, j *jobs.Job) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		wp.Start()
+
+		j := schedule(t, wp, "stuck")
+		waitForJobState(t, jobSvc, j.ID, jobs.Accepted)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		if err := wp.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+
+		job := waitForJobState(t, jobSvc, j.ID, jobs.NoAvailableWorkers)
+		if job.LeaseExpiresAt.Valid {
+			t.Fatal("expected the lease of the released job to be cleared")
+		}
+	})
+
+	t.Run("running jobs which submitted a transaction are not released", func(t *testing.T) {
+		wp := newPool(t)
+		wp.RegisterExecutor("submitted_stuck", func(ctx context.Context, j *jobs.Job) error {
+			if err := jobs.MarkTransactionSubmitted(ctx); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		wp.Start()
+
+		j := schedule(t, wp, "submitted_stuck")
+		waitForJobState(t, jobSvc, j.ID, jobs.Accepted)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		if err := wp.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected deadline exceeded, got %v", err)
+		}
+
+		job := waitForJobState(t, jobSvc, j.ID, jobs.Error)
+		if !job.TransactionSubmittedAt.Valid || !strings.Contains(job.Error, "transaction was submitted") {
+			t.Fatalf("expected the job to error after submitting a transaction, got %+v", job)
+		}
+		if !job.NextRunAt.Valid || !job.NextRunAt.Time.After(time.Now()) {
+			t.Fatalf("expected the job to be executed again only after the backoff, got %v", job.NextRunAt)
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		wp := newPool(t)
+
+		ok := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.WriteHeader(http.StatusOK)
+		})
+		h := handlers.UseDrain(ok, wp.Draining)
+		ready := handlers.Readiness(func() bool { return !wp.Draining() })
+
+		status := func(h http.Handler, method string) int {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(method, "/v1/accounts", nil))
+			return rec.Code
+		}
+
+		if status(h, http.MethodPost) != http.StatusOK || status(ready, http.MethodGet) != http.StatusOK {
+			t.Fatal("expected requests to be served before draining")
+		}
+
+		if err := wp.Drain(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := status(h, http.MethodPost); code != http.StatusServiceUnavailable {
+			t.Fatalf("expected new work to be rejected while draining, got %d", code)
+		}
+		if code := status(h, http.MethodGet); code != http.StatusOK {
+			t.Fatalf("expected reads to be served while draining, got %d", code)
+		}
+		if code := status(ready, http.MethodGet); code != http.StatusServiceUnavailable {
+			t.Fatalf("expected not ready while draining, got %d", code)
+		}
+	})
+}
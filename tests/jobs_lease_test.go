@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/datastore"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestJobLeases(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	jobSvc := jobs.NewService(jobStore, nil)
+
+	newPool := func(t *testing.T, workerID string) jobs.WorkerPool {
+		wp := jobs.NewWorkerPool(jobStore, 10, 2,
+			jobs.WithWorkerID(workerID),
+			jobs.WithLeaseDuration(300*time.Millisecond),
+			jobs.WithDbJobPollInterval(50*time.Millisecond),
+		)
+		t.Cleanup(func() {
+			wp.Stop(true)
+		})
+		return wp
+	}
+
+	t.Run("heartbeat keeps a slow job from being picked up twice", func(t *testing.T) {
+		var executions int32
+		release := make(chan struct{})
+		var releaseOnce sync.Once
+		unblock := func() { releaseOnce.Do(func() { close(release) }) }
+		defer unblock() // Lets the pools stop if the test fails early
+
+		slow := func(ctx context.Context, j *jobs.Job) error {
+			atomic.AddInt32(&executions, 1)
+			<-release
+			return nil
+		}
+
+		a := newPool(t, "instance-a")
+		a.RegisterExecutor("slow", slow)
+		b := newPool(t, "instance-b")
+		b.RegisterExecutor("slow", slow)
+
+		a.Start()
+
+		j, err := a.CreateJob("slow", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		job := waitForJobState(t, jobSvc, j.ID, jobs.Accepted)
+		if job.WorkerID != "instance-a" || !job.LeaseExpiresAt.Valid {
+			t.Fatalf("expected job to be leased to instance-a, got %q %v", job.WorkerID, job.LeaseExpiresAt)
+		}
+
+		// Instance b polls the database for schedulable jobs
+		b.Start()
+
+		// Several lease durations
+		time.Sleep(time.Second)
+
+		status, err := a.Status()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.WorkerID != "instance-a" || status.ActiveWorkers != 1 {
+			t.Fatalf("expected one active worker on instance-a, got %+v", status)
+		}
+		if len(status.Instances) != 1 || status.Instances[0].WorkerID != "instance-a" || status.Instances[0].ActiveWorkers != 1 {
+			t.Fatalf("expected the lease of instance-a in instances, got %+v", status.Instances)
+		}
+
+		unblock()
+
+		job = waitForJobState(t, jobSvc, j.ID, jobs.Complete)
+		if n := atomic.LoadInt32(&executions); n != 1 {
+			t.Fatalf("expected job to be executed once, got %d", n)
+		}
+		if job.LeaseExpiresAt.Valid {
+			t.Fatal("expected lease to be cleared once the job completed")
+		}
+	})
+
+	t.Run("expired lease of a crashed instance is reclaimed", func(t *testing.T) {
+		wp := newPool(t, "instance-c")
+		wp.RegisterExecutor("ok", func(ctx context.Context, j *jobs.Job) error {
+			return nil
+		})
+
+		j, err := wp.CreateJob("ok", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Accepted by an instance which never renews the lease
+		if err := jobStore.AcceptJob(j, "crashed", 100*time.Millisecond, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+
+		schedulable, err := jobStore.SchedulableJobs(time.Hour, time.Hour, datastore.ParseListOptions(0, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range schedulable {
+			if s.ID == j.ID {
+				t.Fatal("expected job with an active lease not to be schedulable")
+			}
+		}
+
+		time.Sleep(200 * time.Millisecond)
+
+		wp.Start()
+
+		job := waitForJobState(t, jobSvc, j.ID, jobs.Complete)
+		if job.WorkerID != "instance-c" || job.ExecCount != 2 {
+			t.Fatalf("expected job to be executed again by instance-c, got %q with exec count %d", job.WorkerID, job.ExecCount)
+		}
+	})
+}
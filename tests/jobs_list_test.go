@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/handlers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestJobList(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	// Not started, jobs stay in INIT
+	wp := jobs.NewWorkerPool(jobStore, 10, 1)
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	created := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		j, err := wp.CreateJob("withdrawal_create", "", jobs.WithAttributes([]byte(`{"Sender":"0x01","Request":{"Recipient":"0x02"}}`)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		created[j.ID.String()] = true
+	}
+	other, err := wp.CreateJob("account_create", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := mux.NewRouter()
+	router.Handle("/jobs", handlers.NewJobs(jobSvc).List()).Methods(http.MethodGet)
+
+	list := func(t *testing.T, query url.Values) ([]jobs.JSONResponse, *http.Response) {
+		t.Helper()
+		res := sendWithHeaders(router, http.MethodGet, "/jobs?"+query.Encode(), nil, nil)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, res.StatusCode)
+		}
+		var jj []jobs.JSONResponse
+		if err := json.NewDecoder(res.Body).Decode(&jj); err != nil {
+			t.Fatal(err)
+		}
+		return jj, res
+	}
+
+	t.Run("cursor pages do not skip or duplicate jobs", func(t *testing.T) {
+		query := url.Values{"type": {"withdrawal_create"}, "limit": {"2"}}
+		seen := make(map[string]bool)
+
+		for page := 0; ; page++ {
+			jj, res := list(t, query)
+
+			if page == 0 && res.Header.Get(handlers.TotalCountHeader) != "5" {
+				t.Fatalf("expected total count 5, got %q", res.Header.Get(handlers.TotalCountHeader))
+			}
+
+			for _, j := range jj {
+				if seen[j.ID.String()] {
+					t.Fatalf("job %s returned twice", j.ID)
+				}
+				seen[j.ID.String()] = true
+			}
+
+			// A job inserted while paging sorts before the cursor
+			if page == 0 {
+				if _, err := wp.CreateJob("withdrawal_create", ""); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cursor := res.Header.Get(handlers.NextCursorHeader)
+			if cursor == "" {
+				break
+			}
+			query.Set("cursor", cursor)
+		}
+
+		for id := range created {
+			if !seen[id] {
+				t.Fatalf("job %s was skipped", id)
+			}
+		}
+		if len(seen) != len(created) {
+			t.Fatalf("expected %d jobs, got %d", len(created), len(seen))
+		}
+	})
+
+	t.Run("filter by attribute and state", func(t *testing.T) {
+		jj, _ := list(t, url.Values{"attributes.Request.Recipient": {"0x02"}, "state": {"INIT,FAILED"}})
+		if len(jj) != len(created) {
+			t.Fatalf("expected %d jobs, got %d", len(created), len(jj))
+		}
+
+		jj, _ = list(t, url.Values{"attributes.Sender": {"0x03"}})
+		if len(jj) != 0 {
+			t.Fatalf("expected no jobs, got %d", len(jj))
+		}
+
+		jj, _ = list(t, url.Values{"type": {"account_create"}, "sort": {"updatedAt"}, "order": {"asc"}})
+		if len(jj) != 1 || jj[0].ID != other.ID {
+			t.Fatalf("expected only job %s, got %v", other.ID, jj)
+		}
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		queries := []url.Values{
+			{"state": {"DONE"}},
+			{"createdAfter": {"yesterday"}},
+			{"sort": {"type"}},
+			{"attributes.Sender')": {"0x01"}},
+			{"cursor": {"not a cursor"}},
+		}
+		for _, q := range queries {
+			res := sendWithHeaders(router, http.MethodGet, "/jobs?"+q.Encode(), nil, nil)
+			if res.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected status code %d for %v, got %d", http.StatusBadRequest, q, res.StatusCode)
+			}
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+type chanNotifier chan jobs.JobNotification
+
+func (c chanNotifier) Notifications() <-chan jobs.JobNotification {
+	return c
+}
+
+func TestJobNotifications(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+
+	// Jobs are only picked up through notifications, the database is polled
+	// once on start
+	newPool := func(t *testing.T) (jobs.WorkerPool, chanNotifier) {
+		notifier := make(chanNotifier, 10)
+		wp := jobs.NewWorkerPool(jobStore, 10, 2,
+			jobs.WithJobNotifier(notifier),
+			jobs.WithDbJobPollInterval(time.Hour),
+			jobs.WithReSchedulableGracePeriod(time.Hour),
+		)
+		wp.RegisterExecutor("ok", func(ctx context.Context, j *jobs.Job) error {
+			return nil
+		})
+		t.Cleanup(func() {
+			wp.Stop(true)
+		})
+		return wp, notifier
+	}
+
+	t.Run("job deferred by another instance is taken over", func(t *testing.T) {
+		wp, notifier := newPool(t)
+		jobSvc := jobs.NewService(jobStore, wp)
+
+		j, err := wp.CreateJob("ok", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j.State = jobs.NoAvailableWorkers
+		if err := jobStore.UpdateJob(j); err != nil {
+			t.Fatal(err)
+		}
+
+		wp.Start()
+
+		notifier <- jobs.JobNotification{ID: j.ID, State: jobs.NoAvailableWorkers}
+
+		waitForJobState(t, jobSvc, j.ID, jobs.Complete)
+	})
+
+	t.Run("waiting request sees updates of another instance", func(t *testing.T) {
+		wp, notifier := newPool(t)
+		jobSvc := jobs.NewService(jobStore, wp, jobs.WithWaitPollInterval(time.Hour))
+
+		j, err := wp.CreateJob("ok", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wp.Start()
+
+		time.AfterFunc(100*time.Millisecond, func() {
+			j.State = jobs.Complete
+			if err := jobStore.UpdateJob(j); err != nil {
+				t.Error(err)
+			}
+			notifier <- jobs.JobNotification{ID: j.ID, State: jobs.Complete}
+		})
+
+		begin := time.Now()
+
+		job, err := jobSvc.Wait(context.Background(), j.ID.String(), jobs.WaitRequest{
+			States:  []string{string(jobs.Complete)},
+			Timeout: "5s",
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Complete || time.Since(begin) > 2*time.Second {
+			t.Fatalf("expected waiting to end once notified, got %q after %s", job.State, time.Since(begin))
+		}
+	})
+
+	t.Run("missed notifications wake up the database scheduler", func(t *testing.T) {
+		wp, notifier := newPool(t)
+		jobSvc := jobs.NewService(jobStore, wp)
+
+		wp.Start()
+
+		j, err := wp.CreateJob("ok", "", jobs.WithRunAt(time.Now().Add(200*time.Millisecond)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(300 * time.Millisecond)
+
+		// Sent by the notifier after reconnecting
+		notifier <- jobs.JobNotification{}
+
+		waitForJobState(t, jobSvc, j.ID, jobs.Complete)
+	})
+}
@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestJobRedisQueue(t *testing.T) {
+	cfg := test.LoadConfig(t)
+
+	if cfg.IdempotencyMiddlewareRedisURL == "" {
+		t.Skip("skipped as \"cfg.IdempotencyMiddlewareRedisURL\" is empty")
+	}
+
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	jobSvc := jobs.NewService(jobStore, nil)
+
+	pool := &redis.Pool{
+		Dial: func() (redis.Conn, error) {
+			return redis.DialURL(cfg.IdempotencyMiddlewareRedisURL)
+		},
+	}
+	t.Cleanup(func() {
+		pool.Close()
+	})
+
+	queue := jobs.RedisQueueConfig{
+		Pool:   pool,
+		Stream: "test:jobs:" + uuid.NewString(),
+		Group:  "workers",
+	}
+	t.Cleanup(func() {
+		conn := pool.Get()
+		defer conn.Close()
+		_, _ = conn.Do("DEL", queue.Stream)
+	})
+
+	newPool := func(t *testing.T, workerID string) jobs.WorkerPool {
+		wp := jobs.NewWorkerPool(jobStore, 1, 1,
+			jobs.WithRedisQueue(queue),
+			jobs.WithWorkerID(workerID),
+			jobs.WithLeaseDuration(300*time.Millisecond),
+			jobs.WithDbJobPollInterval(time.Hour),
+		)
+		wp.RegisterExecutor("ok", func(ctx context.Context, j *jobs.Job) error {
+			return nil
+		})
+		t.Cleanup(func() {
+			wp.Stop(true)
+		})
+		return wp
+	}
+
+	streamLen := func(t *testing.T) int {
+		t.Helper()
+		conn := pool.Get()
+		defer conn.Close()
+		n, err := redis.Int(conn.Do("XLEN", queue.Stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	t.Run("jobs are executed by any instance", func(t *testing.T) {
+		scheduler := newPool(t, "instance-a")
+		worker := newPool(t, "instance-b")
+		worker.Start()
+
+		var ids []uuid.UUID
+		for i := 0; i < 5; i++ {
+			j, err := scheduler.CreateJob("ok", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := scheduler.Schedule(j); err != nil {
+				t.Fatal(err)
+			}
+			if j.State == jobs.NoAvailableWorkers {
+				t.Fatal("expected the stream to take the job")
+			}
+			ids = append(ids, j.ID)
+		}
+
+		for _, id := range ids {
+			job := waitForJobState(t, jobSvc, id, jobs.Complete)
+			if job.WorkerID != "instance-b" {
+				t.Fatalf("expected job to be executed by instance-b, got %q", job.WorkerID)
+			}
+		}
+
+		// Entries are acknowledged once the executed job has been saved
+		deadline := time.Now().Add(5 * time.Second)
+		for n := streamLen(t); n != 0; n = streamLen(t) {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected acknowledged entries to be removed, got %d entries", n)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	})
+
+	t.Run("entries of a crashed instance are claimed", func(t *testing.T) {
+		wp := newPool(t, "instance-c")
+
+		j, err := wp.CreateJob("ok", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Read by an instance which crashes before executing the job
+		conn := pool.Get()
+		defer conn.Close()
+		if _, err := conn.Do("XGROUP", "CREATE", queue.Stream, queue.Group, "0", "MKSTREAM"); err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			t.Fatal(err)
+		}
+		if _, err := conn.Do("XADD", queue.Stream, "*", "job", j.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := conn.Do("XREADGROUP", "GROUP", queue.Group, "crashed", "COUNT", 10, "STREAMS", queue.Stream, ">"); err != nil {
+			t.Fatal(err)
+		}
+
+		wp.Start()
+
+		job := waitForJobState(t, jobSvc, j.ID, jobs.Complete)
+		if job.WorkerID != "instance-c" {
+			t.Fatalf("expected job to be executed by instance-c, got %q", job.WorkerID)
+		}
+	})
+}
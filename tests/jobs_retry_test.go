@@ -0,0 +1,200 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestJobRetry(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	// Fail jobs on their first error
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithMaxJobErrorCount(0))
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	// Fails on the first execution, succeeds afterwards
+	executions := make(map[string]int)
+	wp.RegisterExecutor("flaky", func(ctx context.Context, j *jobs.Job) error {
+		executions[j.ID.String()]++
+		if executions[j.ID.String()] == 1 {
+			return errors.New("access node unavailable")
+		}
+		return nil
+	})
+
+	wp.RegisterExecutor("broken", func(ctx context.Context, j *jobs.Job) error {
+		return errors.New("invalid script")
+	})
+
+	wp.RegisterExecutor("rate_limited", func(ctx context.Context, j *jobs.Job) error {
+		return errors.New("rate limit of 100% reached for key_a")
+	})
+
+	wp.RegisterExecutor("rate_limited_other", func(ctx context.Context, j *jobs.Job) error {
+		return errors.New("rate limit of 1000 reached for keyxa")
+	})
+
+	failJob := func(t *testing.T, jobType string) *jobs.Job {
+		t.Helper()
+		j, err := wp.CreateJob(jobType, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := test.WaitForJob(jobSvc, j.ID.String()); err == nil {
+			t.Fatal("expected job to fail")
+		}
+		job, err := jobSvc.Details(j.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	assertStatus := func(t *testing.T, err error, status int) {
+		t.Helper()
+		if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != status {
+			t.Fatalf("expected a %d error, got %v", status, err)
+		}
+	}
+
+	t.Run("failed job is retried and keeps its error history", func(t *testing.T) {
+		failed := failJob(t, "flaky")
+
+		job, err := jobSvc.Retry(failed.ID.String(), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.ExecCount != 0 {
+			t.Fatalf("expected execution count to be reset, got %d", job.ExecCount)
+		}
+
+		job, err = test.WaitForJob(jobSvc, failed.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State != jobs.Complete {
+			t.Fatalf("expected job.State = %q, got %q", jobs.Complete, job.State)
+		}
+		if len(job.Errors) != 1 || job.Errors[0] != "access node unavailable" {
+			t.Fatalf("expected previous errors to be kept, got %v", job.Errors)
+		}
+
+		_, err = jobSvc.Retry(failed.ID.String(), false)
+		assertStatus(t, err, http.StatusConflict)
+	})
+
+	t.Run("bulk retry only requeues matching jobs", func(t *testing.T) {
+		start := time.Now().Add(-time.Second)
+		flaky := failJob(t, "flaky")
+		broken := failJob(t, "broken")
+
+		_, err := jobSvc.RetryFailed(jobs.RetryRequest{})
+		assertStatus(t, err, http.StatusBadRequest)
+
+		future := time.Now().Add(time.Hour)
+		retried, err := jobSvc.RetryFailed(jobs.RetryRequest{ErrorContains: "ACCESS NODE", FailedAfter: &future})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*retried) != 0 {
+			t.Fatalf("expected no jobs outside the time window, got %d", len(*retried))
+		}
+
+		retried, err = jobSvc.RetryFailed(jobs.RetryRequest{ErrorContains: "ACCESS NODE", FailedAfter: &start})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(*retried) != 1 || (*retried)[0].ID != flaky.ID {
+			t.Fatalf("expected only job %s to be retried, got %v", flaky.ID, *retried)
+		}
+
+		if _, err := test.WaitForJob(jobSvc, flaky.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		job, err := jobSvc.Details(broken.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State != jobs.Failed {
+			t.Fatalf("expected non-matching job to stay %q, got %q", jobs.Failed, job.State)
+		}
+	})
+
+	t.Run("error filter matches wildcards literally", func(t *testing.T) {
+		limited := failJob(t, "rate_limited")
+		other := failJob(t, "rate_limited_other")
+
+		for _, contains := range []string{"100%", "key_a", "100% reached for key_"} {
+			retried, err := jobSvc.RetryFailed(jobs.RetryRequest{ErrorContains: contains})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, j := range *retried {
+				if j.ID == other.ID {
+					t.Fatalf("expected %q not to match %q", contains, "rate limit of 1000 reached for keyxa")
+				}
+			}
+		}
+
+		job, err := jobSvc.Details(limited.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == jobs.Failed {
+			t.Fatalf("expected job %s to be retried", limited.ID)
+		}
+	})
+
+	t.Run("submitted job is only retried with force", func(t *testing.T) {
+		failed := failJob(t, "broken")
+
+		submittedAt := time.Now().Add(-time.Minute)
+		if err := db.Model(&jobs.Job{}).Where("id = ?", failed.ID).Update("transaction_submitted_at", submittedAt).Error; err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := jobSvc.Retry(failed.ID.String(), false)
+		assertStatus(t, err, http.StatusConflict)
+
+		retried, err := jobSvc.RetryFailed(jobs.RetryRequest{Type: "broken"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, j := range *retried {
+			if j.ID == failed.ID {
+				t.Fatal("expected bulk retry without force to skip the submitted job")
+			}
+		}
+
+		job, err := jobSvc.Retry(failed.ID.String(), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !job.TransactionSubmittedAt.Valid {
+			t.Fatal("expected the retried job to keep its transaction submission time")
+		}
+
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		_, err := jobSvc.Retry("8c3fb6f1-8c1a-4f7d-9d0f-0e6b1c2d3e4f", false)
+		assertStatus(t, err, http.StatusNotFound)
+	})
+}
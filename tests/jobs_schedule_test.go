@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/datastore"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestScheduledJobs(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1, jobs.WithDbJobPollInterval(50*time.Millisecond))
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	wp.RegisterExecutor("payout", func(ctx context.Context, j *jobs.Job) error {
+		return nil
+	})
+
+	t.Run("job is not executed before it is due", func(t *testing.T) {
+		runAt := time.Now().Add(500 * time.Millisecond)
+
+		j, err := wp.CreateJob("payout", "", jobs.WithRunAt(runAt))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := jobStore.AcceptJob(j, "test", time.Minute, time.Minute); err == nil {
+			t.Fatal("expected job not to be acceptable before it is due")
+		}
+
+		list, err := jobSvc.List(jobs.ListRequest{Scheduled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Jobs) != 1 || list.Jobs[0].ID != j.ID {
+			t.Fatalf("expected job %s to be listed as scheduled, got %v", j.ID, list.Jobs)
+		}
+
+		job, err := test.WaitForJob(jobSvc, j.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.UpdatedAt.Before(runAt) {
+			t.Fatalf("expected job to be executed after %s, was executed at %s", runAt, job.UpdatedAt)
+		}
+	})
+
+	t.Run("scheduled job can be cancelled", func(t *testing.T) {
+		j, err := wp.CreateJob("payout", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := jobSvc.Cancel(j.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		list, err := jobSvc.List(jobs.ListRequest{Scheduled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Jobs) != 0 {
+			t.Fatalf("expected no scheduled jobs, got %d", len(list.Jobs))
+		}
+	})
+}
+
+func TestScheduledJobDueInThePast(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	// Not started, only used for creating jobs
+	wp := jobs.NewWorkerPool(jobStore, 10, 1)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	j, err := wp.CreateJob("payout", "", jobs.WithRunAt(time.Now().Add(-time.Minute)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Due jobs do not wait for the accepted grace period
+	jj, err := jobStore.SchedulableJobs(time.Hour, time.Hour, datastore.ParseListOptions(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jj) != 1 || jj[0].ID != j.ID {
+		t.Fatalf("expected job %s to be schedulable, got %v", j.ID, jj)
+	}
+}
@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/numeroai/flow-wallet-api/handlers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/onflow/flow-go-sdk"
+)
+
+type typedTestJobAttributes struct {
+	Address       flow.Address      `json:"address"`
+	WebhookSecret string            `json:"webhookSecret"`
+	Params        map[string]string `json:"params,omitempty"`
+}
+
+type typedTestJobResult struct {
+	Address  string `json:"address"`
+	KeyCount int    `json:"keyCount"`
+}
+
+func TestJobTypedResults(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1)
+	jobSvc := jobs.NewService(jobStore, wp)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+
+	wp.RegisterExecutor("typed", func(ctx context.Context, j *jobs.Job) error {
+		j.Result = "0xf8d6e0586b0a20c7:3"
+		return j.SetTypedResult(typedTestJobResult{Address: "0xf8d6e0586b0a20c7", KeyCount: 3})
+	},
+		jobs.WithAttributesSchema(typedTestJobAttributes{}),
+		jobs.WithResultSchema(typedTestJobResult{}),
+	)
+	wp.Start()
+
+	router := mux.NewRouter()
+	jobsHandler := handlers.NewJobs(jobSvc)
+	router.Handle("/jobs/schemas", jobsHandler.Schemas()).Methods(http.MethodGet)
+	router.Handle("/jobs/schemas/{jobType}", jobsHandler.Schema()).Methods(http.MethodGet)
+	router.Handle("/jobs/{jobId}", jobsHandler.Details()).Methods(http.MethodGet)
+
+	t.Run("details include typed result and sanitized attributes", func(t *testing.T) {
+		attrs, err := json.Marshal(typedTestJobAttributes{
+			Address:       flow.HexToAddress("0xf8d6e0586b0a20c7"),
+			WebhookSecret: "hunter2",
+			Params:        map[string]string{"api_key": "hunter2", "tokenName": "FlowToken"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		j, err := wp.CreateJob("typed", "", jobs.WithAttributes(attrs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+		waitForJobState(t, jobSvc, j.ID, jobs.Complete)
+
+		res := sendWithHeaders(router, http.MethodGet, "/jobs/"+j.ID.String(), nil, nil)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, res.StatusCode)
+		}
+
+		var body struct {
+			Result      string                 `json:"result"`
+			TypedResult typedTestJobResult     `json:"typedResult"`
+			Attributes  map[string]interface{} `json:"attributes"`
+			ExecCount   int                    `json:"execCount"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body.Result != "0xf8d6e0586b0a20c7:3" {
+			t.Errorf("expected legacy result to be kept, got %q", body.Result)
+		}
+		if body.TypedResult.Address != "0xf8d6e0586b0a20c7" || body.TypedResult.KeyCount != 3 {
+			t.Errorf("unexpected typed result %+v", body.TypedResult)
+		}
+		if body.ExecCount != 1 {
+			t.Errorf("expected exec count 1, got %d", body.ExecCount)
+		}
+
+		if body.Attributes["address"] != "f8d6e0586b0a20c7" {
+			t.Errorf("expected address attribute, got %v", body.Attributes["address"])
+		}
+		if body.Attributes["webhookSecret"] != "[REDACTED]" {
+			t.Errorf("expected secret attribute to be redacted, got %v", body.Attributes["webhookSecret"])
+		}
+		params, _ := body.Attributes["params"].(map[string]interface{})
+		if params["api_key"] != "[REDACTED]" || params["tokenName"] != "FlowToken" {
+			t.Errorf("expected nested api key to be redacted, got %v", params)
+		}
+	})
+
+	t.Run("schemas", func(t *testing.T) {
+		res := sendWithHeaders(router, http.MethodGet, "/jobs/schemas/typed", nil, nil)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, res.StatusCode)
+		}
+
+		type schema struct {
+			Type       string                            `json:"type"`
+			Properties map[string]map[string]interface{} `json:"properties"`
+			Required   []string                          `json:"required"`
+		}
+		var body struct {
+			JobType    string  `json:"jobType"`
+			Attributes *schema `json:"attributes"`
+			Result     *schema `json:"result"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+
+		if body.JobType != "typed" || body.Attributes == nil || body.Result == nil {
+			t.Fatalf("unexpected schema %+v", body)
+		}
+		if body.Attributes.Properties["address"]["type"] != "string" || body.Attributes.Properties["params"]["type"] != "object" {
+			t.Errorf("unexpected attributes schema %+v", body.Attributes)
+		}
+		if len(body.Attributes.Required) != 2 {
+			t.Errorf("expected optional params, got required %v", body.Attributes.Required)
+		}
+		if body.Result.Properties["keyCount"]["type"] != "integer" {
+			t.Errorf("unexpected result schema %+v", body.Result)
+		}
+
+		res = sendWithHeaders(router, http.MethodGet, "/jobs/schemas", nil, nil)
+		var list []jobs.Schema
+		if err := json.NewDecoder(res.Body).Decode(&list); err != nil {
+			t.Fatal(err)
+		}
+		found := map[string]bool{}
+		for _, s := range list {
+			found[s.JobType] = true
+		}
+		if !found["typed"] || !found[jobs.SendJobStatusJobType] {
+			t.Errorf("expected all registered job types to be listed, got %v", found)
+		}
+
+		res = sendWithHeaders(router, http.MethodGet, "/jobs/schemas/unknown", nil, nil)
+		if res.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotFound, res.StatusCode)
+		}
+	})
+}
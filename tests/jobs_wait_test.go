@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+func TestJobWait(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 1)
+	// Long poll interval, updates from this instance must wake waiters up
+	jobSvc := jobs.NewService(jobStore, wp, jobs.WithWaitPollInterval(time.Hour))
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	release := make(chan struct{})
+	wp.RegisterExecutor("gated", func(ctx context.Context, j *jobs.Job) error {
+		<-release
+		return nil
+	})
+
+	t.Run("returns once the job completes", func(t *testing.T) {
+		j, err := wp.CreateJob("gated", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+
+		time.AfterFunc(200*time.Millisecond, func() { close(release) })
+
+		start := time.Now()
+		job, err := jobSvc.Wait(context.Background(), j.ID.String(), jobs.WaitRequest{States: []string{string(jobs.Complete)}, Timeout: "10s"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Complete {
+			t.Fatalf("expected job.State = %q, got %q", jobs.Complete, job.State)
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatalf("expected wait to end promptly, took %s", time.Since(start))
+		}
+	})
+
+	t.Run("returns once the job is cancelled", func(t *testing.T) {
+		j, err := wp.CreateJob("gated", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.AfterFunc(200*time.Millisecond, func() {
+			if _, err := jobSvc.Cancel(j.ID.String()); err != nil {
+				t.Error(err)
+			}
+		})
+
+		job, err := jobSvc.Wait(context.Background(), j.ID.String(), jobs.WaitRequest{States: []string{string(jobs.Complete)}, Timeout: "10s"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Cancelled {
+			t.Fatalf("expected job.State = %q, got %q", jobs.Cancelled, job.State)
+		}
+	})
+
+	t.Run("returns the current job on timeout", func(t *testing.T) {
+		j, err := wp.CreateJob("gated", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job, err := jobSvc.Wait(context.Background(), j.ID.String(), jobs.WaitRequest{States: []string{string(jobs.Complete)}, Timeout: "100ms"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Init {
+			t.Fatalf("expected job.State = %q, got %q", jobs.Init, job.State)
+		}
+	})
+
+	t.Run("sees updates made by other instances", func(t *testing.T) {
+		otherSvc := jobs.NewService(jobStore, wp, jobs.WithWaitPollInterval(50*time.Millisecond))
+
+		j, err := wp.CreateJob("gated", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Update the database directly, as another instance would
+		time.AfterFunc(200*time.Millisecond, func() {
+			j.State = jobs.Failed
+			if err := jobStore.UpdateJob(j); err != nil {
+				t.Error(err)
+			}
+		})
+
+		job, err := otherSvc.Wait(context.Background(), j.ID.String(), jobs.WaitRequest{Timeout: "10s"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.State != jobs.Failed {
+			t.Fatalf("expected job.State = %q, got %q", jobs.Failed, job.State)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		j, err := wp.CreateJob("gated", "", jobs.WithRunAt(time.Now().Add(time.Hour)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		requests := []jobs.WaitRequest{
+			{States: []string{"DONE"}},
+			{Timeout: "30"},
+			{Timeout: "-1s"},
+		}
+		for _, req := range requests {
+			_, err := jobSvc.Wait(context.Background(), j.ID.String(), req)
+			if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a bad request error for %+v, got %v", req, err)
+			}
+		}
+	})
+}
@@ -47,7 +47,7 @@ func Test_IdempotencyMiddleware(t *testing.T) {
 }
 
 // TODO: Move to test utils
-func sendWithHeaders(router *mux.Router, method, path string, body io.Reader, headers map[string]string) *http.Response {
+func sendWithHeaders(router http.Handler, method, path string, body io.Reader, headers map[string]string) *http.Response {
 	req := httptest.NewRequest(method, path, body)
 	req.Header.Set("content-type", "application/json")
 
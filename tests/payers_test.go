@@ -0,0 +1,400 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/numeroai/flow-wallet-api/accounts"
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/keys"
+	"github.com/numeroai/flow-wallet-api/keys/basic"
+	"github.com/numeroai/flow-wallet-api/payers"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/transactions"
+	"github.com/onflow/cadence"
+	"github.com/onflow/cadence/common"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+	"google.golang.org/grpc"
+)
+
+// payerKeyManager resolves payers from in-memory authorizers and stores
+// added payers without checking them against the chain
+type payerKeyManager struct {
+	keys.Manager
+	store  keys.Store
+	payers map[string]keys.Authorizer
+}
+
+func (km *payerKeyManager) PayerAuthorizer(ctx context.Context, name string) (keys.Authorizer, error) {
+	if name == "" {
+		name = keys.AdminPayerName
+	}
+	a, ok := km.payers[name]
+	if !ok {
+		return keys.Authorizer{}, fmt.Errorf("unknown payer: %q", name)
+	}
+	return a, nil
+}
+
+func (km *payerKeyManager) AddPayer(ctx context.Context, payer *keys.Payer, key keys.Private) error {
+	payer.KeyIndex = key.Index
+	payer.KeyType = key.Type
+	return km.store.InsertPayer(payer)
+}
+
+func TestPayerProposalKeys(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := keys.NewGormStore(db)
+
+	gen := flow.NewAddressGenerator(cfg.ChainID)
+	admin := flow_helpers.FormatAddress(gen.NextAddress())
+	sponsor := flow_helpers.FormatAddress(gen.NextAddress())
+
+	for _, k := range []keys.ProposalKey{
+		{Address: admin, KeyIndex: 0},
+		{Address: sponsor, KeyIndex: 0},
+		{Address: sponsor, KeyIndex: 1},
+		{Address: sponsor, KeyIndex: 2},
+	} {
+		if err := store.InsertProposalKey(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count, err := store.ProposalKeyCount(sponsor); err != nil || count != 3 {
+		t.Fatalf("expected 3 proposal keys for the sponsor, got %d, %v", count, err)
+	}
+
+	// Keys of the pool are used in turns
+	seen := map[uint32]bool{}
+	for i := 0; i < 2; i++ {
+		index, err := store.ProposalKeyIndex(sponsor, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[index] = true
+	}
+	if !seen[0] || !seen[1] {
+		t.Errorf("expected sponsor keys 0 and 1 to be used, got %v", seen)
+	}
+
+	if err := store.InsertPayer(&keys.Payer{Name: "sponsor", Address: sponsor, ProposalKeyCount: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	accountStore := accounts.NewGormStore(db)
+	if err := accountStore.InsertAccount(&accounts.Account{Address: admin, Payer: "sponsor"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeletePayer("sponsor"); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := store.ProposalKeyCount(sponsor); err != nil || count != 0 {
+		t.Errorf("expected proposal keys of the payer to be deleted, got %d, %v", count, err)
+	}
+	if count, err := store.ProposalKeyCount(admin); err != nil || count != 1 {
+		t.Errorf("expected admin proposal keys to be kept, got %d, %v", count, err)
+	}
+
+	acc, err := accountStore.Account(admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.Payer != "" {
+		t.Errorf("expected account to fall back to the admin payer, got %q", acc.Payer)
+	}
+}
+
+// payerAccountFlowClient returns the same account for every address
+type payerAccountFlowClient struct {
+	flow_helpers.FlowClient
+	account *flow.Account
+}
+
+func (fc *payerAccountFlowClient) GetAccount(ctx context.Context, address flow.Address, opts ...grpc.CallOption) (*flow.Account, error) {
+	return fc.account, nil
+}
+
+func TestPayerProposalKeysOfMultiKeyAccount(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := keys.NewGormStore(db)
+
+	address := flow.NewAddressGenerator(cfg.ChainID).NextAddress()
+	payerKey := newInMemoryAuthorizer(t, address, 1)
+	otherKey := newInMemoryAuthorizer(t, address, 2)
+
+	pk, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, bytes.Repeat([]byte{1}, crypto.MinSeedLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accountKey := func(index uint32, a keys.Authorizer, revoked bool) *flow.AccountKey {
+		k := *a.Key
+		k.Index = index
+		k.Revoked = revoked
+		return &k
+	}
+
+	// Keys 1 and 4 belong to someone else, key 3 is revoked
+	fc := &payerAccountFlowClient{account: &flow.Account{Address: address, Keys: []*flow.AccountKey{
+		accountKey(0, payerKey, false),
+		accountKey(1, otherKey, false),
+		accountKey(2, payerKey, false),
+		accountKey(3, payerKey, true),
+		accountKey(4, otherKey, false),
+	}}}
+
+	km := basic.NewKeyManager(cfg, store, fc)
+
+	key := keys.Private{
+		Index:    0,
+		Type:     keys.AccountKeyTypeLocal,
+		Value:    hex.EncodeToString(pk.Encode()),
+		SignAlgo: crypto.ECDSA_P256,
+		HashAlgo: crypto.SHA3_256,
+	}
+
+	err = km.AddPayer(context.Background(), &keys.Payer{Name: "sponsor", Address: address.Hex(), ProposalKeyCount: 3}, key)
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a bad request error for more proposal keys than matching keys, got %v", err)
+	}
+
+	if err := km.AddPayer(context.Background(), &keys.Payer{Name: "sponsor", Address: address.Hex(), ProposalKeyCount: 2}, key); err != nil {
+		t.Fatal(err)
+	}
+
+	formatted := flow_helpers.FormatAddress(address)
+	if count, err := store.ProposalKeyCount(formatted); err != nil || count != 2 {
+		t.Fatalf("expected 2 proposal keys for the payer, got %d, %v", count, err)
+	}
+
+	seen := map[uint32]bool{}
+	for i := 0; i < 4; i++ {
+		index, err := store.ProposalKeyIndex(formatted, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[index] = true
+	}
+	if len(seen) != 2 || !seen[0] || !seen[2] {
+		t.Errorf("expected only keys 0 and 2 to be used, got %v", seen)
+	}
+}
+
+func TestTransactionPayer(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	gen := flow.NewAddressGenerator(cfg.ChainID)
+	admin := newInMemoryAuthorizer(t, gen.NextAddress(), 1)
+	sponsor := newInMemoryAuthorizer(t, gen.NextAddress(), 2)
+	user := newInMemoryAuthorizer(t, gen.NextAddress(), 3)
+
+	cfg.AdminAddress = admin.Address.HexWithPrefix()
+	cfg.CoSignAllowAnyScript = true
+	km := &payerKeyManager{payers: map[string]keys.Authorizer{
+		keys.AdminPayerName: admin,
+		"sponsor":           sponsor,
+	}}
+	store := transactions.NewGormStore(db)
+	svc := transactions.NewService(cfg, store, km, nil, &cosignWorkerPool{})
+
+	var sequenceNumber uint64
+	encode := func(payer flow.Address) string {
+		sequenceNumber++
+		tx := flow.NewTransaction().
+			SetScript([]byte("transaction { prepare(a: AuthAccount) {} }")).
+			SetReferenceBlockID(flow.Identifier{1}).
+			SetComputeLimit(9999).
+			SetProposalKey(user.Address, 0, sequenceNumber).
+			SetPayer(payer).
+			AddAuthorizer(user.Address)
+		if err := tx.SignPayload(user.Address, 0, user.Signer); err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(tx.Encode())
+	}
+
+	t.Run("admin pays by default", func(t *testing.T) {
+		_, tx, err := svc.CoSign(context.Background(), false, encode(admin.Address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Payer != keys.AdminPayerName {
+			t.Errorf("expected payer %q, got %q", keys.AdminPayerName, tx.Payer)
+		}
+	})
+
+	t.Run("default payer of the account", func(t *testing.T) {
+		accountStore := accounts.NewGormStore(db)
+		if err := accountStore.InsertAccount(&accounts.Account{Address: flow_helpers.FormatAddress(user.Address)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := accountStore.UpdateAccountPayer(flow_helpers.FormatAddress(user.Address), "sponsor"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := svc.CoSign(context.Background(), false, encode(admin.Address)); err == nil {
+			t.Error("expected an error for a transaction not paid by the default payer")
+		}
+
+		_, tx, err := svc.CoSign(context.Background(), false, encode(sponsor.Address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Payer != "sponsor" {
+			t.Errorf("expected payer %q, got %q", "sponsor", tx.Payer)
+		}
+	})
+
+	t.Run("payer of the request", func(t *testing.T) {
+		_, tx, err := svc.CoSign(context.Background(), false, encode(admin.Address), transactions.WithPayer(keys.AdminPayerName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tx.Payer != keys.AdminPayerName {
+			t.Errorf("expected payer %q, got %q", keys.AdminPayerName, tx.Payer)
+		}
+
+		if _, _, err := svc.CoSign(context.Background(), false, encode(admin.Address), transactions.WithPayer("unknown")); err == nil {
+			t.Error("expected an error for an unknown payer")
+		}
+	})
+
+	t.Run("fees are attributed to the payer", func(t *testing.T) {
+		for i, fee := range []uint64{100, 250} {
+			tx := &transactions.Transaction{TransactionId: fmt.Sprintf("fee-%d", i), Payer: "sponsor", Fee: fee}
+			if err := store.InsertTransaction(tx); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		fees, count, err := svc.PayerFees("sponsor")
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Includes the co-signed transaction without a fee
+		if fees != "0.00000350" || count != 3 {
+			t.Errorf("expected fees 0.00000350 of 3 transactions, got %s of %d", fees, count)
+		}
+	})
+}
+
+func TestFeeFromEvents(t *testing.T) {
+	location := common.NewAddressLocation(nil, common.Address{0xf9, 0x19, 0xee, 0x77, 0x44, 0x7b, 0x74, 0x97}, "FlowFees")
+	eventType := cadence.NewEventType(location, "FlowFees.FeesDeducted", []cadence.Field{
+		cadence.NewField("amount", cadence.UFix64Type),
+		cadence.NewField("inclusionEffort", cadence.UFix64Type),
+		cadence.NewField("executionEffort", cadence.UFix64Type),
+	}, nil)
+
+	amount, err := cadence.NewUFix64("0.00001234")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []flow.Event{
+		{Type: "flow.AccountCreated"},
+		{
+			Type:  "A.f919ee77447b7497.FlowFees.FeesDeducted",
+			Value: cadence.NewEvent([]cadence.Value{amount, cadence.UFix64(100_000_000), cadence.UFix64(0)}).WithType(eventType),
+		},
+	}
+
+	if fee := transactions.FeeFromEvents(events); fee != uint64(amount) {
+		t.Errorf("expected fee %d, got %d", uint64(amount), fee)
+	}
+
+	if fee := transactions.FeeFromEvents(events[:1]); fee != 0 {
+		t.Errorf("expected no fee, got %d", fee)
+	}
+}
+
+func TestPayersService(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	gen := flow.NewAddressGenerator(cfg.ChainID)
+	admin := gen.NextAddress()
+	sponsor := gen.NextAddress()
+	cfg.AdminAddress = admin.HexWithPrefix()
+
+	store := keys.NewGormStore(db)
+	km := &payerKeyManager{store: store}
+	txs := transactions.NewService(cfg, transactions.NewGormStore(db), km, nil, &cosignWorkerPool{})
+	svc := payers.NewService(cfg, store, km, txs)
+
+	valid := payers.JSONRequest{Name: "sponsor", Address: sponsor.Hex(), PrivateKey: "0xabcd"}
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			modify func(r *payers.JSONRequest)
+		}{
+			{"empty name", func(r *payers.JSONRequest) { r.Name = "" }},
+			{"invalid name", func(r *payers.JSONRequest) { r.Name = "Sponsor 1" }},
+			{"admin name", func(r *payers.JSONRequest) { r.Name = keys.AdminPayerName }},
+			{"invalid address", func(r *payers.JSONRequest) { r.Address = "0x1" }},
+			{"admin address", func(r *payers.JSONRequest) { r.Address = admin.Hex() }},
+			{"invalid key type", func(r *payers.JSONRequest) { r.KeyType = "plain" }},
+			{"no key", func(r *payers.JSONRequest) { r.PrivateKey = "" }},
+		}
+
+		for _, c := range cases {
+			req := valid
+			c.modify(&req)
+			_, err := svc.Create(context.Background(), req)
+			if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s: expected a bad request error, got %v", c.name, err)
+			}
+		}
+	})
+
+	t.Run("creates payers", func(t *testing.T) {
+		res, err := svc.Create(context.Background(), valid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Address != flow_helpers.FormatAddress(sponsor) || res.KeyType != keys.AccountKeyTypeLocal || res.ProposalKeyCount != 1 {
+			t.Errorf("expected defaults to be applied, got %+v", res.Payer)
+		}
+
+		_, err = svc.Create(context.Background(), valid)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusConflict {
+			t.Errorf("expected a conflict error for a duplicate payer, got %v", err)
+		}
+
+		list, err := svc.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 || list[0].Name != keys.AdminPayerName || list[1].Name != "sponsor" {
+			t.Errorf("expected the admin and sponsor payers, got %+v", list)
+		}
+	})
+
+	t.Run("deletes payers", func(t *testing.T) {
+		if err := svc.Delete(keys.AdminPayerName); err == nil {
+			t.Error("expected an error when deleting the admin payer")
+		}
+
+		if err := svc.Delete("sponsor"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := svc.Details("sponsor"); err == nil {
+			t.Error("expected the payer to be deleted")
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/keys/basic"
+	"github.com/numeroai/flow-wallet-api/subscriptions"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+)
+
+// recordingWorkerPool records scheduled webhook notifications
+type recordingWorkerPool struct {
+	jobs.WorkerPool
+	scheduled map[string]string // subscription id -> event
+}
+
+func (wp *recordingWorkerPool) ScheduleWebhookNotification(subscriptionID, event, parentJobID, content string) error {
+	wp.scheduled[subscriptionID] = event
+	return nil
+}
+
+func TestSubscriptionService(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	wp := &recordingWorkerPool{scheduled: make(map[string]string)}
+	store := subscriptions.NewGormStore(db)
+	crypter := basic.NewCrypter(cfg)
+	svc := subscriptions.NewService(store, wp, crypter)
+	resolver := subscriptions.NewTargetResolver(store, crypter)
+
+	// secret returns the secret webhook deliveries of the subscription are
+	// signed with
+	secret := func(t *testing.T, id string) string {
+		t.Helper()
+		target, err := resolver.WebhookTarget(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(target.Secret)
+	}
+
+	t.Run("invalid requests are rejected", func(t *testing.T) {
+		reqs := []subscriptions.JSONRequest{
+			{URL: "not a url"},
+			{URL: "ftp://example.com"},
+			{URL: "http://example.com", Timeout: "soon"},
+			{URL: "http://example.com", Events: []subscriptions.EventKind{"account.deleted"}},
+			{URL: "http://example.com", JobStates: []string{string(jobs.Accepted)}},
+		}
+		for _, req := range reqs {
+			_, err := svc.Create(req)
+			if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+				t.Fatalf("expected a bad request error for %+v, got %v", req, err)
+			}
+		}
+	})
+
+	t.Run("create, update and delete", func(t *testing.T) {
+		res, err := svc.Create(subscriptions.JSONRequest{URL: "http://example.com/hook"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Secret == "" {
+			t.Fatal("expected a generated secret in the create response")
+		}
+
+		id := res.ID.String()
+
+		stored, err := svc.Details(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Contains(stored.Secret, []byte(res.Secret)) {
+			t.Fatal("expected the secret to be stored encrypted")
+		}
+
+		if got := secret(t, id); got != res.Secret {
+			t.Fatalf("expected deliveries to be signed with the generated secret, got %q", got)
+		}
+
+		sub, err := svc.Update(id, subscriptions.JSONRequest{URL: "http://example.com/other", Timeout: "5s"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if sub.URL != "http://example.com/other" || sub.Timeout.String() != "5s" {
+			t.Fatalf("expected subscription to be updated, got %+v", sub)
+		}
+
+		if got := secret(t, id); got != res.Secret {
+			t.Fatal("expected secret to be kept on update")
+		}
+
+		if _, err := svc.Update(id, subscriptions.JSONRequest{URL: "http://example.com/other", Secret: "changed"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := secret(t, id); got != "changed" {
+			t.Fatalf("expected secret to be replaced on update, got %q", got)
+		}
+
+		if err := svc.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = svc.Details(id)
+		if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+	})
+
+	t.Run("publish only to matching subscriptions", func(t *testing.T) {
+		all, err := svc.Create(subscriptions.JSONRequest{URL: "http://example.com/all"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		accountsOnly, err := svc.Create(subscriptions.JSONRequest{
+			URL:    "http://example.com/accounts",
+			Events: []subscriptions.EventKind{subscriptions.EventAccountCreated},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		failedWithdrawals, err := svc.Create(subscriptions.JSONRequest{
+			URL:       "http://example.com/withdrawals",
+			Events:    []subscriptions.EventKind{subscriptions.EventJobFinished},
+			JobTypes:  []string{"withdrawal_create"},
+			JobStates: []string{string(jobs.Failed)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job := jobs.Job{Type: "withdrawal_create", State: jobs.Complete}
+		if err := svc.Publish(subscriptions.NewJobFinishedEvent(job)); err != nil {
+			t.Fatal(err)
+		}
+
+		if wp.scheduled[all.ID.String()] != string(subscriptions.EventJobFinished) {
+			t.Fatal("expected unfiltered subscription to receive the event")
+		}
+
+		if _, ok := wp.scheduled[accountsOnly.ID.String()]; ok {
+			t.Fatal("did not expect account subscription to receive a job event")
+		}
+
+		if _, ok := wp.scheduled[failedWithdrawals.ID.String()]; ok {
+			t.Fatal("did not expect failed job subscription to receive a complete job")
+		}
+
+		job.State = jobs.Failed
+		if err := svc.Publish(subscriptions.NewJobFinishedEvent(job)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := wp.scheduled[failedWithdrawals.ID.String()]; !ok {
+			t.Fatal("expected failed job subscription to receive a failed job")
+		}
+
+		cancelled, err := svc.Create(subscriptions.JSONRequest{
+			URL:       "http://example.com/cancelled",
+			Events:    []subscriptions.EventKind{subscriptions.EventJobFinished},
+			JobStates: []string{string(jobs.Cancelled)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job.State = jobs.Cancelled
+		if err := svc.Publish(subscriptions.NewJobFinishedEvent(job)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := wp.scheduled[cancelled.ID.String()]; !ok {
+			t.Fatal("expected cancelled job subscription to receive a cancelled job")
+		}
+
+		skipped, err := svc.Create(subscriptions.JSONRequest{
+			URL:       "http://example.com/skipped",
+			Events:    []subscriptions.EventKind{subscriptions.EventJobFinished},
+			JobStates: []string{string(jobs.Skipped)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job.State = jobs.Skipped
+		if err := svc.Publish(subscriptions.NewJobFinishedEvent(job)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := wp.scheduled[skipped.ID.String()]; !ok {
+			t.Fatal("expected skipped job subscription to receive a skipped job")
+		}
+
+		if err := svc.Publish(subscriptions.NewAccountCreatedEvent("0x01cf0e2f2f715450")); err != nil {
+			t.Fatal(err)
+		}
+
+		if wp.scheduled[accountsOnly.ID.String()] != string(subscriptions.EventAccountCreated) {
+			t.Fatal("expected account subscription to receive the event")
+		}
+	})
+
+	t.Run("plaintext secrets are encrypted", func(t *testing.T) {
+		id := uuid.New()
+		err := db.Exec("INSERT INTO webhook_subscriptions (id, url, secret) VALUES (?, ?, ?)", id, "http://example.com/legacy", "legacy-secret").Error
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := svc.EncryptPlaintextSecrets(); err != nil {
+			t.Fatal(err)
+		}
+
+		if plaintext, err := store.PlaintextSecrets(); err != nil || len(plaintext) != 0 {
+			t.Fatalf("expected no plaintext secrets to be left, got %v, %v", plaintext, err)
+		}
+
+		if got := secret(t, id.String()); got != "legacy-secret" {
+			t.Fatalf("expected deliveries to be signed with the existing secret, got %q", got)
+		}
+	})
+}
@@ -19,7 +19,7 @@ func Test_TokensSetup(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	svc := test.GetServices(t, cfg).GetTokens()
 
-	_, testAccount, err := test.GetServices(t, cfg).GetAccounts().Create(context.Background(), true)
+	_, testAccount, err := test.GetServices(t, cfg).GetAccounts().Create(context.Background(), true, "")
 	if err != nil {
 		t.Fatal(err)
 	}
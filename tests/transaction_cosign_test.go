@@ -0,0 +1,246 @@
+package tests
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/keys"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/transactions"
+	"github.com/onflow/flow-go-sdk"
+	"github.com/onflow/flow-go-sdk/crypto"
+)
+
+// cosignKeyManager signs as the admin account with an in-memory key
+type cosignKeyManager struct {
+	keys.Manager
+	admin keys.Authorizer
+}
+
+func (km *cosignKeyManager) AdminAuthorizer(ctx context.Context) (keys.Authorizer, error) {
+	return km.admin, nil
+}
+
+func (km *cosignKeyManager) PayerAuthorizer(ctx context.Context, name string) (keys.Authorizer, error) {
+	if name != "" && name != keys.AdminPayerName {
+		return keys.Authorizer{}, fmt.Errorf("unknown payer: %q", name)
+	}
+	return km.admin, nil
+}
+
+// cosignWorkerPool records scheduled jobs without executing them
+type cosignWorkerPool struct {
+	jobs.WorkerPool
+	scheduled []*jobs.Job
+}
+
+func (wp *cosignWorkerPool) RegisterExecutor(jobType string, executorF jobs.ExecutorFunc, opts ...jobs.ExecutorOption) {
+}
+
+func (wp *cosignWorkerPool) CreateJob(jobType, txID string, opts ...jobs.JobOption) (*jobs.Job, error) {
+	return &jobs.Job{ID: uuid.New(), Type: jobType, TransactionID: txID, State: jobs.Init}, nil
+}
+
+func (wp *cosignWorkerPool) Schedule(j *jobs.Job) error {
+	wp.scheduled = append(wp.scheduled, j)
+	return nil
+}
+
+func newInMemoryAuthorizer(t *testing.T, address flow.Address, seed byte) keys.Authorizer {
+	t.Helper()
+
+	s := make([]byte, crypto.MinSeedLength)
+	for i := range s {
+		s[i] = seed
+	}
+
+	pk, err := crypto.GeneratePrivateKey(crypto.ECDSA_P256, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := crypto.NewInMemorySigner(pk, crypto.SHA3_256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return keys.Authorizer{
+		Address: address,
+		Key:     &flow.AccountKey{Index: 0, PublicKey: pk.PublicKey(), SigAlgo: crypto.ECDSA_P256, HashAlgo: crypto.SHA3_256},
+		Signer:  signer,
+	}
+}
+
+func TestTransactionCoSign(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	gen := flow.NewAddressGenerator(cfg.ChainID)
+	admin := gen.NextAddress()
+	user1 := newInMemoryAuthorizer(t, gen.NextAddress(), 1)
+	user2 := newInMemoryAuthorizer(t, gen.NextAddress(), 2)
+
+	cfg.AdminAddress = admin.HexWithPrefix()
+	cfg.CoSignAllowAnyScript = true
+	km := &cosignKeyManager{admin: newInMemoryAuthorizer(t, admin, 3)}
+	wp := &cosignWorkerPool{}
+	store := transactions.NewGormStore(db)
+	svc := transactions.NewService(cfg, store, km, nil, wp)
+
+	const script = "transaction { prepare(a: AuthAccount, b: AuthAccount) {} }"
+
+	// build returns a transaction proposed by user1, authorized by user1 and
+	// user2 and paid by the admin, after applying modify.
+	build := func(t *testing.T, modify func(tx *flow.Transaction)) *flow.Transaction {
+		tx := flow.NewTransaction().
+			SetScript([]byte(script)).
+			SetReferenceBlockID(flow.Identifier{1}).
+			SetComputeLimit(9999).
+			SetProposalKey(user1.Address, 0, 0).
+			SetPayer(admin).
+			AddAuthorizer(user1.Address).
+			AddAuthorizer(user2.Address)
+
+		if modify != nil {
+			modify(tx)
+		}
+
+		for _, a := range []keys.Authorizer{user1, user2} {
+			if err := tx.SignPayload(a.Address, a.Key.Index, a.Signer); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return tx
+	}
+
+	encode := func(tx *flow.Transaction) string {
+		return hex.EncodeToString(tx.Encode())
+	}
+
+	t.Run("co-signs and schedules", func(t *testing.T) {
+		tx := build(t, nil)
+
+		job, transaction, err := svc.CoSign(context.Background(), false, encode(tx))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(wp.scheduled) != 1 || wp.scheduled[0] != job || job.TransactionID != transaction.TransactionId {
+			t.Fatalf("expected a job for the transaction, got %+v", wp.scheduled)
+		}
+
+		stored, err := store.Transaction(transaction.TransactionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signed, err := flow.DecodeTransaction(stored.FlowTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(signed.PayloadSignatures) != 2 {
+			t.Errorf("expected payload signatures to be kept, got %d", len(signed.PayloadSignatures))
+		}
+		if len(signed.EnvelopeSignatures) != 1 || signed.EnvelopeSignatures[0].Address != admin {
+			t.Fatalf("expected the admin to sign the envelope, got %+v", signed.EnvelopeSignatures)
+		}
+
+		// Verify the envelope signature against the admin key
+		valid, err := km.admin.Key.PublicKey.Verify(
+			signed.EnvelopeSignatures[0].Signature,
+			append(flow.TransactionDomainTag[:], signed.EnvelopeMessage()...),
+			crypto.NewSHA3_256(),
+		)
+		if err != nil || !valid {
+			t.Errorf("expected a valid envelope signature, got %v, %v", valid, err)
+		}
+
+		if signed.ID().Hex() != transaction.TransactionId {
+			t.Errorf("expected transaction id %s, got %s", signed.ID().Hex(), transaction.TransactionId)
+		}
+	})
+
+	t.Run("rejects transactions against policy", func(t *testing.T) {
+		adminSigned := func(modify func(tx *flow.Transaction)) string {
+			tx := build(t, modify)
+			if err := tx.SignPayload(admin, 0, km.admin.Signer); err != nil {
+				t.Fatal(err)
+			}
+			return encode(tx)
+		}
+
+		cases := []struct {
+			name    string
+			encoded string
+			err     string
+		}{
+			{"not hex", "xyz", "not a valid encoded transaction"},
+			{"not a transaction", "c0ffee", "not a valid encoded transaction"},
+			{"other payer", encode(build(t, func(tx *flow.Transaction) { tx.SetPayer(user2.Address) })), "payer must be"},
+			{"admin authorizer", adminSigned(func(tx *flow.Transaction) { tx.AddAuthorizer(admin) }), "can not be an authorizer"},
+			{"admin proposer", adminSigned(func(tx *flow.Transaction) { tx.SetProposalKey(admin, 0, 0) }), "can not be the proposer"},
+			{"no compute limit", encode(build(t, func(tx *flow.Transaction) { tx.SetComputeLimit(0) })), "compute limit"},
+			{"unsigned authorizer", encode(build(t, func(tx *flow.Transaction) { tx.AddAuthorizer(gen.NextAddress()) })), "missing payload signature"},
+			{"duplicate authorizer", encode(build(t, func(tx *flow.Transaction) { tx.AddAuthorizer(user2.Address) })), "duplicate authorizer"},
+		}
+
+		for _, c := range cases {
+			_, _, err := svc.CoSign(context.Background(), false, c.encoded)
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("%s: expected error %q, got %v", c.name, c.err, err)
+			}
+		}
+
+		signed := build(t, nil)
+		if err := signed.SignEnvelope(km.admin.Address, 0, km.admin.Signer); err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := svc.CoSign(context.Background(), false, encode(signed)); err == nil {
+			t.Error("expected an error for a transaction with envelope signatures")
+		}
+
+		if len(wp.scheduled) != 1 {
+			t.Errorf("expected rejected transactions not to be scheduled, got %d jobs", len(wp.scheduled))
+		}
+	})
+
+	t.Run("rejects transactions without allowed scripts", func(t *testing.T) {
+		cfg.CoSignAllowAnyScript = false
+		t.Cleanup(func() { cfg.CoSignAllowAnyScript = true })
+
+		_, _, err := svc.CoSign(context.Background(), false, encode(build(t, nil)))
+		if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != http.StatusForbidden {
+			t.Fatalf("expected a forbidden error, got %v", err)
+		}
+	})
+
+	t.Run("allowed scripts", func(t *testing.T) {
+		cfg.CoSignAllowAnyScript = false
+		cfg.CoSignAllowedScripts = []string{transactions.ScriptHash([]byte("transaction {}"))}
+		t.Cleanup(func() {
+			cfg.CoSignAllowAnyScript = true
+			cfg.CoSignAllowedScripts = nil
+		})
+
+		if _, _, err := svc.CoSign(context.Background(), false, encode(build(t, nil))); err == nil {
+			t.Fatal("expected an error for a script which is not allowed")
+		}
+
+		cfg.CoSignAllowedScripts = append(cfg.CoSignAllowedScripts, transactions.ScriptHash([]byte(script)))
+
+		if _, _, err := svc.CoSign(context.Background(), false, encode(build(t, func(tx *flow.Transaction) {
+			tx.SetProposalKey(user1.Address, 0, 1)
+		}))); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
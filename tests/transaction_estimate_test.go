@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/transactions"
+)
+
+func TestTransactionComputeLimit(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	cfg.MaxComputeLimit = 1000
+	svc := transactions.NewService(cfg, transactions.NewGormStore(db), &cosignKeyManager{}, nil, &cosignWorkerPool{})
+
+	_, err := svc.Sign(context.Background(), cfg.AdminAddress, nil, "transaction {}", nil, transactions.WithComputeLimit(1001))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a bad request error for a compute limit above the maximum, got %v", err)
+	}
+
+	_, err = svc.Estimate("transaction {}", nil, transactions.WithComputeLimit(1001))
+	if reqErr, ok := err.(*errors.RequestError); !ok || reqErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a bad request error for a compute limit above the maximum, got %v", err)
+	}
+}
+
+func TestTransactionEstimate(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	cfg.MaxComputeLimit = 9999
+	store := transactions.NewGormStore(db)
+	svc := transactions.NewService(cfg, store, &cosignKeyManager{}, nil, &cosignWorkerPool{})
+
+	const (
+		script      = "transaction(amount: UFix64) { prepare(a: AuthAccount) {} }"
+		otherScript = "transaction { prepare(a: AuthAccount) {} }"
+	)
+
+	t.Run("validates the request", func(t *testing.T) {
+		if _, err := svc.Estimate("", nil); err == nil {
+			t.Error("expected an error for an empty script")
+		}
+
+		if _, err := svc.Estimate(script, []transactions.Argument{"not json"}); err == nil {
+			t.Error("expected an error for an invalid argument")
+		}
+	})
+
+	t.Run("without executed transactions", func(t *testing.T) {
+		estimate, err := svc.Estimate(script, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if estimate.Basis != transactions.EstimateBasisComputeLimit || estimate.Computation != 9999 || estimate.Fee != "" || estimate.Samples != 0 {
+			t.Errorf("expected the compute limit without a fee, got %+v", estimate)
+		}
+	})
+
+	for i, tx := range []transactions.Transaction{
+		{TransactionId: "a", ScriptHash: transactions.ScriptHash([]byte(script)), Fee: 1000, ComputationUsed: 10},
+		{TransactionId: "b", ScriptHash: transactions.ScriptHash([]byte(script)), Fee: 1200, ComputationUsed: 14},
+		{TransactionId: "c", ScriptHash: transactions.ScriptHash([]byte(otherScript)), Fee: 500, ComputationUsed: 2},
+		// Not executed yet
+		{TransactionId: "d", ScriptHash: transactions.ScriptHash([]byte(script))},
+	} {
+		tx := tx
+		if err := store.InsertTransaction(&tx); err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+	}
+
+	t.Run("from executed transactions of the script", func(t *testing.T) {
+		estimate, err := svc.Estimate(script, []transactions.Argument{`{"type":"UFix64","value":"1.0"}`}, transactions.WithComputeLimit(12))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := transactions.Estimate{
+			ComputeLimit:        12,
+			Computation:         14,
+			Fee:                 "0.00001200",
+			Basis:               transactions.EstimateBasisHistory,
+			Samples:             2,
+			ExceedsComputeLimit: true,
+		}
+		if *estimate != expected {
+			t.Errorf("expected %+v, got %+v", expected, *estimate)
+		}
+	})
+
+	t.Run("upper bound for other scripts", func(t *testing.T) {
+		estimate, err := svc.Estimate("transaction {}", nil, transactions.WithComputeLimit(100))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The highest fee per computation is 500 / 2
+		expected := transactions.Estimate{
+			ComputeLimit: 100,
+			Computation:  100,
+			Fee:          "0.00025000",
+			Basis:        transactions.EstimateBasisComputeLimit,
+		}
+		if *estimate != expected {
+			t.Errorf("expected %+v, got %+v", expected, *estimate)
+		}
+	})
+}
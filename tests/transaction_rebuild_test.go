@@ -0,0 +1,259 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/keys"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/transactions"
+	c_json "github.com/onflow/cadence/encoding/json"
+	"github.com/onflow/flow-go-sdk"
+	accessGrpc "github.com/onflow/flow-go-sdk/access/grpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rebuildKeyManager hands out the admin proposal key with the current
+// sequence number
+type rebuildKeyManager struct {
+	cosignKeyManager
+	sequenceNumber uint64
+	proposalKeys   int
+}
+
+func (km *rebuildKeyManager) proposalKey() keys.Authorizer {
+	km.proposalKeys++
+	a := km.admin
+	key := *a.Key
+	key.SequenceNumber = km.sequenceNumber
+	a.Key = &key
+	return a
+}
+
+func (km *rebuildKeyManager) AdminProposalKey(ctx context.Context) (keys.Authorizer, error) {
+	return km.proposalKey(), nil
+}
+
+func (km *rebuildKeyManager) PayerProposalKey(ctx context.Context, name string) (keys.Authorizer, error) {
+	return km.proposalKey(), nil
+}
+
+// rebuildWorkerPool keeps the registered executors, so jobs can be executed
+// by the test
+type rebuildWorkerPool struct {
+	cosignWorkerPool
+	executors map[string]jobs.ExecutorFunc
+}
+
+func (wp *rebuildWorkerPool) RegisterExecutor(jobType string, executorF jobs.ExecutorFunc, opts ...jobs.ExecutorOption) {
+	if wp.executors == nil {
+		wp.executors = make(map[string]jobs.ExecutorFunc)
+	}
+	wp.executors[jobType] = executorF
+}
+
+// rebuildFlowClient seals every sent transaction, unless it has been marked
+// as expired or failed
+type rebuildFlowClient struct {
+	flow_helpers.FlowClient
+	sent    []flow.Identifier
+	expired map[flow.Identifier]bool
+	failed  map[flow.Identifier]error
+}
+
+func (fc *rebuildFlowClient) isSent(id flow.Identifier) bool {
+	for _, s := range fc.sent {
+		if s == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (fc *rebuildFlowClient) GetLatestBlockHeader(ctx context.Context, isSealed bool, opts ...grpc.CallOption) (*flow.BlockHeader, error) {
+	return &flow.BlockHeader{ID: flow.Identifier{byte(len(fc.sent) + 1)}}, nil
+}
+
+func (fc *rebuildFlowClient) GetTransaction(ctx context.Context, id flow.Identifier, opts ...grpc.CallOption) (*flow.Transaction, error) {
+	if !fc.isSent(id) && !fc.expired[id] {
+		return nil, accessGrpc.RPCError{GRPCErr: status.Error(codes.NotFound, "transaction not found")}
+	}
+	return &flow.Transaction{}, nil
+}
+
+func (fc *rebuildFlowClient) GetTransactionResult(ctx context.Context, id flow.Identifier, opts ...grpc.CallOption) (*flow.TransactionResult, error) {
+	if fc.expired[id] {
+		return &flow.TransactionResult{Status: flow.TransactionStatusExpired}, nil
+	}
+	if err, ok := fc.failed[id]; ok {
+		return &flow.TransactionResult{Status: flow.TransactionStatusSealed, Error: err}, nil
+	}
+	return &flow.TransactionResult{Status: flow.TransactionStatusSealed}, nil
+}
+
+func (fc *rebuildFlowClient) SendTransaction(ctx context.Context, tx flow.Transaction, opts ...grpc.CallOption) error {
+	fc.sent = append(fc.sent, tx.ID())
+	return nil
+}
+
+func TestTransactionRebuild(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+
+	admin := flow.NewAddressGenerator(cfg.ChainID).NextAddress()
+	cfg.AdminAddress = admin.HexWithPrefix()
+
+	km := &rebuildKeyManager{cosignKeyManager: cosignKeyManager{admin: newInMemoryAuthorizer(t, admin, 1)}}
+	fc := &rebuildFlowClient{expired: make(map[flow.Identifier]bool), failed: make(map[flow.Identifier]error)}
+	wp := &rebuildWorkerPool{}
+	store := transactions.NewGormStore(db)
+	svc := transactions.NewService(cfg, store, km, fc, wp)
+
+	const script = "transaction(amount: UFix64) { prepare(a: AuthAccount) {} }"
+	args := []transactions.Argument{`{"type":"UFix64","value":"1.0"}`}
+
+	ctx := context.Background()
+
+	job, tx, err := svc.Create(ctx, false, cfg.AdminAddress, nil, script, args, transactions.General, transactions.WithComputeLimit(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tx.Code != script || tx.ComputeLimit != 100 || tx.Payer != keys.AdminPayerName {
+		t.Fatalf("expected the intent to be stored, got %+v", tx)
+	}
+
+	if len(tx.FlowTransaction) > 0 || tx.FlowTransactionId != "" || km.proposalKeys != 0 {
+		t.Fatalf("expected the transaction not to be built before its job runs, got %+v", tx)
+	}
+
+	// execute runs the job, which may fail with wantErr
+	execute := func(t *testing.T, wantErr bool) (*transactions.Transaction, *flow.Transaction) {
+		t.Helper()
+
+		err := wp.executors[transactions.TransactionJobType](ctx, job)
+		if (err != nil) != wantErr {
+			t.Fatalf("expected error %t, got %v", wantErr, err)
+		}
+
+		stored, err := store.Transaction(tx.TransactionId)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		flowTx, err := flow.DecodeTransaction(stored.FlowTransaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if flowTx.ID().Hex() != stored.FlowTransactionId {
+			t.Fatalf("expected the flow transaction id %s to match the stored transaction %s", stored.FlowTransactionId, flowTx.ID().Hex())
+		}
+
+		return &stored, flowTx
+	}
+
+	t.Run("built with the current sequence number", func(t *testing.T) {
+		// The sequence number was used by another transaction in the meantime
+		km.sequenceNumber++
+
+		stored, flowTx := execute(t, false)
+
+		if flowTx.ProposalKey.SequenceNumber != km.sequenceNumber {
+			t.Errorf("expected sequence number %d, got %d", km.sequenceNumber, flowTx.ProposalKey.SequenceNumber)
+		}
+
+		if string(flowTx.Script) != script || flowTx.GasLimit != 100 || len(flowTx.Arguments) != 1 {
+			t.Fatalf("expected the transaction to match the intent, got %+v", flowTx)
+		}
+
+		arg, err := c_json.Decode(nil, flowTx.Arguments[0])
+		if err != nil || arg.String() != "1.00000000" {
+			t.Errorf("expected the argument to match the intent, got %v, %v", arg, err)
+		}
+
+		if stored.FlowTransactionId == tx.TransactionId || len(stored.SupersededIds) != 0 {
+			t.Errorf("expected a flow transaction id and no superseded ids, got %+v", stored)
+		}
+
+		if len(fc.sent) != 1 || fc.sent[0] != flowTx.ID() {
+			t.Errorf("expected only the built transaction to be sent, got %v", fc.sent)
+		}
+
+		// Both ids resolve to the same transaction
+		byFlowId, err := store.Transaction(stored.FlowTransactionId)
+		if err != nil || byFlowId.TransactionId != tx.TransactionId {
+			t.Errorf("expected to find the transaction by its flow transaction id, got %+v, %v", byFlowId, err)
+		}
+
+		if got := store.GetOrCreateTransaction(stored.FlowTransactionId); got.TransactionId != tx.TransactionId {
+			t.Errorf("expected the existing transaction for its flow transaction id, got %+v", got)
+		}
+
+		var result transactions.TransactionJobResult
+		if err := json.Unmarshal(job.TypedResult, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result.TransactionID != tx.TransactionId || result.FlowTransactionID != stored.FlowTransactionId {
+			t.Errorf("unexpected job result %+v", result)
+		}
+	})
+
+	t.Run("not sent again once sealed", func(t *testing.T) {
+		before, _ := execute(t, false)
+		after, _ := execute(t, false)
+
+		if after.FlowTransactionId != before.FlowTransactionId || len(fc.sent) != 1 {
+			t.Errorf("expected the sealed transaction not to be sent again, sent %v", fc.sent)
+		}
+	})
+
+	// superseded runs the job after marking the current build with mark and
+	// checks that it was replaced by a new build
+	superseded := func(t *testing.T, mark func(id flow.Identifier)) {
+		before, _ := execute(t, false)
+		mark(flow.HexToID(before.FlowTransactionId))
+		sent := len(fc.sent)
+
+		after, _ := execute(t, false)
+
+		n := len(after.SupersededIds)
+		if after.FlowTransactionId == before.FlowTransactionId || n == 0 || after.SupersededIds[n-1] != before.FlowTransactionId {
+			t.Errorf("expected %s to be superseded, got %+v", before.FlowTransactionId, after)
+		}
+
+		if len(fc.sent) != sent+1 {
+			t.Errorf("expected the rebuilt transaction to be sent, sent %v", fc.sent)
+		}
+	}
+
+	t.Run("rebuilt when expired", func(t *testing.T) {
+		superseded(t, func(id flow.Identifier) {
+			fc.expired[id] = true
+		})
+	})
+
+	t.Run("rebuilt when rejected for its sequence number", func(t *testing.T) {
+		superseded(t, func(id flow.Identifier) {
+			fc.failed[id] = fmt.Errorf("[Error Code: 1007] invalid proposal key: public key 0 on account %s has sequence number 5, but given 4", admin)
+		})
+	})
+
+	t.Run("not rebuilt when failed otherwise", func(t *testing.T) {
+		before, _ := execute(t, false)
+		fc.failed[flow.HexToID(before.FlowTransactionId)] = fmt.Errorf("[Error Code: 1101] cadence runtime error")
+		sent := len(fc.sent)
+
+		after, _ := execute(t, true)
+
+		if after.FlowTransactionId != before.FlowTransactionId || len(fc.sent) != sent {
+			t.Errorf("expected the failed transaction not to be sent again, got %+v", after)
+		}
+	})
+}
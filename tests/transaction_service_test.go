@@ -15,7 +15,7 @@ func Test_TransactionSignByAdmin(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	txSvc := test.GetServices(t, cfg).GetTransactions()
 
-	tx, err := txSvc.Sign(context.Background(), cfg.AdminAddress, "", nil)
+	tx, err := txSvc.Sign(context.Background(), cfg.AdminAddress, nil, "", nil)
 	if err != nil {
 		t.Fatalf("expected err == nil, got %#v", err)
 	}
@@ -40,12 +40,12 @@ func Test_TransactionSignByAnotherAccount(t *testing.T) {
 	cfg := test.LoadConfig(t)
 	svcs := test.GetServices(t, cfg)
 
-	_, acc, err := svcs.GetAccounts().Create(ctx, true)
+	_, acc, err := svcs.GetAccounts().Create(ctx, true, "")
 	if err != nil {
 		t.Fatalf("expected err == nil, got %#v", err)
 	}
 
-	tx, err := svcs.GetTransactions().Sign(ctx, acc.Address, "", nil)
+	tx, err := svcs.GetTransactions().Sign(ctx, acc.Address, nil, "", nil)
 	if err != nil {
 		t.Fatalf("expected err == nil, got %#v", err)
 	}
@@ -66,6 +66,60 @@ func Test_TransactionSignByAnotherAccount(t *testing.T) {
 	}
 }
 
+func Test_TransactionSignByMultipleAuthorizers(t *testing.T) {
+	ctx := context.Background()
+	cfg := test.LoadConfig(t)
+	svcs := test.GetServices(t, cfg)
+	txSvc := svcs.GetTransactions()
+
+	_, acc1, err := svcs.GetAccounts().Create(ctx, true, "")
+	if err != nil {
+		t.Fatalf("expected err == nil, got %#v", err)
+	}
+
+	_, acc2, err := svcs.GetAccounts().Create(ctx, true, "")
+	if err != nil {
+		t.Fatalf("expected err == nil, got %#v", err)
+	}
+
+	authorizers := []string{acc2.Address, acc1.Address, cfg.AdminAddress}
+
+	tx, err := txSvc.Sign(ctx, acc1.Address, authorizers, "", nil)
+	if err != nil {
+		t.Fatalf("expected err == nil, got %#v", err)
+	}
+
+	// Verify that the authorizers are kept in the requested order.
+	if len(tx.Authorizers) != len(authorizers) {
+		t.Fatalf("expected %d authorizers, got %d", len(authorizers), len(tx.Authorizers))
+	}
+	for i, a := range authorizers {
+		if tx.Authorizers[i] != flow.HexToAddress(a) {
+			t.Errorf("expected authorizer %d to be %s, got %s", i, a, tx.Authorizers[i])
+		}
+	}
+
+	// Verify that every authorizer other than the payer signs the payload.
+	for _, a := range []string{acc1.Address, acc2.Address} {
+		if !addressExists(a, tx.PayloadSignatures) {
+			t.Errorf("couldn't find authorizer %s from payload signatures", a)
+		}
+	}
+
+	// Verify that the payer signs only the envelope.
+	if addressExists(cfg.AdminAddress, tx.PayloadSignatures) {
+		t.Error("expected payer not to sign the payload")
+	}
+	if !addressExists(cfg.AdminAddress, tx.EnvelopeSignatures) {
+		t.Error("couldn't find payer's address from envelope signatures")
+	}
+
+	// Verify that duplicate authorizers are rejected.
+	if _, err := txSvc.Sign(ctx, acc1.Address, []string{acc2.Address, acc2.Address}, "", nil); err == nil {
+		t.Fatal("expected an error for duplicate authorizers")
+	}
+}
+
 func addressExists(addr string, sigs []flow.TransactionSignature) bool {
 	addr = strings.TrimPrefix(addr, "0x")
 	for _, s := range sigs {
@@ -82,24 +136,15 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 		cfg := test.LoadConfig(t)
 		app := test.GetServices(t, cfg)
 		txSvc := app.GetTransactions()
-		system := app.GetSystem()
-
-		// Pause, so the transaction won't get automatically sent
-		if err := system.Pause(); err != nil {
-			t.Fatal(err)
-		}
 
 		ctx := context.Background()
 
-		_, tx, err := txSvc.Create(ctx, false, cfg.AdminAddress, "transaction() { prepare(signer: &Account){} execute {}}", nil, transactions.General)
+		tx, err := txSvc.Sign(ctx, cfg.AdminAddress, nil, "transaction() { prepare(signer: &Account){} execute {}}", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
-		if err != nil {
-			t.Fatal(err)
-		}
+		flowTx := &tx.Transaction
 
 		// Update the sequence number
 		flowTx.
@@ -113,8 +158,6 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 	})
 
 	t.Run("update sequence number during job run", func(t *testing.T) {
-		t.Skip("not supported currently")
-
 		cfg := test.LoadConfig(t)
 		cfg.AdminProposalKeyCount = 1
 		cfg.WorkerCount = 1
@@ -129,32 +172,20 @@ func Test_TransactionProposalKeySequenceNumber(t *testing.T) {
 
 		ctx := context.Background()
 
-		job1, tx1, err := txSvc.Create(ctx, false, cfg.AdminAddress, "transaction() { prepare(signer: &Account){} execute {}}", nil, transactions.General)
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		job2, tx2, err := txSvc.Create(ctx, false, cfg.AdminAddress, "transaction() { prepare(signer: &Account){} execute {}}", nil, transactions.General)
+		job1, tx1, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, "transaction() { prepare(signer: &Account){} execute {}}", nil, transactions.General)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		flowTx1, err := flow.DecodeTransaction(tx1.FlowTransaction)
+		job2, tx2, err := txSvc.Create(ctx, false, cfg.AdminAddress, nil, "transaction() { prepare(signer: &Account){} execute {}}", nil, transactions.General)
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		flowTx2, err := flow.DecodeTransaction(tx2.FlowTransaction)
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		if flowTx1.ProposalKey.KeyIndex != flowTx2.ProposalKey.KeyIndex {
-			t.Fatal("expected key indexes to match")
-		}
-
-		if flowTx1.ProposalKey.SequenceNumber != flowTx2.ProposalKey.SequenceNumber {
-			t.Fatal("expected sequence numbers to match")
+		// Both are built with the then current sequence number when their jobs
+		// run, not when they are created
+		if len(tx1.FlowTransaction) > 0 || len(tx2.FlowTransaction) > 0 {
+			t.Fatal("expected the transactions not to be built before their jobs run")
 		}
 
 		// Resume when both transactions are in the queue
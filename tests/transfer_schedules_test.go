@@ -0,0 +1,294 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/schedules"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	"github.com/numeroai/flow-wallet-api/transactions"
+)
+
+// withdrawalRecorder records withdrawals instead of sending them to the chain
+type withdrawalRecorder struct {
+	tokens.Service
+	requests []tokens.WithdrawalRequest
+	err      error
+	onCreate func()
+}
+
+func (r *withdrawalRecorder) CreateWithdrawal(ctx context.Context, sync bool, sender string, request tokens.WithdrawalRequest) (*jobs.Job, *transactions.Transaction, error) {
+	if r.onCreate != nil {
+		r.onCreate()
+	}
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	r.requests = append(r.requests, request)
+	return &jobs.Job{ID: uuid.New(), Type: tokens.WithdrawalCreateJobType}, nil, nil
+}
+
+func TestTransferSchedules(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	store := schedules.NewGormStore(db)
+	recorder := &withdrawalRecorder{}
+	svc := schedules.NewService(cfg, store, recorder)
+	ctx := context.Background()
+
+	sender := "0xf8d6e0586b0a20c7"
+	recipient := "0x01cf0e2f2f715450"
+
+	create := func(t *testing.T, req schedules.JSONRequest) *schedules.Schedule {
+		t.Helper()
+		sch, err := svc.Create(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sch
+	}
+
+	// Moves the next occurrence of a schedule to the past
+	makeDue := func(t *testing.T, sch *schedules.Schedule, ago time.Duration) {
+		t.Helper()
+		s, err := store.Schedule(sch.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.NextRunAt = sql.NullTime{Time: time.Now().Add(-ago), Valid: true}
+		if err := store.UpdateSchedule(&s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assertStatus := func(t *testing.T, err error, status int) {
+		t.Helper()
+		if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != status {
+			t.Fatalf("expected a %d error, got %v", status, err)
+		}
+	}
+
+	t.Run("invalid schedules", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		requests := []schedules.JSONRequest{
+			{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "every monday"},
+			{Sender: "0x1", Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "0 9 * * MON"},
+			{Sender: sender, Recipient: recipient, TokenName: "FUSD", Cron: "0 9 * * MON"},
+			{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "0 9 * * MON", EndAt: &past},
+		}
+		for _, req := range requests {
+			_, err := svc.Create(req)
+			assertStatus(t, err, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("due occurrence creates a single withdrawal", func(t *testing.T) {
+		recorder.requests = nil
+		sch := create(t, schedules.JSONRequest{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "0 9 * * MON"})
+
+		if sch.State != schedules.Active || !sch.NextRunAt.Valid || sch.NextRunAt.Time.Weekday() != time.Monday {
+			t.Fatalf("expected an active schedule running next monday, got %+v", sch)
+		}
+
+		// Missed occurrences are coalesced into one withdrawal
+		makeDue(t, sch, 21*24*time.Hour)
+
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(recorder.requests) != 1 {
+			t.Fatalf("expected 1 withdrawal, got %d", len(recorder.requests))
+		}
+		if r := recorder.requests[0]; r.Recipient != recipient || r.FtAmount != "1.0" || r.TokenName != "FUSD" {
+			t.Fatalf("unexpected withdrawal request %+v", r)
+		}
+
+		runs, err := svc.Runs(sch.ID.String(), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || !runs[0].JobID.Valid {
+			t.Fatalf("expected 1 run with a job, got %+v", runs)
+		}
+
+		sch, err = svc.Details(sch.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sch.NextRunAt.Time.After(time.Now()) {
+			t.Fatalf("expected next run to be in the future, got %s", sch.NextRunAt.Time)
+		}
+	})
+
+	t.Run("failed withdrawal is recorded", func(t *testing.T) {
+		recorder.err = errors.New("insufficient balance")
+		defer func() { recorder.err = nil }()
+
+		sch := create(t, schedules.JSONRequest{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "*/5 * * * *"})
+		makeDue(t, sch, time.Minute)
+
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		runs, err := svc.Runs(sch.ID.String(), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || runs[0].JobID.Valid || runs[0].Error != "insufficient balance" {
+			t.Fatalf("expected 1 run with an error, got %+v", runs)
+		}
+	})
+
+	t.Run("occurrence is recorded before the withdrawal is created", func(t *testing.T) {
+		sch := create(t, schedules.JSONRequest{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "*/5 * * * *"})
+		makeDue(t, sch, time.Minute)
+
+		var pending []schedules.Run
+		recorder.onCreate = func() {
+			var err error
+			pending, err = svc.Runs(sch.ID.String(), 0, 0)
+			if err != nil {
+				t.Error(err)
+			}
+		}
+		defer func() { recorder.onCreate = nil }()
+
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(pending) != 1 || pending[0].JobID.Valid || pending[0].Error != "" {
+			t.Fatalf("expected a pending run while creating the withdrawal, got %+v", pending)
+		}
+
+		runs, err := svc.Runs(sch.ID.String(), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 1 || runs[0].ID != pending[0].ID || !runs[0].JobID.Valid {
+			t.Fatalf("expected the pending run to be finished with a job, got %+v", runs)
+		}
+	})
+
+	t.Run("interrupted run fails", func(t *testing.T) {
+		recorder.requests = nil
+		sch := create(t, schedules.JSONRequest{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "0 9 * * MON"})
+
+		// Runs of an instance which stopped before creating the withdrawal
+		interrupted := &schedules.Run{ScheduleID: sch.ID, ScheduledFor: time.Now().Add(-time.Hour), CreatedAt: time.Now().Add(-time.Hour)}
+		recent := &schedules.Run{ScheduleID: sch.ID, ScheduledFor: time.Now()}
+		for _, r := range []*schedules.Run{interrupted, recent} {
+			if err := store.InsertRun(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(recorder.requests) != 0 {
+			t.Fatalf("expected pending runs not to be retried, got %d withdrawals", len(recorder.requests))
+		}
+
+		runs, err := svc.Runs(sch.ID.String(), 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != 2 {
+			t.Fatalf("expected 2 runs, got %+v", runs)
+		}
+		for _, r := range runs {
+			switch r.ID {
+			case interrupted.ID:
+				if r.Error == "" {
+					t.Errorf("expected the interrupted run to fail, got %+v", r)
+				}
+			case recent.ID:
+				if r.Error != "" {
+					t.Errorf("expected the recent run to stay pending, got %+v", r)
+				}
+			}
+		}
+	})
+
+	t.Run("paused schedule is skipped until resumed", func(t *testing.T) {
+		recorder.requests = nil
+		sch := create(t, schedules.JSONRequest{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "*/5 * * * *"})
+
+		if _, err := svc.Pause(sch.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+		makeDue(t, sch, time.Minute)
+
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(recorder.requests) != 0 {
+			t.Fatalf("expected no withdrawals, got %d", len(recorder.requests))
+		}
+
+		resumed, err := svc.Resume(sch.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resumed.State != schedules.Active || !resumed.NextRunAt.Time.After(time.Now()) {
+			t.Fatalf("expected schedule to resume from a future occurrence, got %+v", resumed)
+		}
+	})
+
+	t.Run("schedule ends after its last occurrence", func(t *testing.T) {
+		recorder.requests = nil
+		sch := create(t, schedules.JSONRequest{Sender: sender, Recipient: recipient, TokenName: "FUSD", FtAmount: "1.0", Cron: "*/5 * * * *"})
+
+		// The last occurrence before the end date is due
+		s, err := store.Schedule(sch.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.NextRunAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+		s.EndAt = sql.NullTime{Time: time.Now(), Valid: true}
+		if err := store.UpdateSchedule(&s); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := svc.RunDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if len(recorder.requests) != 1 {
+			t.Fatalf("expected 1 withdrawal, got %d", len(recorder.requests))
+		}
+
+		sch, err = svc.Details(sch.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sch.State != schedules.Ended || sch.NextRunAt.Valid {
+			t.Fatalf("expected schedule to end, got %+v", sch)
+		}
+
+		_, err = svc.Resume(sch.ID.String())
+		assertStatus(t, err, http.StatusConflict)
+	})
+
+	t.Run("unknown schedule", func(t *testing.T) {
+		_, err := svc.Pause(uuid.New().String())
+		assertStatus(t, err, http.StatusNotFound)
+
+		_, err = svc.Details("not-an-id")
+		assertStatus(t, err, http.StatusBadRequest)
+	})
+}
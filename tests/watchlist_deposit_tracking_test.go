@@ -30,7 +30,7 @@ func Test_NonCustodialAccountDepositTracking(t *testing.T) {
 
 	nonCustodialAccount := test.NewFlowAccount(t, fc, adminAuthorizer.Address, adminAuthorizer.Key, adminAuthorizer.Signer)
 
-	_, custodialAccount, err := accountSvc.Create(context.Background(), true)
+	_, custodialAccount, err := accountSvc.Create(context.Background(), true, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -2,11 +2,13 @@ package tests
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/numeroai/flow-wallet-api/datastore"
 	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/numeroai/flow-wallet-api/tests/test"
 	"github.com/google/uuid"
@@ -116,6 +118,10 @@ func Test_WorkerPoolExecutesJobWithError(t *testing.T) {
 	if job.State != jobs.Error {
 		t.Fatalf("expected job.State = %q, got %q", jobs.Error, job.State)
 	}
+
+	if !job.NextRunAt.Valid || !job.NextRunAt.Time.After(job.UpdatedAt) {
+		t.Fatalf("expected job.NextRunAt to be set after the error, got %v", job.NextRunAt)
+	}
 }
 
 func Test_WorkerPoolExecutesJobWithPermanentError(t *testing.T) {
@@ -291,6 +297,46 @@ func Test_WorkerPoolPicksUpErroredJob(t *testing.T) {
 	}
 }
 
+func Test_SchedulableJobsRespectsNextRunAt(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+
+	t0 := time.Now()
+	due := &jobs.Job{
+		State:     jobs.Error,
+		Type:      "job",
+		ExecCount: 1,
+		NextRunAt: sql.NullTime{Time: t0.Add(-time.Second), Valid: true},
+	}
+	backingOff := &jobs.Job{
+		State:     jobs.Error,
+		Type:      "job",
+		ExecCount: 5,
+		NextRunAt: sql.NullTime{Time: t0.Add(time.Hour), Valid: true},
+	}
+
+	for _, j := range []*jobs.Job{due, backingOff} {
+		if err := db.Create(j).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Make both jobs look old enough for the flat grace period
+	if err := db.Model(&jobs.Job{}).Where("1 = 1").UpdateColumn("updated_at", t0.Add(-10*time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	jj, err := jobStore.SchedulableJobs(time.Minute, time.Minute, datastore.ParseListOptions(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jj) != 1 || jj[0].ID != due.ID {
+		t.Fatalf("expected only job %s to be schedulable, got %v", due.ID, jj)
+	}
+}
+
 func Test_WorkerPoolDoesntPickupFailedJob(t *testing.T) {
 	// XXX: This test is very much a best effort case. There are several
 	// theoretical glitches here that make this a bit unreliable. There's a
@@ -411,3 +457,56 @@ func Test_ExceedingWorkerpoolCapacity(t *testing.T) {
 		t.Errorf("expected job.State = %q, got %q", jobs.NoAvailableWorkers, j.State)
 	}
 }
+
+func Test_WorkerPoolRespectsJobTypeConcurrency(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+
+	wp := jobs.NewWorkerPool(jobStore, 10, 4, jobs.WithJobTypeConcurrency(map[string]int{"limited": 1}))
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	executedWG := &sync.WaitGroup{}
+	wp.RegisterExecutor("limited", func(ctx context.Context, j *jobs.Job) error {
+		defer executedWG.Done()
+
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		return nil
+	})
+
+	for i := 0; i < 3; i++ {
+		executedWG.Add(1)
+		j, err := wp.CreateJob("limited", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := wp.Schedule(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	executedWG.Wait()
+
+	if maxRunning != 1 {
+		t.Fatalf("expected at most 1 concurrent job, got %d", maxRunning)
+	}
+}
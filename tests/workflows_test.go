@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numeroai/flow-wallet-api/accounts"
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tests/test"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	"github.com/numeroai/flow-wallet-api/transactions"
+	"github.com/numeroai/flow-wallet-api/workflows"
+)
+
+// accountCreator creates accounts without a chain
+type accountCreator struct {
+	accounts.Service
+}
+
+func (accountCreator) Create(ctx context.Context, sync bool, payer string, opts ...jobs.JobOption) (*jobs.Job, *accounts.Account, error) {
+	return nil, &accounts.Account{Address: "0x01cf0e2f2f715450"}, nil
+}
+
+// onboardingRecorder sets up vaults and funds accounts without a chain
+type onboardingRecorder struct {
+	tokens.Service
+
+	mu          sync.Mutex
+	setups      map[string]string // token name -> address
+	withdrawals []tokens.WithdrawalRequest
+}
+
+func (r *onboardingRecorder) Setup(ctx context.Context, sync bool, tokenName, address string, opts ...transactions.TransactionOption) (*jobs.Job, *transactions.Transaction, error) {
+	if tokenName == "Unknown" {
+		return nil, nil, &wallet_errors.RequestError{StatusCode: http.StatusNotFound, Err: fmt.Errorf("token not found")}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setups[tokenName] = address
+	return nil, &transactions.Transaction{TransactionId: "tx-setup-" + tokenName}, nil
+}
+
+func (r *onboardingRecorder) CreateWithdrawal(ctx context.Context, sync bool, sender string, request tokens.WithdrawalRequest) (*jobs.Job, *transactions.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.withdrawals = append(r.withdrawals, request)
+	return nil, &transactions.Transaction{TransactionId: "tx-withdrawal"}, nil
+}
+
+func TestWorkflows(t *testing.T) {
+	cfg := test.LoadConfig(t)
+	db := test.GetDatabase(t, cfg)
+	jobStore := jobs.NewGormStore(db)
+	wp := jobs.NewWorkerPool(jobStore, 10, 2, jobs.WithMaxJobErrorCount(0))
+	jobSvc := jobs.NewService(jobStore, wp)
+	recorder := &onboardingRecorder{setups: make(map[string]string)}
+	svc := workflows.NewService(workflows.NewGormStore(db), wp, jobSvc, accountCreator{}, recorder)
+
+	t.Cleanup(func() {
+		wp.Stop(false)
+	})
+	wp.Start()
+
+	waitForWorkflow := func(t *testing.T, id string) *workflows.JSONResponse {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			w, err := svc.Details(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.State != workflows.Running {
+				return w
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected workflow %s to finish, got %+v", id, w)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	assertStatus := func(t *testing.T, err error, status int) {
+		t.Helper()
+		if reqErr, ok := err.(*wallet_errors.RequestError); !ok || reqErr.StatusCode != status {
+			t.Fatalf("expected a %d error, got %v", status, err)
+		}
+	}
+
+	t.Run("onboarding uses the created account", func(t *testing.T) {
+		res, err := svc.Create(workflows.JSONRequest{Steps: []workflows.StepRequest{
+			{Name: "fund", Operation: workflows.OpWithdrawalCreate, DependsOn: []string{"fusd"}, Params: map[string]string{
+				"sender": "0xf8d6e0586b0a20c7", "tokenName": "FUSD", "recipient": "{{account.result}}", "amount": "10.0",
+			}},
+			{Name: "account", Operation: workflows.OpAccountCreate},
+			{Name: "fusd", Operation: workflows.OpTokenSetup, DependsOn: []string{"account"}, Params: map[string]string{"address": "{{account.result}}", "tokenName": "FUSD"}},
+			{Name: "nft", Operation: workflows.OpTokenSetup, DependsOn: []string{"account"}, Params: map[string]string{"address": "{{account.result}}", "tokenName": "ExampleNFT"}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if res.Steps[0].Name != "fund" || res.Steps[0].State != jobs.Blocked {
+			t.Fatalf("expected steps in request order with fund blocked, got %+v", res.Steps)
+		}
+
+		w := waitForWorkflow(t, res.ID.String())
+		if w.State != workflows.Complete {
+			t.Fatalf("expected workflow to complete, got %+v", w)
+		}
+
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+
+		if recorder.setups["FUSD"] != "0x01cf0e2f2f715450" || recorder.setups["ExampleNFT"] != "0x01cf0e2f2f715450" {
+			t.Fatalf("expected vaults to be set up for the created account, got %v", recorder.setups)
+		}
+		if len(recorder.withdrawals) != 1 || recorder.withdrawals[0].Recipient != "0x01cf0e2f2f715450" {
+			t.Fatalf("expected the created account to be funded, got %v", recorder.withdrawals)
+		}
+		if w.Steps[0].Result != "tx-withdrawal" {
+			t.Fatalf("expected step result to be the transaction id, got %q", w.Steps[0].Result)
+		}
+	})
+
+	t.Run("failed step skips the following steps", func(t *testing.T) {
+		res, err := svc.Create(workflows.JSONRequest{Ordered: true, Steps: []workflows.StepRequest{
+			{Operation: workflows.OpAccountCreate},
+			{Operation: workflows.OpTokenSetup, Params: map[string]string{"address": "{{0.result}}", "tokenName": "Unknown"}},
+			{Operation: workflows.OpTokenSetup, Params: map[string]string{"address": "{{0.result}}", "tokenName": "FUSD"}},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w := waitForWorkflow(t, res.ID.String())
+		if w.State != workflows.Failed {
+			t.Fatalf("expected workflow to fail, got %+v", w)
+		}
+
+		states := []jobs.State{w.Steps[0].State, w.Steps[1].State, w.Steps[2].State}
+		if states[0] != jobs.Complete || states[1] != jobs.Failed || states[2] != jobs.Skipped {
+			t.Fatalf("expected states COMPLETE, FAILED, SKIPPED, got %v", states)
+		}
+	})
+
+	t.Run("invalid workflows", func(t *testing.T) {
+		requests := []workflows.JSONRequest{
+			{},
+			{Steps: []workflows.StepRequest{{Operation: "account_delete"}}},
+			{Steps: []workflows.StepRequest{{Operation: workflows.OpTokenSetup, Params: map[string]string{"address": "0x01"}}}},
+			{Steps: []workflows.StepRequest{{Name: "a", Operation: workflows.OpAccountCreate, DependsOn: []string{"b"}}}},
+			{Steps: []workflows.StepRequest{
+				{Name: "a", Operation: workflows.OpAccountCreate, DependsOn: []string{"b"}},
+				{Name: "b", Operation: workflows.OpAccountCreate, DependsOn: []string{"a"}},
+			}},
+			{Steps: []workflows.StepRequest{
+				{Name: "a", Operation: workflows.OpAccountCreate},
+				{Name: "b", Operation: workflows.OpTokenSetup, Params: map[string]string{"address": "{{a.result}}", "tokenName": "FUSD"}},
+			}},
+			{Ordered: true, Steps: []workflows.StepRequest{
+				{Name: "a", Operation: workflows.OpAccountCreate},
+				{Name: "b", Operation: workflows.OpAccountCreate, DependsOn: []string{"a"}},
+			}},
+		}
+		for _, req := range requests {
+			_, err := svc.Create(req)
+			assertStatus(t, err, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("unknown workflow", func(t *testing.T) {
+		_, err := svc.Details("8c3fb6f1-8c1a-4f7d-9d0f-0e6b1c2d3e4f")
+		assertStatus(t, err, http.StatusNotFound)
+	})
+}
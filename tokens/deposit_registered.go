@@ -0,0 +1,43 @@
+package tokens
+
+import (
+	"github.com/numeroai/flow-wallet-api/templates"
+	log "github.com/sirupsen/logrus"
+)
+
+type DepositRegisteredPayload struct {
+	TransactionId    string
+	RecipientAddress string
+	SenderAddress    string
+	TokenName        string
+	TokenType        templates.TokenType
+	FtAmount         string
+	NftID            uint64
+}
+
+type depositRegisteredHandler interface {
+	Handle(DepositRegisteredPayload)
+}
+
+type depositRegistered struct {
+	handlers []depositRegisteredHandler
+}
+
+var DepositRegistered depositRegistered // singleton of type depositRegistered
+
+// Register adds an event handler for this event
+func (e *depositRegistered) Register(handler depositRegisteredHandler) {
+	log.Debug("Registering DepositRegistered event handler")
+	e.handlers = append(e.handlers, handler)
+}
+
+// Trigger sends out an event with the payload
+func (e *depositRegistered) Trigger(payload DepositRegisteredPayload) {
+	log.
+		WithFields(log.Fields{"payload": payload}).
+		Trace("Handling DepositRegistered event")
+
+	for _, handler := range e.handlers {
+		go handler.Handle(payload)
+	}
+}
@@ -9,6 +9,12 @@ import (
 
 const WithdrawalCreateJobType = "withdrawal_create"
 
+// WithdrawalJobResult is the typed result of withdrawal jobs, the legacy
+// result is the transaction ID.
+type WithdrawalJobResult struct {
+	TransactionID string `json:"transactionId"`
+}
+
 type withdrawalCreateJobAttributes struct {
 	Sender  string
 	Request WithdrawalRequest
@@ -34,5 +40,5 @@ func (s *ServiceImpl) executeCreateWithdrawalJob(ctx context.Context, j *jobs.Jo
 	j.TransactionID = transaction.TransactionId
 	j.Result = transaction.TransactionId
 
-	return nil
+	return j.SetTypedResult(WithdrawalJobResult{TransactionID: transaction.TransactionId})
 }
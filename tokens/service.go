@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/numeroai/flow-wallet-api/accounts"
 	"github.com/numeroai/flow-wallet-api/configs"
+	"github.com/numeroai/flow-wallet-api/errors"
 	"github.com/numeroai/flow-wallet-api/flow_helpers"
 	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/numeroai/flow-wallet-api/keys"
@@ -25,7 +27,7 @@ const (
 )
 
 type Service interface {
-	Setup(ctx context.Context, sync bool, tokenName, address string) (*jobs.Job, *transactions.Transaction, error)
+	Setup(ctx context.Context, sync bool, tokenName, address string, opts ...transactions.TransactionOption) (*jobs.Job, *transactions.Transaction, error)
 	AddAccountToken(tokenName, address string) error
 	AccountTokens(address string, tType templates.TokenType) ([]AccountToken, error)
 	Details(ctx context.Context, tokenName, address string) (*Details, error)
@@ -70,12 +72,14 @@ func NewService(
 	}
 
 	// Register asynchronous job executor.
-	wp.RegisterExecutor(WithdrawalCreateJobType, svc.executeCreateWithdrawalJob)
+	wp.RegisterExecutor(WithdrawalCreateJobType, svc.executeCreateWithdrawalJob,
+		jobs.WithAttributesSchema(withdrawalCreateJobAttributes{}),
+		jobs.WithResultSchema(WithdrawalJobResult{}))
 
 	return svc
 }
 
-func (s *ServiceImpl) Setup(ctx context.Context, sync bool, tokenName, address string) (*jobs.Job, *transactions.Transaction, error) {
+func (s *ServiceImpl) Setup(ctx context.Context, sync bool, tokenName, address string, opts ...transactions.TransactionOption) (*jobs.Job, *transactions.Transaction, error) {
 	// Check if the input is a valid address
 	address, err := flow_helpers.ValidateAddress(address, s.cfg.ChainID)
 	if err != nil {
@@ -102,7 +106,7 @@ func (s *ServiceImpl) Setup(ctx context.Context, sync bool, tokenName, address s
 		txType = transactions.NftSetup
 	}
 
-	job, tx, err := s.transactions.Create(ctx, sync, address, token.Setup, nil, txType)
+	job, tx, err := s.transactions.Create(ctx, sync, address, nil, token.Setup, nil, txType, opts...)
 
 	if err == nil || strings.Contains(err.Error(), "vault exists") {
 		// Handle adding token to account in database
@@ -189,6 +193,20 @@ func (s *ServiceImpl) Details(ctx context.Context, tokenName, address string) (*
 func (s *ServiceImpl) CreateWithdrawal(ctx context.Context, sync bool, sender string, request WithdrawalRequest) (*jobs.Job, *transactions.Transaction, error) {
 	log.WithFields(log.Fields{"sync": sync}).Trace("Create withdrawal")
 
+	if sync && request.RunAt != nil {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("runAt can not be used with sync"),
+		}
+	}
+
+	if request.ComputeLimit > s.cfg.MaxComputeLimit {
+		return nil, nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("compute limit must be at most %d", s.cfg.MaxComputeLimit),
+		}
+	}
+
 	if !sync {
 		// Async
 		attrs := withdrawalCreateJobAttributes{sender, request}
@@ -197,7 +215,12 @@ func (s *ServiceImpl) CreateWithdrawal(ctx context.Context, sync bool, sender st
 			return nil, nil, err
 		}
 
-		job, err := s.wp.CreateJob(WithdrawalCreateJobType, "", jobs.WithAttributes(attrBytes))
+		opts := []jobs.JobOption{jobs.WithAttributes(attrBytes)}
+		if request.RunAt != nil {
+			opts = append(opts, jobs.WithRunAt(*request.RunAt))
+		}
+
+		job, err := s.wp.CreateJob(WithdrawalCreateJobType, "", opts...)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -407,6 +430,16 @@ func (s *ServiceImpl) RegisterDeposit(ctx context.Context, token *templates.Toke
 		return err
 	}
 
+	DepositRegistered.Trigger(DepositRegisteredPayload{
+		TransactionId:    transfer.TransactionId,
+		RecipientAddress: transfer.RecipientAddress,
+		SenderAddress:    transfer.SenderAddress,
+		TokenName:        token.Name,
+		TokenType:        token.Type,
+		FtAmount:         transfer.FtAmount,
+		NftID:            transfer.NftID,
+	})
+
 	return nil
 }
 
@@ -451,7 +484,8 @@ func (s *ServiceImpl) createWithdrawal(ctx context.Context, sender string, reque
 	}
 
 	// Create the transaction, must be sync here
-	_, transaction, err := s.transactions.Create(ctx, true, sender, token.Transfer, arguments, txType)
+	_, transaction, err := s.transactions.Create(ctx, true, sender, nil, token.Transfer, arguments, txType,
+		transactions.WithPayer(request.Payer), transactions.WithComputeLimit(request.ComputeLimit))
 	if err != nil {
 		return nil, err
 	}
@@ -18,10 +18,14 @@ type Details struct {
 }
 
 type WithdrawalRequest struct {
-	TokenName string `json:"tokenName,omitempty"`
-	Recipient string `json:"recipient"`
-	FtAmount  string `json:"amount,omitempty"`
-	NftID     uint64 `json:"nftId,omitempty"`
+	TokenName string     `json:"tokenName,omitempty"`
+	Recipient string     `json:"recipient"`
+	FtAmount  string     `json:"amount,omitempty"`
+	NftID     uint64     `json:"nftId,omitempty"`
+	RunAt     *time.Time `json:"runAt,omitempty"` // Delays an asynchronous withdrawal
+	Payer     string     `json:"payer,omitempty"` // Defaults to the payer of the sender
+	// Defaults to the configured maximum compute limit
+	ComputeLimit uint64 `json:"computeLimit,omitempty"`
 }
 
 // AccountToken represents a token that is enabled on an account.
@@ -0,0 +1,168 @@
+package transactions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// CoSignJSONRequest is the JSON HTTP request of an externally signed
+// transaction.
+type CoSignJSONRequest struct {
+	// RLP encoded flow.Transaction as hex
+	Transaction string `json:"transaction"`
+	// Name of the payer, defaults to the payer of the proposer
+	Payer string `json:"payer"`
+}
+
+// ScriptHash returns the hex encoded SHA-256 hash of a transaction script,
+// as listed in "CoSignAllowedScripts". Executed transactions are recorded
+// with the hash of their script for estimates.
+func ScriptHash(script []byte) string {
+	h := sha256.Sum256(script)
+	return hex.EncodeToString(h[:])
+}
+
+func (s *ServiceImpl) CoSign(ctx context.Context, sync bool, encodedTx string, opts ...TransactionOption) (*jobs.Job, *Transaction, error) {
+	flowTx, err := decodeTransaction(encodedTx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payerName, err := s.payerName(flowTx.ProposalKey.Address.Hex(), opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payer, err := s.km.PayerAuthorizer(ctx, payerName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while getting payer authorizer: %w", err)
+	}
+
+	if err := s.checkCoSignPolicy(flowTx, payer.Address); err != nil {
+		return nil, nil, err
+	}
+
+	// Payer signs the envelope, which changes the transaction id. Resubmitting
+	// the same transaction is rejected by the network, as the sequence number
+	// of the proposal key has been used already.
+	if err := flowTx.SignEnvelope(payer.Address, payer.Key.Index, payer.Signer); err != nil {
+		return nil, nil, err
+	}
+
+	transaction := &Transaction{
+		TransactionId:     flowTx.ID().Hex(),
+		TransactionType:   General,
+		ProposerAddress:   flow_helpers.FormatAddress(flowTx.ProposalKey.Address),
+		Payer:             payerName,
+		FlowTransaction:   flowTx.Encode(),
+		FlowTransactionId: flowTx.ID().Hex(),
+	}
+
+	return s.submit(ctx, sync, transaction)
+}
+
+func decodeTransaction(encodedTx string) (*flow.Transaction, error) {
+	invalidErr := &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("not a valid encoded transaction"),
+	}
+
+	b, err := hex.DecodeString(strings.TrimPrefix(encodedTx, "0x"))
+	if err != nil || len(b) == 0 {
+		return nil, invalidErr
+	}
+
+	flowTx, err := flow.DecodeTransaction(b)
+	if err != nil {
+		return nil, invalidErr
+	}
+
+	return flowTx, nil
+}
+
+// checkCoSignPolicy makes sure the payer account only pays for an externally
+// signed transaction. As the payer signature also counts for the proposal key
+// and the authorizations of the payer, the payer account may not propose or
+// authorize it. Without allowed scripts no transaction is co-signed, unless
+// any script is allowed explicitly.
+func (s *ServiceImpl) checkCoSignPolicy(flowTx *flow.Transaction, payer flow.Address) error {
+	if len(s.cfg.CoSignAllowedScripts) == 0 && !s.cfg.CoSignAllowAnyScript {
+		return &errors.RequestError{
+			StatusCode: http.StatusForbidden,
+			Err:        fmt.Errorf("co-signing is disabled, no allowed scripts are configured"),
+		}
+	}
+
+	policyErr := func(format string, a ...interface{}) error {
+		return &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf(format, a...),
+		}
+	}
+
+	if flowTx.Payer != payer {
+		return policyErr("payer must be %s", payer.HexWithPrefix())
+	}
+
+	if flowTx.ProposalKey.Address == payer {
+		return policyErr("payer account can not be the proposer")
+	}
+
+	if len(flowTx.EnvelopeSignatures) > 0 {
+		return policyErr("transaction has envelope signatures")
+	}
+
+	if flowTx.GasLimit == 0 || flowTx.GasLimit > s.cfg.MaxComputeLimit {
+		return policyErr("compute limit must be between 1 and %d", s.cfg.MaxComputeLimit)
+	}
+
+	if !hasPayloadSignature(flowTx, flowTx.ProposalKey.Address) {
+		return policyErr("missing payload signature of proposer %s", flowTx.ProposalKey.Address.HexWithPrefix())
+	}
+
+	seen := make(map[flow.Address]bool, len(flowTx.Authorizers))
+	for _, a := range flowTx.Authorizers {
+		if a == payer {
+			return policyErr("payer account can not be an authorizer")
+		}
+		if seen[a] {
+			return policyErr("duplicate authorizer: %s", a.HexWithPrefix())
+		}
+		seen[a] = true
+
+		if !hasPayloadSignature(flowTx, a) {
+			return policyErr("missing payload signature of authorizer %s", a.HexWithPrefix())
+		}
+	}
+
+	if !s.cfg.CoSignAllowAnyScript {
+		hash := ScriptHash(flowTx.Script)
+		allowed := false
+		for _, h := range s.cfg.CoSignAllowedScripts {
+			allowed = allowed || strings.EqualFold(strings.TrimSpace(h), hash)
+		}
+		if !allowed {
+			return policyErr("script %s is not allowed", hash)
+		}
+	}
+
+	return nil
+}
+
+func hasPayloadSignature(flowTx *flow.Transaction, address flow.Address) bool {
+	for _, sig := range flowTx.PayloadSignatures {
+		if sig.Address == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package transactions
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/onflow/cadence"
+)
+
+const (
+	// The estimate is the highest fee and computation of earlier executions
+	// of the script.
+	EstimateBasisHistory = "history"
+	// The script has not been executed before, the estimate is the upper bound
+	// set by the compute limit.
+	EstimateBasisComputeLimit = "computeLimit"
+
+	// Number of earlier executions an estimate is based on
+	estimateSampleCount = 100
+)
+
+// EstimateJSONRequest is the JSON HTTP request of a fee estimate.
+type EstimateJSONRequest struct {
+	Code      string     `json:"code"`
+	Arguments []Argument `json:"arguments"`
+	// Compute limit of the transaction, defaults to the configured maximum
+	ComputeLimit uint64 `json:"computeLimit"`
+}
+
+// Estimate JSON HTTP response
+type Estimate struct {
+	ComputeLimit uint64 `json:"computeLimit"`
+	Computation  uint64 `json:"computation"`
+	// Fee in FLOW, empty if no transaction has been executed yet
+	Fee                 string `json:"fee,omitempty"`
+	Basis               string `json:"basis"`
+	Samples             int    `json:"samples"`
+	ExceedsComputeLimit bool   `json:"exceedsComputeLimit"`
+}
+
+// Estimate predicts the computation and fee of a transaction from earlier
+// executions of the same script. The Access API can not execute transactions
+// without sending them, so the arguments are only validated.
+func (s *ServiceImpl) Estimate(code string, args []Argument, opts ...TransactionOption) (*Estimate, error) {
+	computeLimit, err := s.computeLimit(newTransactionOptions(opts).computeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if code == "" {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("code is required"),
+		}
+	}
+
+	for _, arg := range args {
+		if _, err := ArgAsCadence(arg); err != nil {
+			return nil, &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("invalid argument: %w", err),
+			}
+		}
+	}
+
+	executed, err := s.store.ExecutedTransactions(ScriptHash([]byte(code)), estimateSampleCount)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &Estimate{ComputeLimit: computeLimit, Samples: len(executed)}
+
+	if len(executed) > 0 {
+		var fee uint64
+		for _, t := range executed {
+			if t.ComputationUsed > estimate.Computation {
+				estimate.Computation = t.ComputationUsed
+			}
+			if t.Fee > fee {
+				fee = t.Fee
+			}
+		}
+
+		estimate.Basis = EstimateBasisHistory
+		estimate.Fee = cadence.UFix64(fee).String()
+		estimate.ExceedsComputeLimit = estimate.Computation > computeLimit
+
+		return estimate, nil
+	}
+
+	// Without earlier executions of the script, the fee of the compute limit
+	// is estimated from the highest fee per computation of any script.
+	estimate.Basis = EstimateBasisComputeLimit
+	estimate.Computation = computeLimit
+
+	executed, err = s.store.ExecutedTransactions("", estimateSampleCount)
+	if err != nil {
+		return nil, err
+	}
+
+	var fee uint64
+	for _, t := range executed {
+		// Round up, the estimate is an upper bound
+		if f := (t.Fee*computeLimit + t.ComputationUsed - 1) / t.ComputationUsed; f > fee {
+			fee = f
+		}
+	}
+
+	if fee > 0 {
+		estimate.Fee = cadence.UFix64(fee).String()
+	}
+
+	return estimate, nil
+}
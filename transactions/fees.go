@@ -0,0 +1,49 @@
+package transactions
+
+import (
+	"strings"
+
+	"github.com/numeroai/flow-wallet-api/flow_helpers"
+	"github.com/numeroai/flow-wallet-api/keys"
+	"github.com/onflow/cadence"
+	"github.com/onflow/flow-go-sdk"
+)
+
+// Type suffix of the event the FlowFees contract emits for the fees of a
+// transaction.
+const feesDeductedEventSuffix = ".FlowFees.FeesDeducted"
+
+// FeeFromEvents returns the fee deducted from the payer of a transaction in
+// the smallest unit of UFix64 (1e-8), or 0 if the events have no fees.
+func FeeFromEvents(events []flow.Event) uint64 {
+	for _, e := range events {
+		if !strings.HasSuffix(e.Type, feesDeductedEventSuffix) {
+			continue
+		}
+		if amount, ok := e.Value.FieldsMappedByName()["amount"].(cadence.UFix64); ok {
+			return uint64(amount)
+		}
+	}
+	return 0
+}
+
+// payerName returns the name of the payer selected by opts, or the default
+// payer of the proposer.
+func (s *ServiceImpl) payerName(proposerAddress string, opts []TransactionOption) (string, error) {
+	o := newTransactionOptions(opts)
+
+	if o.payer != "" {
+		return o.payer, nil
+	}
+
+	name, err := s.store.AccountPayer(flow_helpers.FormatAddress(flow.HexToAddress(proposerAddress)))
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		return keys.AdminPayerName, nil
+	}
+
+	return name, nil
+}
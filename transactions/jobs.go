@@ -8,6 +8,15 @@ import (
 
 const TransactionJobType = "transaction"
 
+// TransactionJobResult is the typed result of transaction jobs, the legacy
+// result is empty.
+type TransactionJobResult struct {
+	TransactionID string `json:"transactionId"`
+	// ID of the transaction on chain, differs from TransactionID as the
+	// transaction is built when the job runs
+	FlowTransactionID string `json:"flowTransactionId,omitempty"`
+}
+
 func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) error {
 	if j.Type != TransactionJobType {
 		return jobs.ErrInvalidJobType
@@ -20,10 +29,10 @@ func (s *ServiceImpl) executeTransactionJob(ctx context.Context, j *jobs.Job) er
 		return err
 	}
 
-	err = s.sendTransaction(ctx, &tx)
+	err = s.sendTransaction(ctx, &tx, true)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return j.SetTypedResult(TransactionJobResult{TransactionID: tx.TransactionId, FlowTransactionID: tx.FlowTransactionId})
 }
@@ -9,3 +9,35 @@ func WithTxRatelimiter(limiter ratelimit.Limiter) ServiceOption {
 		svc.txRateLimiter = limiter
 	}
 }
+
+// TransactionOption configures a single transaction.
+type TransactionOption func(*transactionOptions)
+
+type transactionOptions struct {
+	payer        string
+	computeLimit uint64
+}
+
+func newTransactionOptions(opts []TransactionOption) transactionOptions {
+	o := transactionOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithPayer selects the payer of the transaction by name. If not set, the
+// default payer of the proposer pays, or the admin account if it has none.
+func WithPayer(name string) TransactionOption {
+	return func(o *transactionOptions) {
+		o.payer = name
+	}
+}
+
+// WithComputeLimit sets the compute limit of the transaction. If not set, or
+// 0, the configured maximum compute limit is used.
+func WithComputeLimit(limit uint64) TransactionOption {
+	return func(o *transactionOptions) {
+		o.computeLimit = limit
+	}
+}
@@ -2,8 +2,10 @@ package transactions
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/numeroai/flow-wallet-api/configs"
 	"github.com/numeroai/flow-wallet-api/datastore"
@@ -12,20 +14,39 @@ import (
 	"github.com/numeroai/flow-wallet-api/jobs"
 	"github.com/numeroai/flow-wallet-api/keys"
 	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go-sdk"
 	"github.com/onflow/flow-go-sdk/access/grpc"
+	log "github.com/sirupsen/logrus"
 	"go.uber.org/ratelimit"
 	"google.golang.org/grpc/codes"
 )
 
+// Error codes of the network for transactions rejected for their proposal key
+const (
+	errCodeInvalidProposalSignature = "[Error Code: 1006]"
+	errCodeInvalidProposalSeqNumber = "[Error Code: 1007]"
+)
+
 type Service interface {
-	Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type) (*jobs.Job, *Transaction, error)
-	Sign(ctx context.Context, proposerAddress string, code string, args []Argument) (*SignedTransaction, error)
+	// Create and Sign build a transaction proposed by proposerAddress and
+	// authorized by authorizerAddresses in the given order. The proposer is
+	// the sole authorizer if no authorizers are given.
+	Create(ctx context.Context, sync bool, proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, opts ...TransactionOption) (*jobs.Job, *Transaction, error)
+	Sign(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, args []Argument, opts ...TransactionOption) (*SignedTransaction, error)
+	// CoSign adds the payer signature to a transaction built and signed
+	// elsewhere, and sends it like Create.
+	CoSign(ctx context.Context, sync bool, encodedTx string, opts ...TransactionOption) (*jobs.Job, *Transaction, error)
 	List(limit, offset int) ([]Transaction, error)
 	ListForAccount(tType Type, address string, limit, offset int) ([]Transaction, error)
 	Details(ctx context.Context, transactionId string) (*Transaction, error)
 	DetailsForAccount(ctx context.Context, tType Type, address, transactionId string) (*Transaction, error)
 	ExecuteScript(ctx context.Context, code string, args []Argument) (cadence.Value, error)
+	// Estimate predicts the computation and fee of a transaction running code.
+	Estimate(code string, args []Argument, opts ...TransactionOption) (*Estimate, error)
+	// PayerFees returns the sum of the fees, as UFix64, and the number of
+	// transactions paid by the payer.
+	PayerFees(payer string) (string, int64, error)
 	UpdateTransaction(t *Transaction) error
 	GetOrCreateTransaction(transactionId string) *Transaction
 }
@@ -63,46 +84,33 @@ func NewService(
 	}
 
 	// Register asynchronous job executor.
-	wp.RegisterExecutor(TransactionJobType, svc.executeTransactionJob)
+	wp.RegisterExecutor(TransactionJobType, svc.executeTransactionJob,
+		jobs.WithResultSchema(TransactionJobResult{}))
 
 	return svc
 }
 
-func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress string, code string, args []Argument, tType Type) (*jobs.Job, *Transaction, error) {
-	transaction, err := s.newTransaction(ctx, proposerAddress, code, args, tType)
+func (s *ServiceImpl) Create(ctx context.Context, sync bool, proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, opts ...TransactionOption) (*jobs.Job, *Transaction, error) {
+	var (
+		transaction *Transaction
+		err         error
+	)
+
+	if sync {
+		transaction, err = s.newTransaction(ctx, proposerAddress, authorizerAddresses, code, args, tType, opts)
+	} else {
+		// The transaction is built and signed when its job runs
+		transaction, err = s.newTransactionIntent(proposerAddress, authorizerAddresses, code, args, tType, opts)
+	}
 	if err != nil {
 		return nil, nil, fmt.Errorf("error while getting new transaction: %w", err)
 	}
 
-	if err := s.store.InsertTransaction(transaction); err != nil {
-		return nil, nil, fmt.Errorf("error while inserting transaction in db: %w", err)
-	}
-
-	if !sync {
-		// Async
-		job, err := s.wp.CreateJob(TransactionJobType, transaction.TransactionId)
-		if err != nil {
-			return nil, nil, fmt.Errorf("error while creating job: %w", err)
-		}
-
-		if err := s.wp.Schedule(job); err != nil {
-			return nil, nil, fmt.Errorf("error while scheduling job: %w", err)
-		}
-
-		return job, transaction, nil
-
-	} else {
-		// Sync
-		if err := s.sendTransaction(ctx, transaction); err != nil {
-			return nil, nil, err
-		}
-
-		return nil, transaction, nil
-	}
+	return s.submit(ctx, sync, transaction)
 }
 
-func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, code string, args []Argument) (*SignedTransaction, error) {
-	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args)
+func (s *ServiceImpl) Sign(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, args []Argument, opts ...TransactionOption) (*SignedTransaction, error) {
+	flowTx, _, err := s.buildFlowTransaction(ctx, proposerAddress, authorizerAddresses, code, args, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +155,13 @@ func (s *ServiceImpl) Details(ctx context.Context, transactionId string) (*Trans
 		return nil, err
 	}
 
-	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transactionId))
+	// Not built yet
+	if transaction.FlowTransactionId == "" {
+		return &transaction, nil
+	}
+
+	// The transaction may have been rebuilt, get the result of the latest one
+	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transaction.FlowTransactionId))
 	if err != nil {
 		return nil, err
 	}
@@ -181,7 +195,13 @@ func (s *ServiceImpl) DetailsForAccount(ctx context.Context, tType Type, address
 		return nil, err
 	}
 
-	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transactionId))
+	// Not built yet
+	if transaction.FlowTransactionId == "" {
+		return &transaction, nil
+	}
+
+	// The transaction may have been rebuilt, get the result of the latest one
+	result, err := s.fc.GetTransactionResult(ctx, flow.HexToID(transaction.FlowTransactionId))
 	if err != nil {
 		return nil, err
 	}
@@ -200,6 +220,14 @@ func (s *ServiceImpl) ExecuteScript(ctx context.Context, code string, args []Arg
 	)
 }
 
+func (s *ServiceImpl) PayerFees(payer string) (string, int64, error) {
+	fees, count, err := s.store.PayerFees(payer)
+	if err != nil {
+		return "", 0, err
+	}
+	return cadence.UFix64(fees).String(), count, nil
+}
+
 func (s *ServiceImpl) UpdateTransaction(t *Transaction) error {
 	return s.store.UpdateTransaction(t)
 }
@@ -208,21 +236,37 @@ func (s *ServiceImpl) GetOrCreateTransaction(transactionId string) *Transaction
 	return s.store.GetOrCreateTransaction(transactionId)
 }
 
-func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress, code string, arguments []Argument) (*flow.Transaction, error) {
+// buildFlowTransaction returns the signed transaction and the name of its
+// payer.
+func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, arguments []Argument, opts []TransactionOption) (*flow.Transaction, string, error) {
+	computeLimit, err := s.computeLimit(newTransactionOptions(opts).computeLimit)
+	if err != nil {
+		return nil, "", err
+	}
+
 	latestBlockID, err := flow_helpers.LatestBlockId(ctx, s.fc)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	// Admin should always be the payer of the transaction fees.
-	payer, err := s.km.AdminAuthorizer(ctx)
+	payerName, err := s.payerName(proposerAddress, opts)
 	if err != nil {
-		return nil, fmt.Errorf("error while getting admin authorizer for payer: %w", err)
+		return nil, "", err
 	}
 
-	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress)
+	payer, err := s.km.PayerAuthorizer(ctx, payerName)
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("error while getting payer authorizer: %w", err)
+	}
+
+	proposer, err := s.getProposalAuthorizer(ctx, proposerAddress, payerName, payer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	authorizers, payloadSigners, err := s.getAuthorizers(ctx, proposer, payer, authorizerAddresses)
+	if err != nil {
+		return nil, "", err
 	}
 
 	flowTx := flow.NewTransaction()
@@ -230,58 +274,198 @@ func (s *ServiceImpl) buildFlowTransaction(ctx context.Context, proposerAddress,
 		SetReferenceBlockID(*latestBlockID).
 		SetProposalKey(proposer.Address, proposer.Key.Index, proposer.Key.SequenceNumber).
 		SetPayer(payer.Address).
-		SetComputeLimit(maxGasLimit).
+		SetComputeLimit(computeLimit).
 		SetScript([]byte(code))
 
 	for _, arg := range arguments {
 		cv, err := ArgAsCadence(arg)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		err = flowTx.AddArgument(cv)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 	}
 
-	// Add authorizers. We assume proposer is always the sole authorizer
-	// https://github.com/numeroai/flow-wallet-api/issues/79
-	flowTx.AddAuthorizer(proposer.Address)
+	// Add authorizers, the order is the order of the signers in the
+	// prepare block of the transaction
+	for _, a := range authorizers {
+		flowTx.AddAuthorizer(a)
+	}
 
 	// Proposer signs the payload (unless proposer == payer).
 	if !proposer.Equals(payer) {
 		if err := flowTx.SignPayload(proposer.Address, proposer.Key.Index, proposer.Signer); err != nil {
-			return nil, err
+			return nil, "", err
+		}
+	}
+
+	// Other authorizers sign the payload
+	for _, a := range payloadSigners {
+		if err := flowTx.SignPayload(a.Address, a.Key.Index, a.Signer); err != nil {
+			return nil, "", err
 		}
 	}
 
 	// Payer signs the envelope
 	if err := flowTx.SignEnvelope(payer.Address, payer.Key.Index, payer.Signer); err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return flowTx, nil
+	return flowTx, payerName, nil
 }
 
-func (s *ServiceImpl) newTransaction(ctx context.Context, proposerAddress string, code string, args []Argument, tType Type) (*Transaction, error) {
+func (s *ServiceImpl) newTransaction(ctx context.Context, proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, opts []TransactionOption) (*Transaction, error) {
 	tx := &Transaction{
 		ProposerAddress: proposerAddress,
 		TransactionType: tType,
+		Code:            code,
+		Authorizers:     authorizerAddresses,
 	}
 
-	flowTx, err := s.buildFlowTransaction(ctx, proposerAddress, code, args)
+	flowTx, payerName, err := s.buildFlowTransaction(ctx, proposerAddress, authorizerAddresses, code, args, opts)
 	if err != nil {
 		return nil, fmt.Errorf("error while building transaction: %w", err)
 	}
 
+	// Store the intent with the arguments as they were encoded for the
+	// transaction, so it can be rebuilt with the same arguments
+	if tx.Arguments, err = encodeArguments(flowTx.Arguments); err != nil {
+		return nil, err
+	}
+
+	tx.Payer = payerName
+	tx.ComputeLimit = flowTx.GasLimit
 	tx.TransactionId = flowTx.ID().Hex()
+	tx.FlowTransactionId = tx.TransactionId
 	tx.FlowTransaction = flowTx.Encode()
 
 	return tx, nil
 }
 
-func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress string) (keys.Authorizer, error) {
+// newTransactionIntent validates the intent of a transaction and returns it
+// without building it, so no proposal key is taken and nothing is signed.
+// Until the transaction is built its id is a random id in the format of
+// transaction ids.
+func (s *ServiceImpl) newTransactionIntent(proposerAddress string, authorizerAddresses []string, code string, args []Argument, tType Type, opts []TransactionOption) (*Transaction, error) {
+	computeLimit, err := s.computeLimit(newTransactionOptions(opts).computeLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	proposerAddress, err = flow_helpers.ValidateAddress(proposerAddress, s.cfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizers, err := s.validateAuthorizers(authorizerAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	payerName, err := s.payerName(proposerAddress, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedArgs := make([][]byte, len(args))
+	for i, arg := range args {
+		cv, err := ArgAsCadence(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if encodedArgs[i], err = c_json.Encode(cv); err != nil {
+			return nil, err
+		}
+	}
+
+	encoded, err := encodeArguments(encodedArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newIntentId()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transaction{
+		TransactionId:   id,
+		TransactionType: tType,
+		ProposerAddress: proposerAddress,
+		Payer:           payerName,
+		Code:            code,
+		Arguments:       encoded,
+		Authorizers:     authorizers,
+		ComputeLimit:    computeLimit,
+	}, nil
+}
+
+// newIntentId returns a random id for a transaction which has not been built.
+func newIntentId() (string, error) {
+	b := make([]byte, len(flow.EmptyID))
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return flow.BytesToID(b).Hex(), nil
+}
+
+// validateAuthorizers validates and formats the authorizer addresses.
+func (s *ServiceImpl) validateAuthorizers(authorizerAddresses []string) ([]string, error) {
+	if len(authorizerAddresses) == 0 {
+		return nil, nil
+	}
+
+	authorizers := make([]string, 0, len(authorizerAddresses))
+	seen := make(map[string]bool, len(authorizerAddresses))
+
+	for _, a := range authorizerAddresses {
+		a, err := flow_helpers.ValidateAddress(a, s.cfg.ChainID)
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[a] {
+			// Convert error to a 400 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("duplicate authorizer: %s", a),
+			}
+			return nil, err
+		}
+		seen[a] = true
+
+		authorizers = append(authorizers, a)
+	}
+
+	return authorizers, nil
+}
+
+// computeLimit validates the compute limit of a request against the configured
+// maximum, which is also the default.
+func (s *ServiceImpl) computeLimit(limit uint64) (uint64, error) {
+	if limit == 0 {
+		return s.cfg.MaxComputeLimit, nil
+	}
+
+	if limit > s.cfg.MaxComputeLimit {
+		// Convert error to a 400 RequestError
+		err := &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("compute limit must be at most %d", s.cfg.MaxComputeLimit),
+		}
+		return 0, err
+	}
+
+	return limit, nil
+}
+
+// getProposalAuthorizer uses a key of the proposal key pool if the proposer
+// is the payer or the admin account.
+func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress, payerName string, payer keys.Authorizer) (keys.Authorizer, error) {
 	// Validate the input address.
 	proposerAddress, err := flow_helpers.ValidateAddress(proposerAddress, s.cfg.ChainID)
 	if err != nil {
@@ -289,7 +473,12 @@ func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress
 	}
 
 	var proposer keys.Authorizer
-	if proposerAddress == s.cfg.AdminAddress {
+	if flow.HexToAddress(proposerAddress) == payer.Address {
+		proposer, err = s.km.PayerProposalKey(ctx, payerName)
+		if err != nil {
+			return keys.Authorizer{}, fmt.Errorf("error while getting payer authorizer: %w", err)
+		}
+	} else if proposerAddress == s.cfg.AdminAddress {
 		proposer, err = s.km.AdminProposalKey(ctx)
 		if err != nil {
 			return keys.Authorizer{}, fmt.Errorf("error while getting admin authorizer: %w", err)
@@ -304,41 +493,219 @@ func (s *ServiceImpl) getProposalAuthorizer(ctx context.Context, proposerAddress
 	return proposer, nil
 }
 
-func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction) error {
-	// TODO: we should "recreate" the transaction as proposal key sequence numbering
-	// might have gotten out of sync by now (in async situations)
+// getAuthorizers validates the authorizer addresses and resolves the keys of
+// the authorizers which have to sign the payload. The proposer and the payer
+// sign the transaction anyway, so their accounts are not resolved again.
+func (s *ServiceImpl) getAuthorizers(ctx context.Context, proposer, payer keys.Authorizer, authorizerAddresses []string) ([]flow.Address, []keys.Authorizer, error) {
+	if len(authorizerAddresses) == 0 {
+		return []flow.Address{proposer.Address}, nil, nil
+	}
+
+	authorizers := make([]flow.Address, 0, len(authorizerAddresses))
+	payloadSigners := []keys.Authorizer{}
+	seen := make(map[flow.Address]bool, len(authorizerAddresses))
+
+	for _, a := range authorizerAddresses {
+		a, err := flow_helpers.ValidateAddress(a, s.cfg.ChainID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		address := flow.HexToAddress(a)
+		if seen[address] {
+			// Convert error to a 400 RequestError
+			err = &errors.RequestError{
+				StatusCode: http.StatusBadRequest,
+				Err:        fmt.Errorf("duplicate authorizer: %s", a),
+			}
+			return nil, nil, err
+		}
+		seen[address] = true
 
-	flowTx, err := flow.DecodeTransaction(tx.FlowTransaction)
+		authorizers = append(authorizers, address)
+
+		if address == proposer.Address || address == payer.Address {
+			continue
+		}
+
+		authorizer, err := s.km.UserAuthorizer(ctx, address)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while getting user authorizer: %w", err)
+		}
+
+		payloadSigners = append(payloadSigners, authorizer)
+	}
+
+	return authorizers, payloadSigners, nil
+}
+
+// submit stores the transaction and sends it, or schedules a job to send it
+// if sync is false.
+func (s *ServiceImpl) submit(ctx context.Context, sync bool, transaction *Transaction) (*jobs.Job, *Transaction, error) {
+	if err := s.store.InsertTransaction(transaction); err != nil {
+		return nil, nil, fmt.Errorf("error while inserting transaction in db: %w", err)
+	}
+
+	if !sync {
+		// Async
+		job, err := s.wp.CreateJob(TransactionJobType, transaction.TransactionId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while creating job: %w", err)
+		}
+
+		if err := s.wp.Schedule(job); err != nil {
+			return nil, nil, fmt.Errorf("error while scheduling job: %w", err)
+		}
+
+		return job, transaction, nil
+
+	} else {
+		// Sync, the transaction was just built
+		if err := s.sendTransaction(ctx, transaction, false); err != nil {
+			return nil, nil, err
+		}
+
+		return nil, transaction, nil
+	}
+}
+
+// sendTransaction sends the transaction and waits for it to be sealed. A
+// transaction which has not been built yet is built from its intent. If
+// rebuild is true, a transaction which has not been sent, has expired or was
+// rejected for its proposal key is built and signed again, as the sequence
+// number of the proposal key and the reference block may be stale by now. A
+// transaction which has been executed otherwise is not sent again.
+func (s *ServiceImpl) sendTransaction(ctx context.Context, tx *Transaction, rebuild bool) error {
+	var (
+		flowTx *flow.Transaction
+		result *flow.TransactionResult
+		err    error
+	)
+
+	if len(tx.FlowTransaction) > 0 {
+		flowTx, err = flow.DecodeTransaction(tx.FlowTransaction)
+		if err != nil {
+			return err
+		}
+
+		// Check if transaction has been sent already.
+		result, err = s.sentTransactionResult(ctx, flowTx.ID())
+		if err != nil {
+			return err
+		}
+	} else if !tx.CanRebuild() {
+		return fmt.Errorf("transaction %s has neither a flow transaction nor an intent", tx.TransactionId)
+	}
+
+	sent := result != nil &&
+		result.Status != flow.TransactionStatusExpired &&
+		!isProposalKeyError(result.Error)
+
+	if flowTx == nil || (rebuild && tx.CanRebuild() && !sent) {
+		flowTx, err = s.buildFromIntent(ctx, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Prevent the job from being cancelled from here on
+	if err := jobs.MarkTransactionSubmitted(ctx); err != nil {
+		return err
+	}
+
+	var resp *flow.TransactionResult
+	if sent {
+		// Sent before, e.g. by an earlier attempt of the job
+		resp, err = flow_helpers.WaitForSeal(ctx, s.fc, flowTx.ID(), s.cfg.TransactionTimeout)
+	} else {
+		// Ratelimit
+		s.txRateLimiter.Take()
+
+		resp, err = flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+	}
 	if err != nil {
 		return err
 	}
 
-	// Check if transaction has been sent already.
-	_, err = s.fc.GetTransaction(ctx, flowTx.ID())
+	tx.Events = resp.Events
+	tx.Fee = FeeFromEvents(resp.Events)
+	tx.ScriptHash = ScriptHash(flowTx.Script)
+	tx.ComputationUsed = resp.ComputationUsage
+
+	// The transaction has been sent, failing to record its fee and
+	// computation should not fail the job
+	if err := s.store.UpdateTransaction(tx); err != nil {
+		log.WithFields(log.Fields{"error": err, "transactionId": tx.TransactionId}).Warn("Error while saving transaction fee")
+	}
+
+	return nil
+}
+
+// sentTransactionResult returns the result of a transaction, or nil if the
+// network does not know the transaction.
+func (s *ServiceImpl) sentTransactionResult(ctx context.Context, id flow.Identifier) (*flow.TransactionResult, error) {
+	_, err := s.fc.GetTransaction(ctx, id)
 	if err != nil {
 		rpcErr, ok := err.(grpc.RPCError)
 		if !ok {
 			// The error wasn't from gRPC.
-			return err
+			return nil, err
 		}
 
 		if rpcErr.GRPCStatus().Code() != codes.NotFound {
 			// Something unexpected went wrong in the gRPC call or in the Access API.
-			return err
+			return nil, err
 		}
 
-		// The Flow transaction was not found. All good. Continue.
+		// The Flow transaction was not found. All good.
+		return nil, nil
 	}
 
-	// Ratelimit
-	s.txRateLimiter.Take()
+	return s.fc.GetTransactionResult(ctx, id)
+}
 
-	resp, err := flow_helpers.SendAndWait(ctx, s.fc, *flowTx, s.cfg.TransactionTimeout)
+// buildFromIntent builds and signs the transaction from its intent, with the
+// current sequence number of the proposal key and the latest block as
+// reference block. The built transaction is stored before it is sent, the id
+// of an earlier build it replaces is added to SupersededIds.
+func (s *ServiceImpl) buildFromIntent(ctx context.Context, tx *Transaction) (*flow.Transaction, error) {
+	args, err := tx.decodeArguments()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("error while decoding transaction arguments: %w", err)
 	}
 
-	tx.Events = resp.Events
+	flowTx, _, err := s.buildFlowTransaction(ctx, tx.ProposerAddress, tx.Authorizers, tx.Code, args, []TransactionOption{
+		WithPayer(tx.Payer),
+		WithComputeLimit(tx.ComputeLimit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while building transaction: %w", err)
+	}
 
-	return nil
+	if tx.FlowTransactionId != "" {
+		tx.SupersededIds = append(tx.SupersededIds, tx.FlowTransactionId)
+	}
+	tx.FlowTransactionId = flowTx.ID().Hex()
+	tx.FlowTransaction = flowTx.Encode()
+
+	if err := s.store.UpdateTransaction(tx); err != nil {
+		return nil, fmt.Errorf("error while saving built transaction: %w", err)
+	}
+
+	log.WithFields(log.Fields{"transactionId": tx.TransactionId, "flowTransactionId": tx.FlowTransactionId}).Debug("Built transaction")
+
+	return flowTx, nil
+}
+
+// isProposalKeyError reports whether the transaction was rejected for its
+// proposal key, e.g. for an outdated sequence number, in which case it was
+// not executed and can be built again.
+func isProposalKeyError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, errCodeInvalidProposalSignature) ||
+		strings.Contains(msg, errCodeInvalidProposalSeqNumber)
 }
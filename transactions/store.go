@@ -13,4 +13,13 @@ type Store interface {
 	GetOrCreateTransaction(txId string) *Transaction
 	InsertTransaction(*Transaction) error
 	UpdateTransaction(*Transaction) error
+	// AccountPayer returns the name of the default payer of an account, or an
+	// empty string if it has none.
+	AccountPayer(address string) (string, error)
+	// PayerFees returns the sum of the fees and the number of transactions
+	// paid by the payer.
+	PayerFees(payer string) (uint64, int64, error)
+	// ExecutedTransactions returns the fee and computation used of the latest
+	// executed transactions with the script hash, or of any script if empty.
+	ExecutedTransactions(scriptHash string, limit int) ([]Transaction, error)
 }
@@ -27,8 +27,7 @@ func (s *GormStore) Transactions(o datastore.ListOptions) (tt []Transaction, err
 }
 
 func (s *GormStore) Transaction(txId string) (t Transaction, err error) {
-	q := &Transaction{TransactionId: txId}
-	err = s.db.Where(q).First(&t).Error
+	err = s.db.Where(byTransactionId(s.db, txId)).First(&t).Error
 	return
 }
 
@@ -46,17 +45,23 @@ func (s *GormStore) TransactionsForAccount(tType Type, address string, o datasto
 }
 
 func (s *GormStore) TransactionForAccount(tType Type, address, txId string) (t Transaction, err error) {
-	q := &Transaction{ProposerAddress: address, TransactionType: tType, TransactionId: txId}
-	err = s.db.Where(q).First(&t).Error
+	q := &Transaction{ProposerAddress: address, TransactionType: tType}
+	err = s.db.Where(q).Where(byTransactionId(s.db, txId)).First(&t).Error
 	return
 }
 
 // -- Misc
 
 func (s *GormStore) GetOrCreateTransaction(txId string) (t *Transaction) {
+	// Transactions built by the wallet are known by the id they were sent with
+	var existing Transaction
+	if err := s.db.Where(byTransactionId(s.db, txId)).First(&existing).Error; err == nil {
+		return &existing
+	}
+
 	s.db.
 		Where(&Transaction{TransactionId: txId}).
-		Attrs(&Transaction{TransactionType: Unknown}).
+		Attrs(&Transaction{TransactionType: Unknown, FlowTransactionId: txId}).
 		FirstOrCreate(&t)
 	return t
 }
@@ -68,3 +73,49 @@ func (s *GormStore) InsertTransaction(t *Transaction) error {
 func (s *GormStore) UpdateTransaction(t *Transaction) error {
 	return s.db.Save(t).Error
 }
+
+func (s *GormStore) AccountPayer(address string) (string, error) {
+	var payers []string
+	err := s.db.
+		Table("accounts").
+		Where("address = ? AND deleted_at IS NULL", address).
+		Limit(1).
+		Pluck("payer", &payers).Error
+	if err != nil || len(payers) == 0 {
+		return "", err
+	}
+	return payers[0], nil
+}
+
+func (s *GormStore) PayerFees(payer string) (uint64, int64, error) {
+	var res struct {
+		Fees  uint64
+		Count int64
+	}
+	err := s.db.
+		Model(&Transaction{}).
+		Select("COALESCE(SUM(fee), 0) AS fees, COUNT(*) AS count").
+		Where(&Transaction{Payer: payer}).
+		Scan(&res).Error
+	return res.Fees, res.Count, err
+}
+
+func (s *GormStore) ExecutedTransactions(scriptHash string, limit int) (tt []Transaction, err error) {
+	q := s.db.
+		Select("fee", "computation_used").
+		Where("computation_used > 0")
+	if scriptHash != "" {
+		q = q.Where(&Transaction{ScriptHash: scriptHash})
+	}
+	err = q.
+		Order("created_at desc").
+		Limit(limit).
+		Find(&tt).Error
+	return
+}
+
+// byTransactionId matches a transaction by its id or by the id it was sent
+// with, if it was rebuilt.
+func byTransactionId(db *gorm.DB, txId string) *gorm.DB {
+	return db.Where(&Transaction{TransactionId: txId}).Or(&Transaction{FlowTransactionId: txId})
+}
@@ -1,15 +1,18 @@
 package transactions
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
+	"github.com/onflow/cadence"
+	c_json "github.com/onflow/cadence/encoding/json"
 	"github.com/onflow/flow-go-sdk"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
-const maxGasLimit = 9999
-
 type SignedTransaction struct {
 	flow.Transaction
 }
@@ -81,42 +84,112 @@ func (st *SignedTransaction) ToJSONResponse() (SignedTransactionJSONResponse, er
 }
 
 // Transaction is the database model for all transactions.
+// Asynchronous transactions are stored as their intent (code, arguments,
+// proposer, authorizers, payer and compute limit) with a random
+// TransactionId, and built from it when sent. FlowTransactionId is the id of
+// FlowTransaction, the latest build, and SupersededIds the ids of the builds
+// it replaced. For other transactions TransactionId is the id as built.
 type Transaction struct {
-	TransactionId   string         `gorm:"column:transaction_id;primaryKey"`
-	TransactionType Type           `gorm:"column:transaction_type;index"`
-	ProposerAddress string         `gorm:"column:proposer_address;index"`
-	FlowTransaction []byte         `gorm:"column:flow_transaction;type:bytes"`
-	CreatedAt       time.Time      `gorm:"column:created_at"`
-	UpdatedAt       time.Time      `gorm:"column:updated_at"`
-	DeletedAt       gorm.DeletedAt `gorm:"column:deleted_at;index"`
-	Events          []flow.Event   `gorm:"-"`
+	TransactionId     string         `gorm:"column:transaction_id;primaryKey"`
+	TransactionType   Type           `gorm:"column:transaction_type;index"`
+	ProposerAddress   string         `gorm:"column:proposer_address;index"`
+	Payer             string         `gorm:"column:payer;index"`
+	Fee               uint64         `gorm:"column:fee"`
+	ScriptHash        string         `gorm:"column:script_hash;index"`
+	ComputationUsed   uint64         `gorm:"column:computation_used"`
+	Code              string         `gorm:"column:code"`
+	Arguments         datatypes.JSON `gorm:"column:arguments"`
+	Authorizers       pq.StringArray `gorm:"column:authorizers;type:text[]"`
+	ComputeLimit      uint64         `gorm:"column:compute_limit"`
+	FlowTransactionId string         `gorm:"column:flow_transaction_id;index"`
+	SupersededIds     pq.StringArray `gorm:"column:superseded_ids;type:text[]"`
+	FlowTransaction   []byte         `gorm:"column:flow_transaction;type:bytes"`
+	CreatedAt         time.Time      `gorm:"column:created_at"`
+	UpdatedAt         time.Time      `gorm:"column:updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	Events            []flow.Event   `gorm:"-"`
 }
 
 func (Transaction) TableName() string {
 	return "transactions"
 }
 
+// CanRebuild reports whether the transaction has an intent to rebuild it
+// from. Externally signed transactions can only be sent as they are.
+func (t Transaction) CanRebuild() bool {
+	return t.Code != ""
+}
+
+// encodeArguments returns the JSON-Cadence encoded arguments of a transaction
+// as a JSON array.
+func encodeArguments(args [][]byte) (datatypes.JSON, error) {
+	raw := make([]json.RawMessage, len(args))
+	for i, a := range args {
+		raw[i] = a
+	}
+	return json.Marshal(raw)
+}
+
+func (t Transaction) decodeArguments() ([]Argument, error) {
+	var raw []json.RawMessage
+	if len(t.Arguments) > 0 {
+		if err := json.Unmarshal(t.Arguments, &raw); err != nil {
+			return nil, err
+		}
+	}
+
+	args := make([]Argument, len(raw))
+	for i, r := range raw {
+		c, err := c_json.Decode(nil, r)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = c
+	}
+
+	return args, nil
+}
+
 // Transaction JSON HTTP request
 type JSONRequest struct {
 	Code      string     `json:"code"`
 	Arguments []Argument `json:"arguments"`
+	// Ordered authorizer addresses, defaults to the proposer
+	Authorizers []string `json:"authorizers"`
+	// Name of the payer, defaults to the payer of the proposer
+	Payer string `json:"payer"`
+	// Compute limit of the transaction, defaults to the configured maximum
+	ComputeLimit uint64 `json:"computeLimit"`
 }
 
 // Transaction JSON HTTP response
 type JSONResponse struct {
-	TransactionId   string       `json:"transactionId"`
-	TransactionType Type         `json:"transactionType"`
-	Events          []flow.Event `json:"events,omitempty"`
-	CreatedAt       time.Time    `json:"createdAt"`
-	UpdatedAt       time.Time    `json:"updatedAt"`
+	TransactionId            string       `json:"transactionId"`
+	TransactionType          Type         `json:"transactionType"`
+	Payer                    string       `json:"payer,omitempty"`
+	Fee                      string       `json:"fee,omitempty"`
+	FlowTransactionId        string       `json:"flowTransactionId,omitempty"`
+	SupersededTransactionIds []string     `json:"supersededTransactionIds,omitempty"`
+	Events                   []flow.Event `json:"events,omitempty"`
+	CreatedAt                time.Time    `json:"createdAt"`
+	UpdatedAt                time.Time    `json:"updatedAt"`
 }
 
 func (t Transaction) ToJSONResponse() JSONResponse {
+	var fee string
+	if t.Fee > 0 {
+		fee = cadence.UFix64(t.Fee).String()
+	}
+
 	return JSONResponse{
-		TransactionId:   t.TransactionId,
-		TransactionType: t.TransactionType,
-		Events:          t.Events,
-		CreatedAt:       t.CreatedAt,
-		UpdatedAt:       t.UpdatedAt,
+		TransactionId:            t.TransactionId,
+		TransactionType:          t.TransactionType,
+		Payer:                    t.Payer,
+		Fee:                      fee,
+		FlowTransactionId:        t.FlowTransactionId,
+		SupersededTransactionIds: t.SupersededIds,
+		Events:                   t.Events,
+		CreatedAt:                t.CreatedAt,
+		UpdatedAt:                t.UpdatedAt,
 	}
 }
@@ -0,0 +1,150 @@
+// Package webhooks provides signing and verification of webhook requests
+// sent by the wallet. Receivers can import this package to check that a
+// payload really came from the wallet and is not being replayed.
+//
+// Signed requests carry the header
+//
+//	X-Flow-Wallet-Signature: t=<unix timestamp>,v1=<hex encoded signature>
+//
+// where the signature is HMAC-SHA256 over "<timestamp>.<raw request body>"
+// using the shared webhook secret.
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader  = "X-Flow-Wallet-Signature"
+	SignatureVersion = "v1"
+	// DefaultTolerance is the maximum accepted age of a signature.
+	DefaultTolerance = 5 * time.Minute
+)
+
+var (
+	ErrMissingHeader    = errors.New("missing webhook signature header")
+	ErrInvalidHeader    = errors.New("invalid webhook signature header")
+	ErrNoValidSignature = errors.New("no valid webhook signature found")
+	ErrTooOld           = errors.New("webhook timestamp outside of tolerance")
+)
+
+// Sign returns the signature header value for payload sent at the given time.
+func Sign(secret []byte, payload []byte, at time.Time) string {
+	ts := at.Unix()
+	return fmt.Sprintf("t=%d,%s=%s", ts, SignatureVersion, hex.EncodeToString(computeSignature(secret, payload, ts)))
+}
+
+// Verify checks that header contains a valid signature of payload made with
+// secret, and that its timestamp is within tolerance of the current time.
+// A tolerance of 0 disables the timestamp check.
+func Verify(secret []byte, payload []byte, header string, tolerance time.Duration) error {
+	return verifyAt(secret, payload, header, tolerance, time.Now())
+}
+
+// VerifyRequest reads the body of r and verifies it against the signature
+// header. The body is returned so it can still be decoded by the caller.
+func VerifyRequest(r *http.Request, secret []byte, tolerance time.Duration) ([]byte, error) {
+	header := r.Header.Get(SignatureHeader)
+	if header == "" {
+		return nil, ErrMissingHeader
+	}
+
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading webhook body: %w", err)
+	}
+
+	if err := Verify(secret, payload, header, tolerance); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func verifyAt(secret []byte, payload []byte, header string, tolerance time.Duration, now time.Time) error {
+	if header == "" {
+		return ErrMissingHeader
+	}
+
+	ts, signatures, err := parseHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if tolerance > 0 {
+		age := now.Sub(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTooOld
+		}
+	}
+
+	expected := computeSignature(secret, payload, ts)
+	for _, s := range signatures {
+		if hmac.Equal(expected, s) {
+			return nil
+		}
+	}
+
+	return ErrNoValidSignature
+}
+
+// parseHeader parses the timestamp and all signatures matching
+// SignatureVersion from a signature header. Unknown fields are ignored so
+// new signature versions can be rolled out without breaking receivers.
+func parseHeader(header string) (int64, [][]byte, error) {
+	var (
+		ts         int64
+		hasTs      bool
+		signatures [][]byte
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return 0, nil, ErrInvalidHeader
+		}
+
+		switch kv[0] {
+		case "t":
+			v, err := strconv.ParseInt(kv[1], 10, 64)
+			if err != nil {
+				return 0, nil, ErrInvalidHeader
+			}
+			ts = v
+			hasTs = true
+		case SignatureVersion:
+			s, err := hex.DecodeString(kv[1])
+			if err != nil {
+				continue
+			}
+			signatures = append(signatures, s)
+		}
+	}
+
+	if !hasTs {
+		return 0, nil, ErrInvalidHeader
+	}
+
+	if len(signatures) == 0 {
+		return 0, nil, ErrNoValidSignature
+	}
+
+	return ts, signatures, nil
+}
+
+func computeSignature(secret []byte, payload []byte, ts int64) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
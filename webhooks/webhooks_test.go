@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	secret := []byte("test-secret")
+	payload := []byte(`{"jobId":"123","state":"COMPLETE"}`)
+	now := time.Now()
+
+	header := Sign(secret, payload, now)
+
+	t.Run("valid signature", func(t *testing.T) {
+		if err := verifyAt(secret, payload, header, DefaultTolerance, now); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		if err := verifyAt([]byte("other"), payload, header, DefaultTolerance, now); err != ErrNoValidSignature {
+			t.Fatalf("expected %s, got %v", ErrNoValidSignature, err)
+		}
+	})
+
+	t.Run("tampered payload", func(t *testing.T) {
+		if err := verifyAt(secret, []byte(`{"jobId":"456"}`), header, DefaultTolerance, now); err != ErrNoValidSignature {
+			t.Fatalf("expected %s, got %v", ErrNoValidSignature, err)
+		}
+	})
+
+	t.Run("replayed outside tolerance", func(t *testing.T) {
+		later := now.Add(DefaultTolerance + time.Second)
+		if err := verifyAt(secret, payload, header, DefaultTolerance, later); err != ErrTooOld {
+			t.Fatalf("expected %s, got %v", ErrTooOld, err)
+		}
+	})
+
+	t.Run("zero tolerance skips timestamp check", func(t *testing.T) {
+		later := now.Add(time.Hour)
+		if err := verifyAt(secret, payload, header, 0, later); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("invalid headers", func(t *testing.T) {
+		for _, h := range []string{"", "garbage", "v1=abcd", "t=notanumber,v1=abcd"} {
+			if err := verifyAt(secret, payload, h, DefaultTolerance, now); err == nil {
+				t.Fatalf("expected an error for header %q", h)
+			}
+		}
+	})
+}
+
+func TestVerifyRequest(t *testing.T) {
+	secret := []byte("test-secret")
+	payload := []byte(`{"jobId":"123"}`)
+
+	r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(payload))
+	r.Header.Set(SignatureHeader, Sign(secret, payload, time.Now()))
+
+	body, err := VerifyRequest(r, secret, DefaultTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(body, payload) {
+		t.Fatalf("expected body %s, got %s", payload, body)
+	}
+
+	r = httptest.NewRequest("POST", "/webhook", bytes.NewReader(payload))
+	if _, err := VerifyRequest(r, secret, DefaultTolerance); err != ErrMissingHeader {
+		t.Fatalf("expected %s, got %v", ErrMissingHeader, err)
+	}
+}
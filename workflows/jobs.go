@@ -0,0 +1,154 @@
+package workflows
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+	wallet_errors "github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	"github.com/numeroai/flow-wallet-api/transactions"
+)
+
+const StepJobType = "workflow_step"
+
+// StepJobResult is the typed result of workflow step jobs, the address of a
+// created account or the ID of a sent transaction. The legacy result is the
+// address or the transaction ID.
+type StepJobResult struct {
+	Address       string `json:"address,omitempty"`
+	TransactionID string `json:"transactionId,omitempty"`
+}
+
+type stepJobAttributes struct {
+	WorkflowID uuid.UUID
+	Step       string
+	Operation  Operation
+	Params     map[string]string
+	StepJobs   map[string]uuid.UUID // Jobs of the steps referenced in Params
+}
+
+func (s *ServiceImpl) executeStepJob(ctx context.Context, j *jobs.Job) error {
+	if j.Type != StepJobType {
+		return jobs.ErrInvalidJobType
+	}
+
+	j.ShouldSendNotification = true
+
+	attrs := stepJobAttributes{}
+	if err := json.Unmarshal(j.Attributes, &attrs); err != nil {
+		return err
+	}
+
+	params, err := s.resolveParams(attrs)
+	if err != nil {
+		return err
+	}
+
+	err = s.runOperation(ctx, j, attrs.Operation, params)
+
+	if reqErr, ok := err.(*wallet_errors.RequestError); ok && reqErr.StatusCode < 500 {
+		// Invalid params will not succeed on a retry either
+		return jobs.PermanentFailure(err)
+	}
+
+	return err
+}
+
+func (s *ServiceImpl) runOperation(ctx context.Context, j *jobs.Job, op Operation, params map[string]string) error {
+	switch op {
+	case OpAccountCreate:
+		_, account, err := s.accounts.Create(ctx, true, params["payer"])
+		if err != nil {
+			return err
+		}
+		j.Result = account.Address
+		return j.SetTypedResult(StepJobResult{Address: account.Address})
+
+	case OpTokenSetup:
+		computeLimit, err := parseComputeLimit(params)
+		if err != nil {
+			return err
+		}
+
+		_, tx, err := s.tokens.Setup(ctx, true, params["tokenName"], params["address"], transactions.WithComputeLimit(computeLimit))
+		if err != nil {
+			return err
+		}
+		j.TransactionID = tx.TransactionId
+		j.Result = tx.TransactionId
+		return j.SetTypedResult(StepJobResult{TransactionID: tx.TransactionId})
+
+	case OpWithdrawalCreate:
+		req := tokens.WithdrawalRequest{
+			TokenName: params["tokenName"],
+			Recipient: params["recipient"],
+			FtAmount:  params["amount"],
+			Payer:     params["payer"],
+		}
+
+		if v := params["nftId"]; v != "" {
+			nftID, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return jobs.PermanentFailure(fmt.Errorf("invalid nftId %q", v))
+			}
+			req.NftID = nftID
+		}
+
+		computeLimit, err := parseComputeLimit(params)
+		if err != nil {
+			return err
+		}
+		req.ComputeLimit = computeLimit
+
+		_, tx, err := s.tokens.CreateWithdrawal(ctx, true, params["sender"], req)
+		if err != nil {
+			return err
+		}
+		j.TransactionID = tx.TransactionId
+		j.Result = tx.TransactionId
+		return j.SetTypedResult(StepJobResult{TransactionID: tx.TransactionId})
+
+	default:
+		return jobs.PermanentFailure(fmt.Errorf("unknown operation %q", op))
+	}
+}
+
+// parseComputeLimit returns the optional compute limit param, 0 if not set.
+func parseComputeLimit(params map[string]string) (uint64, error) {
+	v := params["computeLimit"]
+	if v == "" {
+		return 0, nil
+	}
+
+	computeLimit, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, jobs.PermanentFailure(fmt.Errorf("invalid computeLimit %q", v))
+	}
+
+	return computeLimit, nil
+}
+
+// resolveParams replaces references to earlier steps with their results.
+func (s *ServiceImpl) resolveParams(attrs stepJobAttributes) (map[string]string, error) {
+	results := make(map[string]string, len(attrs.StepJobs))
+	for name, id := range attrs.StepJobs {
+		job, err := s.jobs.Details(id.String())
+		if err != nil {
+			return nil, err
+		}
+		results[name] = job.Result
+	}
+
+	params := make(map[string]string, len(attrs.Params))
+	for k, v := range attrs.Params {
+		params[k] = stepRefRegexp.ReplaceAllStringFunc(v, func(ref string) string {
+			return results[stepRefRegexp.FindStringSubmatch(ref)[1]]
+		})
+	}
+
+	return params, nil
+}
@@ -0,0 +1,338 @@
+package workflows
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/numeroai/flow-wallet-api/accounts"
+	"github.com/numeroai/flow-wallet-api/errors"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"github.com/numeroai/flow-wallet-api/tokens"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	stepNameRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// Params can reference the result of an earlier step with {{<step name>.result}}
+	stepRefRegexp = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_-]+)\.result\s*\}\}`)
+)
+
+type Service interface {
+	// Create validates the steps and creates a job for each of them. Steps
+	// without dependencies are scheduled right away.
+	Create(req JSONRequest) (*JSONResponse, error)
+	Details(id string) (*JSONResponse, error)
+}
+
+type ServiceImpl struct {
+	store    Store
+	wp       jobs.WorkerPool
+	jobs     jobs.Service
+	accounts accounts.Service
+	tokens   tokens.Service
+}
+
+func NewService(store Store, wp jobs.WorkerPool, jobsService jobs.Service, accountService accounts.Service, tokenService tokens.Service) Service {
+	svc := &ServiceImpl{store, wp, jobsService, accountService, tokenService}
+
+	// Register asynchronous job executor.
+	wp.RegisterExecutor(StepJobType, svc.executeStepJob,
+		jobs.WithAttributesSchema(stepJobAttributes{}),
+		jobs.WithResultSchema(StepJobResult{}))
+
+	return svc
+}
+
+func (s *ServiceImpl) Create(req JSONRequest) (*JSONResponse, error) {
+	log.WithFields(log.Fields{"steps": len(req.Steps), "ordered": req.Ordered}).Trace("Create workflow")
+
+	steps, err := parseSteps(req)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := sortSteps(steps)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkStepRefs(steps); err != nil {
+		return nil, err
+	}
+
+	w := &Workflow{}
+	if err := s.store.InsertWorkflow(w); err != nil {
+		return nil, err
+	}
+
+	// Steps are created parents first so that their jobs can depend on
+	// the jobs of earlier steps
+	stepJobs := make(map[string]*jobs.Job, len(steps))
+	created := make([]*jobs.Job, 0, len(steps))
+
+	for _, i := range order {
+		step := steps[i]
+
+		attrs := stepJobAttributes{
+			WorkflowID: w.ID,
+			Step:       step.Name,
+			Operation:  step.Operation,
+			Params:     step.Params,
+			StepJobs:   make(map[string]uuid.UUID),
+		}
+
+		for _, name := range referencedSteps(step) {
+			attrs.StepJobs[name] = stepJobs[name].ID
+		}
+
+		attrBytes, err := json.Marshal(attrs)
+		if err != nil {
+			s.cancelJobs(created)
+			return nil, err
+		}
+
+		parents := make([]uuid.UUID, len(step.DependsOn))
+		for k, name := range step.DependsOn {
+			parents[k] = stepJobs[name].ID
+		}
+
+		job, err := s.wp.CreateJob(StepJobType, "", jobs.WithAttributes(attrBytes), jobs.WithParents(parents...))
+		if err != nil {
+			s.cancelJobs(created)
+			return nil, err
+		}
+
+		stepJobs[step.Name] = job
+		created = append(created, job)
+	}
+
+	w.Steps = make([]Step, len(steps))
+	for i, step := range steps {
+		w.Steps[i] = Step{
+			WorkflowID: w.ID,
+			Position:   i,
+			Name:       step.Name,
+			Operation:  step.Operation,
+			DependsOn:  step.DependsOn,
+			JobID:      stepJobs[step.Name].ID,
+		}
+	}
+
+	if err := s.store.InsertSteps(w.Steps); err != nil {
+		s.cancelJobs(created)
+		return nil, err
+	}
+
+	// Only schedule once the whole workflow has been stored
+	current := make(map[uuid.UUID]jobs.Job, len(created))
+	for _, job := range created {
+		if err := s.wp.Schedule(job); err != nil {
+			return nil, err
+		}
+		current[job.ID] = *job
+	}
+
+	res := w.ToJSONResponse(current)
+
+	return &res, nil
+}
+
+func (s *ServiceImpl) Details(id string) (*JSONResponse, error) {
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, &errors.RequestError{
+			StatusCode: http.StatusBadRequest,
+			Err:        fmt.Errorf("invalid workflow id"),
+		}
+	}
+
+	w, err := s.store.Workflow(uid)
+	if err != nil {
+		if err.Error() == "record not found" {
+			return nil, &errors.RequestError{
+				StatusCode: http.StatusNotFound,
+				Err:        fmt.Errorf("workflow not found"),
+			}
+		}
+		return nil, err
+	}
+
+	stepJobs := make(map[uuid.UUID]jobs.Job, len(w.Steps))
+	for _, step := range w.Steps {
+		job, err := s.jobs.Details(step.JobID.String())
+		if err != nil {
+			return nil, err
+		}
+		stepJobs[job.ID] = *job
+	}
+
+	res := w.ToJSONResponse(stepJobs)
+
+	return &res, nil
+}
+
+// cancelJobs cancels the jobs of a workflow which could not be created
+// completely. Jobs that are not scheduled yet would otherwise be picked up
+// by the database scheduler.
+func (s *ServiceImpl) cancelJobs(jj []*jobs.Job) {
+	for _, j := range jj {
+		if _, err := s.jobs.Cancel(j.ID.String()); err != nil {
+			log.
+				WithFields(log.Fields{"error": err, "jobID": j.ID}).
+				Warn("Could not cancel job of incomplete workflow")
+		}
+	}
+}
+
+func parseSteps(req JSONRequest) ([]StepRequest, error) {
+	if len(req.Steps) == 0 {
+		return nil, badRequest("at least one step is required")
+	}
+
+	steps := make([]StepRequest, len(req.Steps))
+	names := make(map[string]bool, len(req.Steps))
+
+	for i, step := range req.Steps {
+		if step.Name == "" {
+			step.Name = strconv.Itoa(i)
+		}
+
+		if !stepNameRegexp.MatchString(step.Name) {
+			return nil, badRequest(fmt.Sprintf("invalid step name %q", step.Name))
+		}
+
+		if names[step.Name] {
+			return nil, badRequest(fmt.Sprintf("duplicate step name %q", step.Name))
+		}
+		names[step.Name] = true
+
+		params, ok := operationParams[step.Operation]
+		if !ok {
+			return nil, badRequest(fmt.Sprintf("step %q: unknown operation %q", step.Name, step.Operation))
+		}
+
+		for p := range step.Params {
+			if _, ok := params[p]; !ok {
+				return nil, badRequest(fmt.Sprintf("step %q: unknown param %q", step.Name, p))
+			}
+		}
+
+		for p, required := range params {
+			if required && step.Params[p] == "" {
+				return nil, badRequest(fmt.Sprintf("step %q: param %q is required", step.Name, p))
+			}
+		}
+
+		if step.Operation == OpWithdrawalCreate && step.Params["amount"] == "" && step.Params["nftId"] == "" {
+			return nil, badRequest(fmt.Sprintf("step %q: param \"amount\" or \"nftId\" is required", step.Name))
+		}
+
+		if req.Ordered {
+			if len(step.DependsOn) > 0 {
+				return nil, badRequest("dependsOn can not be used with ordered steps")
+			}
+			if i > 0 {
+				step.DependsOn = []string{steps[i-1].Name}
+			}
+		}
+
+		steps[i] = step
+	}
+
+	for _, step := range steps {
+		for _, d := range step.DependsOn {
+			if !names[d] || d == step.Name {
+				return nil, badRequest(fmt.Sprintf("step %q: invalid dependency %q", step.Name, d))
+			}
+		}
+	}
+
+	return steps, nil
+}
+
+// sortSteps returns the indexes of steps so that every step comes after the
+// steps it depends on, keeping the request order otherwise.
+func sortSteps(steps []StepRequest) ([]int, error) {
+	done := make(map[string]bool, len(steps))
+	order := make([]int, 0, len(steps))
+
+	for len(order) < len(steps) {
+		progress := false
+
+		for i, step := range steps {
+			if done[step.Name] || !allDone(step.DependsOn, done) {
+				continue
+			}
+			done[step.Name] = true
+			order = append(order, i)
+			progress = true
+		}
+
+		if !progress {
+			return nil, badRequest("steps have circular dependencies")
+		}
+	}
+
+	return order, nil
+}
+
+func allDone(names []string, done map[string]bool) bool {
+	for _, n := range names {
+		if !done[n] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkStepRefs makes sure params only reference the results of steps which
+// have finished before the step runs.
+func checkStepRefs(steps []StepRequest) error {
+	dependsOn := make(map[string][]string, len(steps))
+	for _, step := range steps {
+		dependsOn[step.Name] = step.DependsOn
+	}
+
+	for _, step := range steps {
+		ancestors := make(map[string]bool)
+		collectAncestors(step.Name, dependsOn, ancestors)
+
+		for _, name := range referencedSteps(step) {
+			if !ancestors[name] {
+				return badRequest(fmt.Sprintf("step %q: can only reference the result of steps it depends on, got %q", step.Name, name))
+			}
+		}
+	}
+
+	return nil
+}
+
+func collectAncestors(name string, dependsOn map[string][]string, res map[string]bool) {
+	for _, d := range dependsOn[name] {
+		if !res[d] {
+			res[d] = true
+			collectAncestors(d, dependsOn, res)
+		}
+	}
+}
+
+func referencedSteps(step StepRequest) []string {
+	var res []string
+	for _, v := range step.Params {
+		for _, m := range stepRefRegexp.FindAllStringSubmatch(v, -1) {
+			res = append(res, m[1])
+		}
+	}
+	return res
+}
+
+func badRequest(msg string) error {
+	return &errors.RequestError{
+		StatusCode: http.StatusBadRequest,
+		Err:        fmt.Errorf("%s", msg),
+	}
+}
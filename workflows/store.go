@@ -0,0 +1,13 @@
+package workflows
+
+import (
+	"github.com/google/uuid"
+)
+
+// Store manages data regarding workflows.
+type Store interface {
+	// Workflow returns a workflow with its steps in order.
+	Workflow(id uuid.UUID) (Workflow, error)
+	InsertWorkflow(*Workflow) error
+	InsertSteps([]Step) error
+}
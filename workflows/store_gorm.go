@@ -0,0 +1,31 @@
+package workflows
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) Store {
+	return &GormStore{db}
+}
+
+func (s *GormStore) Workflow(id uuid.UUID) (w Workflow, err error) {
+	err = s.db.
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position asc")
+		}).
+		First(&w, "id = ?", id).Error
+	return
+}
+
+func (s *GormStore) InsertWorkflow(w *Workflow) error {
+	return s.db.Omit("Steps").Create(w).Error
+}
+
+func (s *GormStore) InsertSteps(ss []Step) error {
+	return s.db.Create(&ss).Error
+}
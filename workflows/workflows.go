@@ -0,0 +1,177 @@
+// Package workflows runs multi-step operations, such as creating an account
+// and setting up its token vaults, as jobs which depend on each other.
+package workflows
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/numeroai/flow-wallet-api/jobs"
+	"gorm.io/gorm"
+)
+
+// Operation is a type for the operations a workflow step can run.
+type Operation string
+
+const (
+	OpAccountCreate    Operation = "account_create"
+	OpTokenSetup       Operation = "token_setup"
+	OpWithdrawalCreate Operation = "withdrawal_create"
+)
+
+// operationParams lists the params each operation accepts and whether they
+// are required.
+var operationParams = map[Operation]map[string]bool{
+	OpAccountCreate: {
+		"payer": false,
+	},
+	OpTokenSetup: {
+		"address":      true,
+		"tokenName":    true,
+		"computeLimit": false,
+	},
+	OpWithdrawalCreate: {
+		"sender":       true,
+		"tokenName":    true,
+		"recipient":    true,
+		"amount":       false,
+		"nftId":        false,
+		"payer":        false,
+		"computeLimit": false,
+	},
+}
+
+// State is a type for the aggregate state of a workflow.
+type State string
+
+const (
+	Running  State = "RUNNING"
+	Complete State = "COMPLETE"
+	Failed   State = "FAILED" // Some step failed, was cancelled or skipped
+)
+
+// Workflow database model
+type Workflow struct {
+	ID        uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	Steps     []Step         `gorm:"foreignKey:WorkflowID"`
+	CreatedAt time.Time      `gorm:"column:created_at"`
+	UpdatedAt time.Time      `gorm:"column:updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+}
+
+func (Workflow) TableName() string {
+	return "workflows"
+}
+
+func (w *Workflow) BeforeCreate(tx *gorm.DB) (err error) {
+	w.ID = uuid.New()
+	return nil
+}
+
+// Step database model, each step is executed by a job of type workflow_step.
+type Step struct {
+	ID         uuid.UUID      `gorm:"column:id;primary_key;type:uuid;"`
+	WorkflowID uuid.UUID      `gorm:"column:workflow_id;type:uuid;index"`
+	Position   int            `gorm:"column:position"`
+	Name       string         `gorm:"column:name"`
+	Operation  Operation      `gorm:"column:operation"`
+	DependsOn  pq.StringArray `gorm:"column:depends_on;type:text[]"`
+	JobID      uuid.UUID      `gorm:"column:job_id;type:uuid"`
+	CreatedAt  time.Time      `gorm:"column:created_at"`
+}
+
+func (Step) TableName() string {
+	return "workflow_steps"
+}
+
+func (s *Step) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return nil
+}
+
+// Workflow step HTTP request
+type StepRequest struct {
+	Name      string            `json:"name"`
+	Operation Operation         `json:"operation"`
+	Params    map[string]string `json:"params"`
+	DependsOn []string          `json:"dependsOn"`
+}
+
+// Workflow HTTP request. Ordered steps run one after another, otherwise each
+// step waits only for the steps listed in its dependsOn.
+type JSONRequest struct {
+	Ordered bool          `json:"ordered"`
+	Steps   []StepRequest `json:"steps"`
+}
+
+// Workflow HTTP response
+type JSONResponse struct {
+	ID        uuid.UUID          `json:"workflowId"`
+	State     State              `json:"state"`
+	Steps     []StepJSONResponse `json:"steps"`
+	CreatedAt time.Time          `json:"createdAt"`
+}
+
+// Workflow step HTTP response
+type StepJSONResponse struct {
+	Name        string          `json:"name"`
+	Operation   Operation       `json:"operation"`
+	DependsOn   []string        `json:"dependsOn"`
+	JobID       uuid.UUID       `json:"jobId"`
+	State       jobs.State      `json:"state"`
+	Result      string          `json:"result"`
+	TypedResult json.RawMessage `json:"typedResult"`
+	Error       string          `json:"error"`
+}
+
+// ToJSONResponse combines the workflow with the current state of its step
+// jobs. stepJobs is keyed by job id.
+func (w Workflow) ToJSONResponse(stepJobs map[uuid.UUID]jobs.Job) JSONResponse {
+	res := JSONResponse{
+		ID:        w.ID,
+		State:     Complete,
+		Steps:     make([]StepJSONResponse, len(w.Steps)),
+		CreatedAt: w.CreatedAt,
+	}
+
+	failed := false
+
+	for i, s := range w.Steps {
+		j := stepJobs[s.JobID]
+
+		res.Steps[i] = StepJSONResponse{
+			Name:      s.Name,
+			Operation: s.Operation,
+			DependsOn: nonNil(s.DependsOn),
+			JobID:     s.JobID,
+			State:     j.State,
+			Result:    j.Result,
+			Error:     j.Error,
+		}
+		if len(j.TypedResult) > 0 {
+			res.Steps[i].TypedResult = json.RawMessage(j.TypedResult)
+		}
+
+		switch {
+		case !j.IsFinished():
+			res.State = Running
+		case j.State != jobs.Complete:
+			failed = true
+		}
+	}
+
+	if res.State != Running && failed {
+		res.State = Failed
+	}
+
+	return res
+}
+
+func nonNil(ss []string) []string {
+	if ss == nil {
+		return []string{}
+	}
+	return ss
+}